@@ -0,0 +1,86 @@
+// Command countrycheck lists the country codes of players that are not ISO
+// 3166-1 alpha-2 codes. It exits with status 1 when there are any, unless -fix
+// moved all their players to the code they stand for.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"os/user"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/config"
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+	"github.com/Nikola-Milovic/vyking-interview/internal/service"
+	"github.com/Nikola-Milovic/vyking-interview/internal/store/open"
+)
+
+func main() {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	slog.SetDefault(logger)
+
+	valid, err := run()
+	if err != nil {
+		slog.Error("country check failed", "error", err)
+		os.Exit(1)
+	}
+	if !valid {
+		os.Exit(1)
+	}
+}
+
+func run() (bool, error) {
+	fix := flag.Bool("fix", false, "move the players of known aliases, like UK, to the code they stand for")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx = domain.WithActor(ctx, actor())
+
+	cfg, err := config.LoadFromEnv()
+	if err != nil {
+		return false, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	db, err := open.Database(ctx, cfg.DB)
+	if err != nil {
+		return false, err
+	}
+	defer db.Close()
+	st := db.Store
+
+	// The check does not need country info, so there is no country API client.
+	svc := service.New(st, nil)
+
+	res, err := svc.CheckCountryCodes(ctx, domain.CheckCountryCodesRequest{Fix: *fix})
+	if err != nil {
+		return false, err
+	}
+
+	left := 0
+	for _, invalid := range res.Invalid {
+		slog.Warn("invalid country code",
+			"country_code", invalid.CountryCode,
+			"players", invalid.PlayerCount,
+			"normalized", invalid.Normalized,
+			"fixed", invalid.Fixed)
+		if invalid.Fixed < invalid.PlayerCount {
+			left++
+		}
+	}
+	slog.Info("country check finished", "invalid_codes", len(res.Invalid), "left", left)
+
+	return left == 0, nil
+}
+
+// actor is who the audit log attributes the changes of the command to, the
+// command and the user running it.
+func actor() string {
+	if u, err := user.Current(); err == nil {
+		return "cmd/countrycheck:" + u.Username
+	}
+	return "cmd/countrycheck"
+}
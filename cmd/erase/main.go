@@ -0,0 +1,112 @@
+// Command erase honors a right to be forgotten request by erasing the name and
+// email of a player. The player keeps their bets and the erasure is logged,
+// erasing a player again prints the first erasure. With -list it prints the
+// erasure log instead.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"os/user"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/config"
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+	"github.com/Nikola-Milovic/vyking-interview/internal/service"
+	"github.com/Nikola-Milovic/vyking-interview/internal/store/open"
+)
+
+func main() {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	slog.SetDefault(logger)
+
+	if err := run(); err != nil {
+		slog.Error("erase failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	playerID := flag.Int("player", 0, "ID of the player to erase, or to list the erasures of with -list")
+	requestedBy := flag.String("requested-by", "", "who asked for the erasure, e.g. the support ticket or the operator")
+	reason := flag.String("reason", "", "why the player is erased")
+	list := flag.Bool("list", false, "print the erasure log instead of erasing")
+	limit := flag.Int("limit", 100, "maximum number of erasures to print with -list")
+	flag.Parse()
+
+	if !*list && *playerID <= 0 {
+		return errors.New("-player is required")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx = domain.WithActor(ctx, actor())
+
+	cfg, err := config.LoadFromEnv()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	db, err := open.Database(ctx, cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	st := db.Store
+
+	// Erasures do not need country info, so there is no country API client.
+	svc := service.New(st, nil)
+
+	if *list {
+		resp, err := svc.ListPlayerErasures(ctx, domain.ListPlayerErasuresRequest{
+			PlayerID: *playerID,
+			Limit:    *limit,
+		})
+		if err != nil {
+			return err
+		}
+		for _, e := range resp.Erasures {
+			logErasure("player erasure", e)
+		}
+		return nil
+	}
+
+	resp, err := svc.ErasePlayer(ctx, domain.ErasePlayerRequest{
+		ID:          *playerID,
+		RequestedBy: *requestedBy,
+		Reason:      *reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	msg := "player erased"
+	if resp.Replayed {
+		msg = "player was already erased"
+	}
+	logErasure(msg, resp.Erasure)
+
+	return nil
+}
+
+func logErasure(msg string, e domain.PlayerErasure) {
+	slog.Info(msg,
+		"erasure_id", e.ID,
+		"player_id", e.PlayerID,
+		"requested_by", e.RequestedBy,
+		"reason", e.Reason,
+		"erased_at", e.ErasedAt)
+}
+
+// actor is who the audit log attributes the changes of the command to, the
+// command and the user running it.
+func actor() string {
+	if u, err := user.Current(); err == nil {
+		return "cmd/erase:" + u.Username
+	}
+	return "cmd/erase"
+}
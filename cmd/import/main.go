@@ -0,0 +1,134 @@
+// Command import loads players or bets exported from another platform from
+// CSV or NDJSON files, or from stdin without files. It logs every rejected row
+// and exits with status 1 when a row was rejected.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/config"
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+	"github.com/Nikola-Milovic/vyking-interview/internal/service"
+	"github.com/Nikola-Milovic/vyking-interview/internal/store/open"
+)
+
+func main() {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	slog.SetDefault(logger)
+
+	ok, err := run()
+	if err != nil {
+		slog.Error("import failed", "error", err)
+		os.Exit(1)
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+func run() (bool, error) {
+	kind := flag.String("type", "", "kind of records to import: players or bets")
+	format := flag.String("format", "", "format of the input: csv or ndjson, by default taken from the file extension and csv for stdin")
+	dryRun := flag.Bool("dry-run", false, "validate every row without writing anything")
+	flag.Parse()
+
+	if *kind == "" {
+		return false, errors.New("-type is required")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx = domain.WithActor(ctx, actor())
+
+	cfg, err := config.LoadFromEnv()
+	if err != nil {
+		return false, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	db, err := open.Database(ctx, cfg.DB)
+	if err != nil {
+		return false, err
+	}
+	defer db.Close()
+	st := db.Store
+
+	// Imports do not need country info, so there is no country API client.
+	svc := service.New(st, nil)
+
+	files := flag.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	ok := true
+	for _, file := range files {
+		resp, err := importFile(ctx, svc, file, domain.ImportRequest{
+			Kind:   domain.ImportKind(*kind),
+			Format: domain.ImportFormat(*format),
+			DryRun: *dryRun,
+		})
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", file, err)
+		}
+
+		for _, e := range resp.Errors {
+			slog.Warn("row rejected", "file", file, "row", e.Row, "external_id", e.ExternalID, "error", e.Message)
+		}
+		slog.Info("file imported",
+			"file", file,
+			"dry_run", *dryRun,
+			"rows", resp.Rows,
+			"imported", resp.Imported,
+			"skipped", resp.Skipped,
+			"failed", resp.Failed,
+			"unlisted_failures", resp.ErrorsDropped)
+
+		ok = ok && resp.Failed == 0
+	}
+
+	return ok, nil
+}
+
+// importFile imports a single file, - is stdin. A request without a format
+// gets the one of the file extension.
+func importFile(ctx context.Context, svc service.Service, file string, req domain.ImportRequest) (domain.ImportResponse, error) {
+	var body io.Reader = os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return domain.ImportResponse{}, err
+		}
+		defer f.Close()
+		body = f
+	}
+	req.Body = body
+
+	if req.Format == "" {
+		req.Format = domain.ImportFormatCSV
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".ndjson", ".jsonl":
+			req.Format = domain.ImportFormatNDJSON
+		}
+	}
+
+	return svc.Import(ctx, req)
+}
+
+// actor is who the audit log attributes the changes of the command to, the
+// command and the user running it.
+func actor() string {
+	if u, err := user.Current(); err == nil {
+		return "cmd/import:" + u.Username
+	}
+	return "cmd/import"
+}
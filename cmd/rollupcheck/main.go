@@ -0,0 +1,113 @@
+// Command rollupcheck compares the country stats rollup against the bets and
+// players it is built from. It exits with status 1 when they differ, unless
+// -repair rebuilt the differing days.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/config"
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+	"github.com/Nikola-Milovic/vyking-interview/internal/service"
+	"github.com/Nikola-Milovic/vyking-interview/internal/store/open"
+)
+
+func main() {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	slog.SetDefault(logger)
+
+	consistent, err := run()
+	if err != nil {
+		slog.Error("rollup check failed", "error", err)
+		os.Exit(1)
+	}
+	if !consistent {
+		os.Exit(1)
+	}
+}
+
+func run() (bool, error) {
+	from := flag.String("from", "", "first day to check (YYYY-MM-DD), empty checks from the first bet")
+	to := flag.String("to", "", "day after the last day to check (YYYY-MM-DD), empty checks up to the last bet")
+	repair := flag.Bool("repair", false, "rebuild the days that differ from the bets")
+	flag.Parse()
+
+	req := domain.CheckCountryStatsRollupRequest{Repair: *repair}
+	var err error
+	if req.From, err = parseDay(*from); err != nil {
+		return false, fmt.Errorf("invalid -from: %w", err)
+	}
+	if req.To, err = parseDay(*to); err != nil {
+		return false, fmt.Errorf("invalid -to: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	cfg, err := config.LoadFromEnv()
+	if err != nil {
+		return false, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	db, err := open.Database(ctx, cfg.DB)
+	if err != nil {
+		return false, err
+	}
+	defer db.Close()
+	st := db.Store
+
+	// The check does not need country info, so there is no country API client.
+	svc := service.New(st, nil)
+
+	res, err := svc.CheckCountryStatsRollup(ctx, req)
+	if err != nil {
+		return false, err
+	}
+
+	for _, m := range res.Stats {
+		slog.Warn("country daily stats differ",
+			"country_code", m.Bets.CountryCode,
+			"day", m.Bets.Day.Format(time.DateOnly),
+			"currency", m.Bets.Currency,
+			"rollup", dailyStatsAttrs(m.Rollup),
+			"bets", dailyStatsAttrs(m.Bets))
+	}
+	for _, m := range res.Activity {
+		slog.Warn("active players differ",
+			"country_code", m.Bets.CountryCode,
+			"day", m.Bets.Day.Format(time.DateOnly),
+			"rollup", m.Rollup.ActivePlayers,
+			"bets", m.Bets.ActivePlayers)
+	}
+	for _, day := range res.Repaired {
+		slog.Info("rebuilt rollup day", "day", day.Format(time.DateOnly))
+	}
+
+	mismatches := len(res.Stats) + len(res.Activity)
+	slog.Info("rollup check finished", "mismatches", mismatches, "repaired_days", len(res.Repaired))
+
+	return mismatches == 0 || *repair, nil
+}
+
+func parseDay(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.DateOnly, s)
+}
+
+func dailyStatsAttrs(s domain.CountryDailyStats) slog.Value {
+	return slog.GroupValue(
+		slog.Int("bet_count", s.BetCount),
+		slog.String("total_amount", s.TotalAmount.String()),
+		slog.String("settled_stakes", s.SettledStakes.String()),
+		slog.String("payouts", s.Payouts.String()),
+		slog.Int("win_count", s.WinCount),
+	)
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+// maxBet caps generated bets, in EUR, so the tail of the whale distribution
+// stays within the bet columns in every currency.
+const maxBet = 50000
+
+// timestampLayout is how timestamps are bound, in UTC. Both drivers store it
+// as is, the MySQL driver sends time.Time values the same way.
+const timestampLayout = "2006-01-02 15:04:05"
+
+var (
+	firstNames = []string{"Ana", "Marko", "Maria", "Klaus", "Emma", "Lucas", "Sofia", "James", "Elena", "Noah", "Mia", "Luca", "Olivia", "Erik", "Chloe", "Pablo"}
+	lastNames  = []string{"Petrović", "Weber", "Santos", "Smith", "Rossi", "García", "Martin", "Johansson", "Müller", "Silva", "Jones", "Bianchi", "Dubois", "Jovanović", "Lindqvist", "Fernández"}
+
+	// countryCurrencies is the currency players of a country bet in, EUR for
+	// countries not listed.
+	countryCurrencies = map[string]string{
+		"RS": "RSD",
+		"BR": "BRL",
+	}
+)
+
+type options struct {
+	Players   int
+	Bets      int
+	Countries []weight
+	From, To  time.Time
+	Seed      uint64
+	BatchSize int
+
+	BetMedian    domain.Money
+	WhaleShare   float64
+	WhaleMedian  domain.Money
+	SettledShare float64
+	WinRate      float64
+
+	// Rates are the EUR exchange rates bets are converted with.
+	Rates map[string]float64
+}
+
+func (o options) validate() error {
+	switch {
+	case o.Players < 1:
+		return errors.New("-players must be greater than 0")
+	case o.Bets < 0:
+		return errors.New("-bets must not be negative")
+	case !o.From.Before(o.To):
+		return errors.New("-from must be before -to")
+	case o.BatchSize < 1:
+		return errors.New("-batch-size must be greater than 0")
+	case o.BetMedian <= 0 || o.WhaleMedian <= 0:
+		return errors.New("-bet-median and -whale-median must be greater than 0")
+	case !isShare(o.WhaleShare) || !isShare(o.SettledShare) || !isShare(o.WinRate):
+		return errors.New("-whale-share, -settled and -win-rate must be between 0 and 1")
+	}
+	return nil
+}
+
+func isShare(f float64) bool {
+	return f >= 0 && f <= 1
+}
+
+type weight struct {
+	Value  string
+	Weight float64
+}
+
+// parseWeights parses a list like "RS=30,DE=20", the weights do not have to
+// add up to anything.
+func parseWeights(s string) ([]weight, error) {
+	var weights []weight
+	for _, part := range strings.Split(s, ",") {
+		value, w, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not VALUE=WEIGHT", part)
+		}
+		f, err := strconv.ParseFloat(w, 64)
+		if err != nil || f <= 0 {
+			return nil, fmt.Errorf("invalid weight %q", w)
+		}
+		weights = append(weights, weight{Value: strings.ToUpper(value), Weight: f})
+	}
+	return weights, nil
+}
+
+// picker picks indexes with probabilities proportional to their weights.
+type picker []float64
+
+func newPicker(weights []float64) picker {
+	cumulative := make(picker, len(weights))
+	var total float64
+	for i, w := range weights {
+		total += w
+		cumulative[i] = total
+	}
+	return cumulative
+}
+
+func (p picker) pick(rng *rand.Rand) int {
+	i := sort.SearchFloat64s(p, rng.Float64()*p[len(p)-1])
+	return min(i, len(p)-1)
+}
+
+// player is what bets need to know about a generated player.
+type player struct {
+	id        int64
+	currency  string
+	whale     bool
+	createdAt time.Time
+}
+
+type seeder struct {
+	db      *sql.DB
+	opts    options
+	rng     *rand.Rand
+	players []player
+	// activity picks the player of a bet, some players bet far more often
+	// than others.
+	activity picker
+}
+
+func newSeeder(db *sql.DB, opts options) *seeder {
+	return &seeder{
+		db:   db,
+		opts: opts,
+		rng:  rand.New(rand.NewPCG(opts.Seed, 0)),
+	}
+}
+
+func (s *seeder) insertPlayers(ctx context.Context) error {
+	var maxID int64
+	if err := s.db.QueryRowContext(ctx, "SELECT COALESCE(MAX(id), 0) FROM players").Scan(&maxID); err != nil {
+		return fmt.Errorf("failed to query players: %w", err)
+	}
+
+	countryWeights := make([]float64, len(s.opts.Countries))
+	for i, c := range s.opts.Countries {
+		countryWeights[i] = c.Weight
+	}
+	countries := newPicker(countryWeights)
+	span := s.opts.To.Sub(s.opts.From)
+
+	s.players = make([]player, s.opts.Players)
+	activity := make([]float64, s.opts.Players)
+	columns := []string{"name", "email", "country_code", "created_at", "updated_at"}
+
+	err := s.insertRows(ctx, "players", columns, s.opts.Players, func(i int) []any {
+		country := s.opts.Countries[countries.pick(s.rng)].Value
+		currency, ok := countryCurrencies[country]
+		if _, known := s.opts.Rates[currency]; !ok || !known {
+			currency = "EUR"
+		}
+
+		p := player{
+			currency:  currency,
+			whale:     s.rng.Float64() < s.opts.WhaleShare,
+			createdAt: s.opts.From.Add(time.Duration(s.rng.Int64N(int64(span)))).Truncate(time.Second),
+		}
+		s.players[i] = p
+		activity[i] = math.Exp(s.rng.NormFloat64())
+
+		name := firstNames[s.rng.IntN(len(firstNames))] + " " + lastNames[s.rng.IntN(len(lastNames))]
+		email := fmt.Sprintf("seed%d.player%d@example.com", s.opts.Seed, maxID+int64(i)+1)
+		createdAt := p.createdAt.Format(timestampLayout)
+		return []any{name, email, country, createdAt, createdAt}
+	})
+	if err != nil {
+		return err
+	}
+
+	// Rows of a single connection are numbered in insert order, so the new
+	// players are the ones after the highest id before.
+	rows, err := s.db.QueryContext(ctx, "SELECT id FROM players WHERE id > ? ORDER BY id", maxID)
+	if err != nil {
+		return fmt.Errorf("failed to query players: %w", err)
+	}
+	defer rows.Close()
+
+	i := 0
+	for rows.Next() {
+		if i == len(s.players) {
+			return errors.New("players were created while seeding")
+		}
+		if err := rows.Scan(&s.players[i].id); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+		i++
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating rows: %w", err)
+	}
+	if i != len(s.players) {
+		return fmt.Errorf("created %d players but found %d", len(s.players), i)
+	}
+
+	s.activity = newPicker(activity)
+	return nil
+}
+
+func (s *seeder) insertBets(ctx context.Context) error {
+	columns := []string{"player_id", "amount", "currency", "status", "payout", "settled_at", "created_at"}
+
+	return s.insertRows(ctx, "bets", columns, s.opts.Bets, func(int) []any {
+		p := s.players[s.activity.pick(s.rng)]
+
+		median := s.opts.BetMedian
+		if p.whale {
+			median = s.opts.WhaleMedian
+		}
+		eur := min(median.Float64()*math.Exp(s.rng.NormFloat64()), maxBet)
+		rate := s.opts.Rates[p.currency]
+		amount := domain.MoneyFromCents(max(int64(math.Round(eur*rate*100)), 10))
+
+		createdAt := p.createdAt.Add(time.Duration(s.rng.Int64N(int64(s.opts.To.Sub(p.createdAt))))).Truncate(time.Second)
+
+		status, payout, settledAt := domain.BetStatusOpen, domain.Money(0), any(nil)
+		if s.rng.Float64() < s.opts.SettledShare {
+			status = domain.BetStatusLost
+			if s.rng.Float64() < s.opts.WinRate {
+				status = domain.BetStatusWon
+				payout = domain.MoneyFromCents(int64(math.Round(float64(amount.Cents()) * (1.2 + 1.8*s.rng.Float64()))))
+			}
+			settledAt = createdAt.Add(time.Duration(s.rng.Int64N(int64(time.Hour)))).Format(timestampLayout)
+		}
+
+		return []any{p.id, amount, p.currency, string(status), payout, settledAt, createdAt.Format(timestampLayout)}
+	})
+}
+
+// insertRows inserts n rows into table, batching them into multi-row inserts
+// of the configured size. row returns the values of the i-th row in the order
+// of columns.
+func (s *seeder) insertRows(ctx context.Context, table string, columns []string, n int, row func(i int) []any) error {
+	placeholder := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+	prefix := fmt.Sprintf("INSERT INTO %s (%s) VALUES ", table, strings.Join(columns, ", "))
+
+	reported := 0
+	for start := 0; start < n; start += s.opts.BatchSize {
+		size := min(s.opts.BatchSize, n-start)
+
+		args := make([]any, 0, size*len(columns))
+		for i := start; i < start+size; i++ {
+			args = append(args, row(i)...)
+		}
+
+		query := prefix + strings.TrimSuffix(strings.Repeat(placeholder+", ", size), ", ")
+		if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("failed to insert %s: %w", table, err)
+		}
+
+		if done := start + size; done*10/n > reported {
+			reported = done * 10 / n
+			slog.Info("seeding", "table", table, "rows", done, "total", n)
+		}
+	}
+	return nil
+}
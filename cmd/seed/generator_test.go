@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+	"github.com/Nikola-Milovic/vyking-interview/internal/store/sqlite"
+	"github.com/Nikola-Milovic/vyking-interview/internal/testutil"
+)
+
+func testOptions(seed uint64) options {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	return options{
+		Players:      2000,
+		Bets:         10000,
+		Countries:    []weight{{"RS", 50}, {"DE", 30}, {"BR", 20}},
+		From:         from,
+		To:           from.AddDate(0, 0, 30),
+		Seed:         seed,
+		BatchSize:    500,
+		BetMedian:    domain.MoneyFromCents(1000),
+		WhaleShare:   0.1,
+		WhaleMedian:  domain.MoneyFromCents(100000),
+		SettledShare: 0.9,
+		WinRate:      0.45,
+	}
+}
+
+// seed runs the generator on a fresh database and returns it with the seeder.
+func seed(t *testing.T, opts options) (*sql.DB, *seeder) {
+	t.Helper()
+	ctx := context.Background()
+
+	db, cleanup := testutil.SetupSQLite(t)
+	t.Cleanup(cleanup)
+
+	rates, err := sqlite.New(db).ListExchangeRates(ctx, domain.ListExchangeRatesQuery{})
+	require.NoError(t, err)
+	opts.Rates = latestRates(rates.Rates)
+
+	s := newSeeder(db, opts)
+	require.NoError(t, s.insertPlayers(ctx))
+	require.NoError(t, s.insertBets(ctx))
+	return db, s
+}
+
+// dump returns every row of the query as text.
+func dump(t *testing.T, db *sql.DB, query string) []string {
+	t.Helper()
+
+	rows, err := db.Query(query)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	require.NoError(t, err)
+
+	var dumped []string
+	for rows.Next() {
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		require.NoError(t, rows.Scan(pointers...))
+		dumped = append(dumped, fmt.Sprint(values...))
+	}
+	require.NoError(t, rows.Err())
+	return dumped
+}
+
+// The generated players and bets, the test data of the migrations is random
+// and the bets are numbered after it.
+const (
+	dumpPlayers = "SELECT id, name, email, country_code, created_at FROM players WHERE email LIKE 'seed%' ORDER BY id"
+	dumpBets    = `
+		SELECT b.player_id, b.amount, b.currency, b.status, b.payout, b.settled_at, b.created_at
+		FROM bets b
+		JOIN players p ON p.id = b.player_id
+		WHERE p.email LIKE 'seed%'
+		ORDER BY b.id`
+)
+
+func TestSeeder_Deterministic(t *testing.T) {
+	opts := testOptions(7)
+	first, _ := seed(t, opts)
+	second, _ := seed(t, opts)
+	other, _ := seed(t, testOptions(8))
+
+	players := dump(t, first, dumpPlayers)
+	bets := dump(t, first, dumpBets)
+	require.Len(t, players, opts.Players)
+	require.Len(t, bets, opts.Bets)
+	assert.Equal(t, players, dump(t, second, dumpPlayers))
+	assert.Equal(t, bets, dump(t, second, dumpBets))
+
+	assert.NotEqual(t, bets, dump(t, other, dumpBets))
+}
+
+func TestSeeder_Distribution(t *testing.T) {
+	opts := testOptions(7)
+	db, s := seed(t, opts)
+
+	seeded := func(query string) map[string]float64 {
+		t.Helper()
+
+		rows, err := db.Query(query)
+		require.NoError(t, err)
+		defer rows.Close()
+
+		counts := map[string]float64{}
+		for rows.Next() {
+			var (
+				key   string
+				count float64
+			)
+			require.NoError(t, rows.Scan(&key, &count))
+			counts[key] = count
+		}
+		require.NoError(t, rows.Err())
+		return counts
+	}
+
+	countries := seeded("SELECT country_code, COUNT(*) FROM players WHERE email LIKE 'seed7.%' GROUP BY country_code")
+	for _, c := range opts.Countries {
+		assert.InDelta(t, c.Weight/100, countries[c.Value]/float64(opts.Players), 0.03, "share of %s players", c.Value)
+	}
+
+	// Players bet in the currency of their country.
+	currencies := seeded(`
+		SELECT p.country_code || ' ' || b.currency, COUNT(*)
+		FROM bets b
+		JOIN players p ON p.id = b.player_id
+		WHERE p.email LIKE 'seed7.%'
+		GROUP BY p.country_code, b.currency`)
+	assert.ElementsMatch(t, []string{"RS RSD", "DE EUR", "BR BRL"}, keys(currencies))
+
+	whales := 0
+	for _, p := range s.players {
+		if p.whale {
+			whales++
+		}
+	}
+	assert.InDelta(t, opts.WhaleShare, float64(whales)/float64(opts.Players), 0.02)
+
+	statuses := seeded("SELECT status, COUNT(*) FROM bets WHERE player_id IN (SELECT id FROM players WHERE email LIKE 'seed7.%') GROUP BY status")
+	settled := statuses["won"] + statuses["lost"]
+	assert.InDelta(t, opts.SettledShare, settled/float64(opts.Bets), 0.02)
+	assert.InDelta(t, opts.WinRate, statuses["won"]/settled, 0.02)
+
+	// Whales bet around their own median, a hundred times the regular one,
+	// so nearly every bet above the geometric mean of both is a whale's.
+	sizes := seeded(`
+		SELECT CASE WHEN b.amount >= 100 THEN 'whale' ELSE 'regular' END, COUNT(*)
+		FROM bets b
+		JOIN players p ON p.id = b.player_id
+		WHERE p.email LIKE 'seed7.%' AND b.currency = 'EUR'
+		GROUP BY 1`)
+	assert.InDelta(t, opts.WhaleShare, sizes["whale"]/(sizes["whale"]+sizes["regular"]), 0.05)
+}
+
+func keys(m map[string]float64) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,143 @@
+// Command seed fills the database with synthetic players and bets, to
+// benchmark the reporting queries and the cache under realistic volume. The
+// same flags generate the same data, the dates default to fixed days as well.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/config"
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+	"github.com/Nikola-Milovic/vyking-interview/internal/store/open"
+)
+
+func main() {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	slog.SetDefault(logger)
+
+	if err := run(); err != nil {
+		slog.Error("seed failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var (
+		opts        options
+		countries   string
+		from, to    string
+		betMedian   float64
+		whaleMedian float64
+	)
+	flag.IntVar(&opts.Players, "players", 100000, "number of players to create")
+	flag.IntVar(&opts.Bets, "bets", 1000000, "number of bets to place")
+	flag.StringVar(&countries, "countries", "US=20,GB=15,DE=15,BR=12,RS=10,FR=8,ES=8,IT=7,SE=5", "weighted country distribution of the players")
+	flag.StringVar(&from, "from", "2025-01-01", "first day bets are placed on (YYYY-MM-DD)")
+	flag.StringVar(&to, "to", "2025-04-01", "day after the last day bets are placed on (YYYY-MM-DD)")
+	flag.Uint64Var(&opts.Seed, "seed", 1, "seed of the random generator")
+	flag.IntVar(&opts.BatchSize, "batch-size", 1000, "rows per insert statement")
+	flag.Float64Var(&betMedian, "bet-median", 10, "median bet of regular players in EUR")
+	flag.Float64Var(&opts.WhaleShare, "whale-share", 0.01, "share of players that are whales")
+	flag.Float64Var(&whaleMedian, "whale-median", 1000, "median bet of whales in EUR")
+	flag.Float64Var(&opts.SettledShare, "settled", 0.9, "share of bets that are settled")
+	flag.Float64Var(&opts.WinRate, "win-rate", 0.45, "share of settled bets that are won")
+	flag.Parse()
+
+	var err error
+	if opts.Countries, err = parseWeights(countries); err != nil {
+		return fmt.Errorf("invalid -countries: %w", err)
+	}
+	// Players are inserted directly, so their codes are validated here.
+	for i, c := range opts.Countries {
+		code, ok := domain.NormalizeCountryCode(c.Value)
+		if !ok {
+			return fmt.Errorf("invalid -countries: %q is not an ISO 3166-1 alpha-2 code", c.Value)
+		}
+		opts.Countries[i].Value = code
+	}
+	if opts.From, err = time.Parse(time.DateOnly, from); err != nil {
+		return fmt.Errorf("invalid -from: %w", err)
+	}
+	if opts.To, err = time.Parse(time.DateOnly, to); err != nil {
+		return fmt.Errorf("invalid -to: %w", err)
+	}
+	opts.BetMedian = domain.MoneyFromCents(int64(betMedian * 100))
+	opts.WhaleMedian = domain.MoneyFromCents(int64(whaleMedian * 100))
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	cfg, err := config.LoadFromEnv()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	// Rebuilding the rollup of every seeded day is a single transaction that
+	// takes far longer than a query of the API.
+	cfg.DB.QueryTimeout = 0
+
+	db, err := open.Database(ctx, cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	st := db.Store
+
+	rates, err := st.ListExchangeRates(ctx, domain.ListExchangeRatesQuery{})
+	if err != nil {
+		return err
+	}
+	opts.Rates = latestRates(rates.Rates)
+
+	start := time.Now()
+	seeder := newSeeder(db.DB, opts)
+
+	if err := seeder.insertPlayers(ctx); err != nil {
+		return err
+	}
+	slog.Info("players created", "count", opts.Players, "duration", time.Since(start))
+
+	if err := seeder.insertBets(ctx); err != nil {
+		return err
+	}
+	slog.Info("bets placed", "count", opts.Bets, "duration", time.Since(start))
+
+	// The bets are inserted directly, so the rollup of the days they were
+	// placed on is rebuilt once at the end.
+	err = st.RebuildCountryDailyStats(ctx, domain.RebuildCountryDailyStatsQuery{From: opts.From, To: opts.To})
+	if err != nil {
+		return err
+	}
+
+	slog.Info("seed finished",
+		"players", opts.Players,
+		"bets", opts.Bets,
+		"seed", opts.Seed,
+		"duration", time.Since(start))
+	return nil
+}
+
+// latestRates returns the most recent rate of every currency.
+func latestRates(rates []domain.ExchangeRate) map[string]float64 {
+	latest := make(map[string]domain.ExchangeRate, len(rates))
+	for _, rate := range rates {
+		if current, ok := latest[rate.Currency]; !ok || rate.EffectiveFrom.After(current.EffectiveFrom) {
+			latest[rate.Currency] = rate
+		}
+	}
+
+	// EUR is the currency rates are quoted against.
+	result := map[string]float64{"EUR": 1}
+	for currency, rate := range latest {
+		result[currency] = rate.Rate
+	}
+	return result
+}
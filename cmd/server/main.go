@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"log/slog"
 	"net"
@@ -16,8 +15,8 @@ import (
 	"github.com/Nikola-Milovic/vyking-interview/internal/config"
 	"github.com/Nikola-Milovic/vyking-interview/internal/service"
 	"github.com/Nikola-Milovic/vyking-interview/internal/store"
+	"github.com/Nikola-Milovic/vyking-interview/internal/store/open"
 	httpTransport "github.com/Nikola-Milovic/vyking-interview/internal/transport/http"
-	_ "github.com/go-sql-driver/mysql"
 )
 
 func main() {
@@ -26,6 +25,14 @@ func main() {
 	}))
 	slog.SetDefault(logger)
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			slog.Error("migration failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if err := run(); err != nil {
 		slog.Error("application failed", "error", err)
 		os.Exit(1)
@@ -44,26 +51,31 @@ func run() (err error) {
 	}
 
 	slog.Info("configuration loaded",
+		"db_driver", cfg.DB.Driver,
 		"db_host", cfg.DB.Host,
 		"db_name", cfg.DB.Name,
 		"server_port", cfg.Server.Port,
 		"cache_size", cfg.Cache.Size,
-		"cache_ttl", cfg.Cache.TTL)
+		"cache_ttl", cfg.Cache.TTL,
+		"db_replicas", len(cfg.DB.ReplicaHosts),
+		"auto_migrate", cfg.DB.AutoMigrate)
+
+	// SQLite databases are always migrated when they are opened.
+	if cfg.DB.AutoMigrate && cfg.DB.Driver == config.DriverMySQL {
+		slog.Info("applying pending migrations")
+		if err := withMigrator(cfg.DB, func(m *store.Migrator) error { return m.Up(0) }); err != nil {
+			return fmt.Errorf("failed to migrate database: %w", err)
+		}
+	}
 
-	slog.Info("connecting to database")
-	db, err := sql.Open("mysql", cfg.DB.DSN())
+	db, err := open.DatabaseWithReplicas(ctx, cfg.DB)
 	if err != nil {
-		return fmt.Errorf("failed to open database: %w", err)
+		return err
 	}
 	defer db.Close()
-
-	if err := db.Ping(); err != nil {
-		return fmt.Errorf("failed to ping database: %w", err)
-	}
-	slog.Info("database connection established")
+	store := db.Store
 
 	cache := memory.New(cfg.Cache.Size, cfg.Cache.TTL)
-	store := store.New(db)
 	countryClient := clients.NewRestCountriesClient(cache, cfg.Cache.TTL)
 	svc := service.New(store, countryClient)
 
@@ -72,7 +84,7 @@ func run() (err error) {
 		BaseContext:  func(_ net.Listener) context.Context { return ctx },
 		ReadTimeout:  cfg.Server.ReadTimeout,
 		WriteTimeout: cfg.Server.WriteTimeout,
-		Handler:      newHTTPHandler(svc),
+		Handler:      newHTTPHandler(svc, cfg.Server.AdminToken),
 	}
 	srvErr := make(chan error, 1)
 	go func() {
@@ -96,10 +108,10 @@ func run() (err error) {
 	return
 }
 
-func newHTTPHandler(svc service.Service) http.Handler {
+func newHTTPHandler(svc service.Service, adminToken string) http.Handler {
 	mux := http.NewServeMux()
 
-	handler := httpTransport.NewHandler(svc)
+	handler := httpTransport.NewHandler(svc, adminToken)
 	handler.RegisterRoutes(mux)
 
 	return mux
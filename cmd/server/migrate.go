@@ -0,0 +1,102 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/config"
+	"github.com/Nikola-Milovic/vyking-interview/internal/store"
+)
+
+const migrateUsage = "usage: server migrate up [N] | down [N] | status | force VERSION"
+
+// runMigrate runs the migrate subcommand with the arguments that follow it.
+// Without N, up applies every pending migration and down reverts the last one.
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	cfg, err := config.LoadFromEnv()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if cfg.DB.Driver != config.DriverMySQL {
+		return fmt.Errorf("migrations only apply to %s, %s databases are migrated when they are opened", config.DriverMySQL, cfg.DB.Driver)
+	}
+
+	return withMigrator(cfg.DB, func(m *store.Migrator) error {
+		switch command, args := args[0], args[1:]; command {
+		case "up":
+			steps, err := parseSteps(args, 0)
+			if err != nil {
+				return err
+			}
+			return m.Up(steps)
+		case "down":
+			steps, err := parseSteps(args, 1)
+			if err != nil {
+				return err
+			}
+			return m.Down(steps)
+		case "status":
+			status, err := m.Status()
+			if err != nil {
+				return err
+			}
+			slog.Info("migration status",
+				"version", status.Version,
+				"dirty", status.Dirty,
+				"latest", status.Latest,
+				"pending", status.Pending)
+			return nil
+		case "force":
+			if len(args) != 1 {
+				return errors.New(migrateUsage)
+			}
+			version, err := strconv.Atoi(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid version %q: %w", args[0], err)
+			}
+			return m.Force(version)
+		default:
+			return errors.New(migrateUsage)
+		}
+	})
+}
+
+// withMigrator runs fn with a migrator on its own connection to the primary,
+// the connection of the service does not allow multiple statements.
+func withMigrator(cfg config.DatabaseConfig, fn func(m *store.Migrator) error) error {
+	db, err := sql.Open("mysql", cfg.MigrationDSN())
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	m, err := store.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	return fn(m)
+}
+
+func parseSteps(args []string, defaultSteps int) (int, error) {
+	switch len(args) {
+	case 0:
+		return defaultSteps, nil
+	case 1:
+		steps, err := strconv.Atoi(args[0])
+		if err != nil || steps < 1 {
+			return 0, fmt.Errorf("invalid number of migrations %q", args[0])
+		}
+		return steps, nil
+	default:
+		return 0, errors.New(migrateUsage)
+	}
+}
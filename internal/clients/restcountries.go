@@ -32,6 +32,7 @@ func NewRestCountriesClient(cache cache.Cache, cacheTTL time.Duration) *RestCoun
 }
 
 type countryResponse struct {
+	Code string `json:"cca2"`
 	Name struct {
 		Common string `json:"common"`
 	} `json:"name"`
@@ -40,6 +41,12 @@ type countryResponse struct {
 }
 
 func (c *RestCountriesClient) GetCountryInfo(ctx context.Context, countryCode string) (domain.CountryInfo, error) {
+	// Players stored before codes were validated may have aliases like UK,
+	// which RestCountries does not resolve.
+	if normalized, ok := domain.NormalizeCountryCode(countryCode); ok {
+		countryCode = normalized
+	}
+
 	cacheKey := strings.ToLower(countryCode)
 
 	if cached, found := c.cache.Get(ctx, cacheKey); found {
@@ -77,6 +84,7 @@ func (c *RestCountriesClient) GetCountryInfo(ctx context.Context, countryCode st
 
 	country := countries[0]
 	info := domain.CountryInfo{
+		Code:    country.Code,
 		Name:    country.Name.Common,
 		Region:  country.Region,
 		Borders: country.Borders,
@@ -2,8 +2,10 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,18 +15,50 @@ type Config struct {
 	Cache    CacheConfig
 }
 
+// Database drivers DB_DRIVER selects between. SQLite needs no server and is
+// meant for local development.
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite"
+)
+
 type DatabaseConfig struct {
+	Driver string
+	// SQLitePath is the database file of the SQLite driver, it is created on
+	// first use. ":memory:" keeps the database in memory.
+	SQLitePath string
+
 	Host     string
 	Port     int
 	User     string
 	Password string
 	Name     string
+	// AutoMigrate applies pending migrations when the server starts.
+	AutoMigrate bool
+
+	// ReplicaHosts are host or host:port addresses of read replicas, they use
+	// the same credentials and database name as the primary.
+	ReplicaHosts []string
+	// MaxReplicaLag is how far a replica may fall behind the primary before
+	// reads go back to the primary.
+	MaxReplicaLag        time.Duration
+	ReplicaCheckInterval time.Duration
+
+	// QueryTimeout bounds every store call, a query and reading its rows or a
+	// whole transaction.
+	QueryTimeout time.Duration
+	// MaxQueryAttempts is how often reads and idempotent writes are tried when
+	// MySQL fails with a deadlock, a lock wait timeout or a lost connection.
+	MaxQueryAttempts int
 }
 
 type ServerConfig struct {
 	Port         int
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
+	// AdminToken is the bearer token of the admin endpoints, they are
+	// disabled without one.
+	AdminToken string
 }
 
 type CacheConfig struct {
@@ -35,15 +69,24 @@ type CacheConfig struct {
 func LoadFromEnv() (Config, error) {
 	cfg := Config{}
 
+	cfg.DB.Driver = getEnv("DB_DRIVER", DriverMySQL)
+	cfg.DB.SQLitePath = getEnv("DB_SQLITE_PATH", "player_activity.db")
 	cfg.DB.Host = getEnv("DB_HOST", "localhost")
 	cfg.DB.Port = getEnvAsInt("DB_PORT", 3306)
 	cfg.DB.User = getRequiredEnv("DB_USER")
 	cfg.DB.Password = getRequiredEnv("DB_PASSWORD")
 	cfg.DB.Name = getEnv("DB_NAME", "player_activity")
+	cfg.DB.AutoMigrate = getEnvAsBool("AUTO_MIGRATE", false)
+	cfg.DB.ReplicaHosts = getEnvAsList("DB_REPLICA_HOSTS")
+	cfg.DB.MaxReplicaLag = time.Duration(getEnvAsInt("DB_MAX_REPLICA_LAG", 5)) * time.Second
+	cfg.DB.ReplicaCheckInterval = time.Duration(getEnvAsInt("DB_REPLICA_CHECK_INTERVAL", 5)) * time.Second
+	cfg.DB.QueryTimeout = time.Duration(getEnvAsInt("DB_QUERY_TIMEOUT", 30)) * time.Second
+	cfg.DB.MaxQueryAttempts = getEnvAsInt("DB_MAX_QUERY_ATTEMPTS", 3)
 
 	cfg.Server.Port = getEnvAsInt("SERVER_PORT", 8080)
 	cfg.Server.ReadTimeout = time.Duration(getEnvAsInt("SERVER_READ_TIMEOUT", 5)) * time.Second
 	cfg.Server.WriteTimeout = time.Duration(getEnvAsInt("SERVER_WRITE_TIMEOUT", 10)) * time.Second
+	cfg.Server.AdminToken = getEnv("ADMIN_TOKEN", "")
 
 	cfg.Cache.TTL = time.Duration(getEnvAsInt("CACHE_TTL", 60)) * time.Minute
 	cfg.Cache.Size = getEnvAsInt("CACHE_SIZE", 1000)
@@ -56,11 +99,23 @@ func LoadFromEnv() (Config, error) {
 }
 
 func (c Config) validate() error {
-	if c.DB.User == "" {
-		return fmt.Errorf("DB_USER is required")
-	}
-	if c.DB.Password == "" {
-		return fmt.Errorf("DB_PASSWORD is required")
+	switch c.DB.Driver {
+	case DriverMySQL:
+		if c.DB.User == "" {
+			return fmt.Errorf("DB_USER is required")
+		}
+		if c.DB.Password == "" {
+			return fmt.Errorf("DB_PASSWORD is required")
+		}
+	case DriverSQLite:
+		if c.DB.SQLitePath == "" {
+			return fmt.Errorf("DB_SQLITE_PATH is required")
+		}
+		if len(c.DB.ReplicaHosts) > 0 {
+			return fmt.Errorf("DB_REPLICA_HOSTS is not supported with the %s driver", DriverSQLite)
+		}
+	default:
+		return fmt.Errorf("DB_DRIVER must be %s or %s", DriverMySQL, DriverSQLite)
 	}
 	if c.Server.Port < 1 || c.Server.Port > 65535 {
 		return fmt.Errorf("SERVER_PORT must be between 1 and 65535")
@@ -68,6 +123,18 @@ func (c Config) validate() error {
 	if c.Cache.Size < 1 {
 		return fmt.Errorf("CACHE_SIZE must be greater than 0")
 	}
+	if len(c.DB.ReplicaHosts) > 0 && c.DB.ReplicaCheckInterval <= 0 {
+		return fmt.Errorf("DB_REPLICA_CHECK_INTERVAL must be greater than 0")
+	}
+	if c.DB.MaxReplicaLag < 0 {
+		return fmt.Errorf("DB_MAX_REPLICA_LAG must not be negative")
+	}
+	if c.DB.QueryTimeout <= 0 {
+		return fmt.Errorf("DB_QUERY_TIMEOUT must be greater than 0")
+	}
+	if c.DB.MaxQueryAttempts < 1 {
+		return fmt.Errorf("DB_MAX_QUERY_ATTEMPTS must be at least 1")
+	}
 	return nil
 }
 
@@ -93,6 +160,48 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 func (c DatabaseConfig) DSN() string {
-	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true", c.User, c.Password, c.Host, c.Port, c.Name)
+	return c.dsn(net.JoinHostPort(c.Host, strconv.Itoa(c.Port)))
+}
+
+// MigrationDSN returns the DSN of the primary with multiple statements per
+// query allowed, which the migrations need.
+func (c DatabaseConfig) MigrationDSN() string {
+	return c.DSN() + "&multiStatements=true"
+}
+
+// ReplicaDSNs returns the DSN of every replica in ReplicaHosts, replicas
+// without a port use the port of the primary.
+func (c DatabaseConfig) ReplicaDSNs() []string {
+	dsns := make([]string, 0, len(c.ReplicaHosts))
+	for _, host := range c.ReplicaHosts {
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			host = net.JoinHostPort(host, strconv.Itoa(c.Port))
+		}
+		dsns = append(dsns, c.dsn(host))
+	}
+	return dsns
+}
+
+// dsn pins the session time zone to UTC, DATE() and CURRENT_TIMESTAMP of the
+// rollup and the defaults then agree with the UTC days of the store.
+func (c DatabaseConfig) dsn(addr string) string {
+	return fmt.Sprintf("%s:%s@tcp(%s)/%s?parseTime=true&loc=UTC&time_zone=%%27%%2B00%%3A00%%27", c.User, c.Password, addr, c.Name)
 }
\ No newline at end of file
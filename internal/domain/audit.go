@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// AuditEntry records a change of a player or bet in the audit log.
+type AuditEntry struct {
+	ID         int
+	EntityType AuditEntityType
+	EntityID   int
+	Action     AuditAction
+	// Actor identifies who made the change, see WithActor.
+	Actor string
+	// Before and After are the audit values of the entity before and after the
+	// change, nil when it did not exist.
+	Before    map[string]any
+	After     map[string]any
+	CreatedAt time.Time
+	// RedactedAt is set once the personal data in Before and After was
+	// replaced because the player was erased.
+	RedactedAt time.Time
+}
+
+type AuditEntityType string
+
+const (
+	AuditEntityPlayer AuditEntityType = "player"
+	AuditEntityBet    AuditEntityType = "bet"
+)
+
+func (t AuditEntityType) Valid() bool {
+	switch t {
+	case AuditEntityPlayer, AuditEntityBet:
+		return true
+	}
+	return false
+}
+
+type AuditAction string
+
+const (
+	AuditActionCreate AuditAction = "create"
+	AuditActionUpdate AuditAction = "update"
+	AuditActionDelete AuditAction = "delete"
+	AuditActionSettle AuditAction = "settle"
+	AuditActionErase  AuditAction = "erase"
+	AuditActionImport AuditAction = "import"
+)
+
+// UnknownActor is the actor of changes made without one in the context.
+const UnknownActor = "unknown"
+
+type actorKey struct{}
+
+// WithActor returns a context whose changes the audit log attributes to actor.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor of the context, UnknownActor when it has
+// none.
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return UnknownActor
+}
+
+// AuditValues are the values of the player the audit log records, keyed like
+// the columns of players.
+func (p Player) AuditValues() map[string]any {
+	return map[string]any{
+		"id":           p.ID,
+		"name":         p.Name,
+		"email":        p.Email,
+		"country_code": p.CountryCode,
+		"created_at":   p.CreatedAt.UTC(),
+		"updated_at":   p.UpdatedAt.UTC(),
+		"erased_at":    auditTime(p.ErasedAt),
+	}
+}
+
+// AuditValues are the values of the bet the audit log records, keyed like the
+// columns of bets.
+func (b Bet) AuditValues() map[string]any {
+	return map[string]any{
+		"id":         b.ID,
+		"player_id":  b.PlayerID,
+		"amount":     b.Amount.String(),
+		"currency":   b.Currency,
+		"status":     string(b.Status),
+		"payout":     b.Payout.String(),
+		"settled_at": auditTime(b.SettledAt),
+		"created_at": b.CreatedAt.UTC(),
+	}
+}
+
+// auditTime records zero times as null.
+func auditTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC()
+}
@@ -0,0 +1,110 @@
+package domain
+
+import "strings"
+
+// countryCodes map the officially assigned ISO 3166-1 alpha-2 codes, and XK
+// for Kosovo, which is user-assigned but used by the EU, to their alpha-3
+// codes. alpha3CountryCodes is the reverse, for the alpha-3 borders of the
+// country API.
+var countryCodes, alpha3CountryCodes = newCountryCodeMaps(`
+	AD AND  AE ARE  AF AFG  AG ATG  AI AIA  AL ALB  AM ARM  AO AGO  AQ ATA  AR ARG
+	AS ASM  AT AUT  AU AUS  AW ABW  AX ALA  AZ AZE
+	BA BIH  BB BRB  BD BGD  BE BEL  BF BFA  BG BGR  BH BHR  BI BDI  BJ BEN  BL BLM
+	BM BMU  BN BRN  BO BOL  BQ BES  BR BRA  BS BHS  BT BTN  BV BVT  BW BWA  BY BLR
+	BZ BLZ
+	CA CAN  CC CCK  CD COD  CF CAF  CG COG  CH CHE  CI CIV  CK COK  CL CHL  CM CMR
+	CN CHN  CO COL  CR CRI  CU CUB  CV CPV  CW CUW  CX CXR  CY CYP  CZ CZE
+	DE DEU  DJ DJI  DK DNK  DM DMA  DO DOM  DZ DZA
+	EC ECU  EE EST  EG EGY  EH ESH  ER ERI  ES ESP  ET ETH
+	FI FIN  FJ FJI  FK FLK  FM FSM  FO FRO  FR FRA
+	GA GAB  GB GBR  GD GRD  GE GEO  GF GUF  GG GGY  GH GHA  GI GIB  GL GRL  GM GMB
+	GN GIN  GP GLP  GQ GNQ  GR GRC  GS SGS  GT GTM  GU GUM  GW GNB  GY GUY
+	HK HKG  HM HMD  HN HND  HR HRV  HT HTI  HU HUN
+	ID IDN  IE IRL  IL ISR  IM IMN  IN IND  IO IOT  IQ IRQ  IR IRN  IS ISL  IT ITA
+	JE JEY  JM JAM  JO JOR  JP JPN
+	KE KEN  KG KGZ  KH KHM  KI KIR  KM COM  KN KNA  KP PRK  KR KOR  KW KWT  KY CYM
+	KZ KAZ
+	LA LAO  LB LBN  LC LCA  LI LIE  LK LKA  LR LBR  LS LSO  LT LTU  LU LUX  LV LVA
+	LY LBY
+	MA MAR  MC MCO  MD MDA  ME MNE  MF MAF  MG MDG  MH MHL  MK MKD  ML MLI  MM MMR
+	MN MNG  MO MAC  MP MNP  MQ MTQ  MR MRT  MS MSR  MT MLT  MU MUS  MV MDV  MW MWI
+	MX MEX  MY MYS  MZ MOZ
+	NA NAM  NC NCL  NE NER  NF NFK  NG NGA  NI NIC  NL NLD  NO NOR  NP NPL  NR NRU
+	NU NIU  NZ NZL
+	OM OMN
+	PA PAN  PE PER  PF PYF  PG PNG  PH PHL  PK PAK  PL POL  PM SPM  PN PCN  PR PRI
+	PS PSE  PT PRT  PW PLW  PY PRY
+	QA QAT
+	RE REU  RO ROU  RS SRB  RU RUS  RW RWA
+	SA SAU  SB SLB  SC SYC  SD SDN  SE SWE  SG SGP  SH SHN  SI SVN  SJ SJM  SK SVK
+	SL SLE  SM SMR  SN SEN  SO SOM  SR SUR  SS SSD  ST STP  SV SLV  SX SXM  SY SYR
+	SZ SWZ
+	TC TCA  TD TCD  TF ATF  TG TGO  TH THA  TJ TJK  TK TKL  TL TLS  TM TKM  TN TUN
+	TO TON  TR TUR  TT TTO  TV TUV  TW TWN  TZ TZA
+	UA UKR  UG UGA  UM UMI  US USA  UY URY  UZ UZB
+	VA VAT  VC VCT  VE VEN  VG VGB  VI VIR  VN VNM  VU VUT
+	WF WLF  WS WSM
+	XK XKX
+	YE YEM  YT MYT
+	ZA ZAF  ZM ZMB  ZW ZWE
+`)
+
+// alpha3CountryCodeAliases map alpha-3 codes the country API uses besides the
+// registry's to their alpha-2 code.
+var alpha3CountryCodeAliases = map[string]string{
+	// RestCountries lists Kosovo with UNK, the code of the UN mission.
+	"UNK": "XK",
+}
+
+// countryCodeAliases map codes in common use that ISO 3166-1 reserves for
+// other purposes to the assigned code of the country.
+var countryCodeAliases = map[string]string{
+	// The United Kingdom is GB, UK is only exceptionally reserved.
+	"UK": "GB",
+	// The EU uses EL for Greece.
+	"EL": "GR",
+}
+
+// newCountryCodeMaps reads pairs of alpha-2 and alpha-3 codes.
+func newCountryCodeMaps(pairs string) (alpha2, alpha3 map[string]string) {
+	alpha2, alpha3 = make(map[string]string), make(map[string]string)
+	codes := strings.Fields(pairs)
+	for i := 0; i+1 < len(codes); i += 2 {
+		alpha2[codes[i]] = codes[i+1]
+		alpha3[codes[i+1]] = codes[i]
+	}
+	return alpha2, alpha3
+}
+
+// IsCountryCode reports whether code is an assigned ISO 3166-1 alpha-2 code,
+// as stored: upper case and without aliases.
+func IsCountryCode(code string) bool {
+	_, ok := countryCodes[code]
+	return ok
+}
+
+// NormalizeCountryCode returns the ISO 3166-1 alpha-2 code of code, which may
+// be in any case, surrounded by space or a known alias like UK. ok is false
+// when code is not a country code.
+func NormalizeCountryCode(code string) (string, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if alias, ok := countryCodeAliases[code]; ok {
+		code = alias
+	}
+	if !IsCountryCode(code) {
+		return "", false
+	}
+	return code, true
+}
+
+// CountryCodeFromAlpha3 returns the alpha-2 code of an ISO 3166-1 alpha-3 code
+// like the borders of CountryInfo, in any case. ok is false when the registry
+// does not know the code.
+func CountryCodeFromAlpha3(code string) (string, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if alias, ok := alpha3CountryCodeAliases[code]; ok {
+		return alias, true
+	}
+	alpha2, ok := alpha3CountryCodes[code]
+	return alpha2, ok
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// CountryStatsCursor is the ranking key of the last country on a page of
+// country stats. Values are kept as exact decimal strings so that the next page
+// starts precisely after it.
+type CountryStatsCursor struct {
+	SortBy      CountryStatsSortField `json:"s"`
+	Order       SortOrder             `json:"o"`
+	SortValue   string                `json:"v"`
+	TotalBets   string                `json:"t"`
+	CountryCode string                `json:"c"`
+	Currency    string                `json:"u"`
+}
+
+func (c CountryStatsCursor) Encode() string {
+	// Marshalling a struct of strings can not fail.
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCountryStatsCursor(s string) (CountryStatsCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return CountryStatsCursor{}, fmt.Errorf("malformed cursor: %w", ErrInvalidArgument)
+	}
+
+	var c CountryStatsCursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return CountryStatsCursor{}, fmt.Errorf("malformed cursor: %w", ErrInvalidArgument)
+	}
+
+	if !c.SortBy.Valid() || !c.Order.Valid() || c.CountryCode == "" {
+		return CountryStatsCursor{}, fmt.Errorf("malformed cursor: %w", ErrInvalidArgument)
+	}
+
+	return c, nil
+}
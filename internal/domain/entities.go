@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"math"
+	"time"
+)
 
 type Player struct {
 	ID          int
@@ -9,25 +12,157 @@ type Player struct {
 	CountryCode string
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
+	// ErasedAt is when the player's personal data was erased, zero if it was
+	// not.
+	ErasedAt time.Time
+}
+
+type CountryPlayerCount struct {
+	CountryCode string
+	PlayerCount int
+}
+
+// PlayerErasure is an entry of the log of honored erasure requests.
+type PlayerErasure struct {
+	ID       int
+	PlayerID int
+	// RequestedBy identifies who asked for the erasure, e.g. the support
+	// ticket or the operator.
+	RequestedBy string
+	Reason      string
+	ErasedAt    time.Time
 }
 
 type Bet struct {
-	ID        int
-	PlayerID  int
-	Amount    float64
+	ID       int
+	PlayerID int
+	Amount   Money
+	Currency string
+	Status   BetStatus
+	// Payout is what a won bet paid out in Currency, zero for any other status.
+	Payout Money
+	// SettledAt is zero while the bet is open.
+	SettledAt time.Time
 	CreatedAt time.Time
 }
 
+type BetStatus string
+
+const (
+	BetStatusOpen BetStatus = "open"
+	BetStatusWon  BetStatus = "won"
+	BetStatusLost BetStatus = "lost"
+	BetStatusVoid BetStatus = "void"
+)
+
+func (s BetStatus) Valid() bool {
+	switch s {
+	case BetStatusOpen, BetStatusWon, BetStatusLost, BetStatusVoid:
+		return true
+	}
+	return false
+}
+
+// ImportedPlayer is a player exported by another platform, ExternalID is its
+// ID there. A zero CreatedAt means now.
+type ImportedPlayer struct {
+	ExternalID  string
+	Name        string
+	Email       string
+	CountryCode string
+	CreatedAt   time.Time
+}
+
+// ImportedBet is a bet exported by another platform. It references its player
+// by the ExternalID the player was imported with.
+type ImportedBet struct {
+	ExternalID       string
+	PlayerExternalID string
+	Amount           Money
+	Currency         string
+	Status           BetStatus
+	Payout           Money
+	CreatedAt        time.Time
+	// SettledAt is zero for open bets.
+	SettledAt time.Time
+}
+
+// ImportRowResult is the outcome of importing a single row. ID is the internal
+// ID the row maps to, Skipped is set when an earlier import already created
+// it. Err is set when the row was rejected, the rest of the batch is imported
+// regardless.
+type ImportRowResult struct {
+	ID      int
+	Skipped bool
+	Err     error
+}
+
+// BaseCurrency is the currency exchange rates are quoted against. It is also
+// the default currency of bets and reports.
+const BaseCurrency = "EUR"
+
+// ExchangeRate is the amount of Currency one unit of BaseCurrency buys. It
+// applies from EffectiveFrom until the next rate of the same currency, the
+// first rate of a currency also applies to everything before it.
+type ExchangeRate struct {
+	Currency      string
+	EffectiveFrom time.Time
+	Rate          float64
+}
+
 type CountryPlayerStats struct {
 	CountryCode     string
 	PlayerCount     int
-	TotalBets       float64
-	AvgBetPerPlayer float64
+	TotalBets       Money
+	AvgBetPerPlayer Money
+	BetCount        int
+	Settlement
+}
+
+// Settlement sums up won and lost bets, open and void bets are left out.
+type Settlement struct {
+	SettledStakes Money
+	// GGR is the gross gaming revenue, settled stakes minus payouts.
+	GGR      Money
+	WinCount int
+}
+
+// HoldPercentage is the share of settled stakes kept as GGR, 0 without any
+// settled stakes.
+func (s Settlement) HoldPercentage() float64 {
+	if s.SettledStakes == 0 {
+		return 0
+	}
+	return float64(s.GGR) / float64(s.SettledStakes) * 100
+}
+
+func (s *Settlement) Add(other Settlement) {
+	s.SettledStakes += other.SettledStakes
+	s.GGR += other.GGR
+	s.WinCount += other.WinCount
+}
+
+// UnknownRegion groups countries whose region could not be resolved.
+const UnknownRegion = "unknown"
+
+type RegionPlayerStats struct {
+	Region       string
+	CountryCodes []string
+	PlayerCount  int
+	TotalBets    Money
+	// AvgBetPerPlayer is weighted by player count, not an average of the
+	// country averages.
+	AvgBetPerPlayer Money
+	BetCount        int
+	Settlement
 }
 
 type CountryInfo struct {
-	Name    string
-	Region  string
+	// Code is the ISO 3166-1 alpha-2 code of the country.
+	Code   string
+	Name   string
+	Region string
+	// Borders holds the ISO 3166-1 alpha-3 codes of the neighbouring countries.
 	Borders []string
 }
 
@@ -38,4 +173,151 @@ func (c CountryInfo) IsZero() bool {
 type CountryPlayerStatsWithInfo struct {
 	CountryPlayerStats
 	CountryInfo CountryInfo
+	// Distribution is only set when it was asked for.
+	Distribution *BetDistribution
+}
+
+// BetDistribution describes the spread of individual bet amounts. Percentiles
+// are linearly interpolated between the closest ranks, being statistics they
+// are approximate float64 values rather than Money.
+type BetDistribution struct {
+	Count     int
+	Min       float64
+	Max       float64
+	Median    float64
+	P90       float64
+	P99       float64
+	StdDev    float64
+	Histogram []HistogramBucket
+}
+
+// HistogramBucket counts bets in [LowerBound, UpperBound), the last bucket of a
+// histogram has an infinite UpperBound.
+type HistogramBucket struct {
+	LowerBound float64
+	UpperBound float64
+	Count      int
+}
+
+// NewHistogram returns empty buckets for the given ascending lower bounds.
+func NewHistogram(edges []float64) []HistogramBucket {
+	histogram := make([]HistogramBucket, len(edges))
+	for i, edge := range edges {
+		histogram[i] = HistogramBucket{LowerBound: edge, UpperBound: math.Inf(1)}
+		if i+1 < len(edges) {
+			histogram[i].UpperBound = edges[i+1]
+		}
+	}
+	return histogram
+}
+
+type PlayerStats struct {
+	Player       Player
+	BetCount     int
+	TotalWagered Money
+	AvgBet       Money
+	MinBet       Money
+	MaxBet       Money
+	// FirstBetAt and LastBetAt are zero when the player has not placed any bets.
+	FirstBetAt time.Time
+	LastBetAt  time.Time
+}
+
+type PlayerStatsWithInfo struct {
+	PlayerStats
+	CountryInfo CountryInfo
+}
+
+type LeaderboardEntry struct {
+	// Rank is dense, players with equal totals share a rank and no ranks are skipped.
+	Rank         int
+	PlayerID     int
+	Name         string
+	CountryCode  string
+	TotalWagered Money
+	BetCount     int
+}
+
+type BucketInterval string
+
+const (
+	BucketDay   BucketInterval = "day"
+	BucketWeek  BucketInterval = "week"
+	BucketMonth BucketInterval = "month"
+)
+
+func (i BucketInterval) Valid() bool {
+	switch i {
+	case BucketDay, BucketWeek, BucketMonth:
+		return true
+	}
+	return false
+}
+
+// TimeRange is a half open [Start, End) interval.
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+type CountryActivityBucket struct {
+	// Start is the calendar start of the bucket in the requested time zone.
+	Start         time.Time
+	TotalBets     Money
+	BetCount      int
+	ActivePlayers int
+}
+
+type CountryActivitySeries struct {
+	CountryCode string
+	Buckets     []CountryActivityBucket
+}
+
+// CountryDailyStats are the bets placed by the players of a country on a
+// single UTC day in a single currency, amounts are in that currency.
+type CountryDailyStats struct {
+	CountryCode   string
+	Day           time.Time
+	Currency      string
+	BetCount      int
+	TotalAmount   Money
+	SettledStakes Money
+	Payouts       Money
+	WinCount      int
+}
+
+// CountryDailyActivity is the number of players of a country that placed a
+// bet on a single UTC day.
+type CountryDailyActivity struct {
+	CountryCode   string
+	Day           time.Time
+	ActivePlayers int
+}
+
+type CountryStatsSortField string
+
+const (
+	SortByPlayerCount     CountryStatsSortField = "player_count"
+	SortByTotalBets       CountryStatsSortField = "total_bets"
+	SortByAvgBetPerPlayer CountryStatsSortField = "avg_bet_per_player"
+	SortByBetCount        CountryStatsSortField = "bet_count"
+)
+
+func (f CountryStatsSortField) Valid() bool {
+	switch f {
+	case SortByPlayerCount, SortByTotalBets, SortByAvgBetPerPlayer, SortByBetCount:
+		return true
+	}
+	return false
+}
+
+type SortOrder string
+
+const (
+	SortOrderAsc  SortOrder = "asc"
+	SortOrderDesc SortOrder = "desc"
+)
+
+func (o SortOrder) Valid() bool {
+	return o == SortOrderAsc || o == SortOrderDesc
 }
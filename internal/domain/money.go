@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an exact amount in cents, matching the DECIMAL(10, 2) bet amounts.
+type Money int64
+
+const centsPerUnit = 100
+
+// MoneyFromCents returns the amount of the given number of cents.
+func MoneyFromCents(cents int64) Money {
+	return Money(cents)
+}
+
+// ParseMoney parses a plain decimal such as "12.5" or "-3.25". Digits past the
+// second decimal place are only accepted when they are zero, amounts are never
+// rounded silently.
+func ParseMoney(s string) (Money, error) {
+	digits := s
+	negative := false
+	switch {
+	case strings.HasPrefix(digits, "-"):
+		negative = true
+		digits = digits[1:]
+	case strings.HasPrefix(digits, "+"):
+		digits = digits[1:]
+	}
+
+	units, fraction, hasFraction := strings.Cut(digits, ".")
+	if units == "" || (hasFraction && fraction == "") || !isDigits(units) || !isDigits(fraction) {
+		return 0, fmt.Errorf("invalid amount %q: %w", s, ErrInvalidArgument)
+	}
+
+	if len(fraction) > 2 {
+		if strings.TrimRight(fraction[2:], "0") != "" {
+			return 0, fmt.Errorf("amount %q has more than two decimal places: %w", s, ErrInvalidArgument)
+		}
+		fraction = fraction[:2]
+	}
+	fraction += strings.Repeat("0", 2-len(fraction))
+
+	cents, err := strconv.ParseInt(units+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %w", s, ErrInvalidArgument)
+	}
+	if negative {
+		cents = -cents
+	}
+
+	return Money(cents), nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Cents returns the amount in cents.
+func (m Money) Cents() int64 {
+	return int64(m)
+}
+
+// Float64 returns an approximation of the amount, only meant for ranking and
+// statistics.
+func (m Money) Float64() float64 {
+	return float64(m) / centsPerUnit
+}
+
+// String formats the amount with exactly two decimal places.
+func (m Money) String() string {
+	sign := ""
+	cents := int64(m)
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/centsPerUnit, cents%centsPerUnit)
+}
+
+// DivRound divides the amount by n, rounding to the nearest cent with halves
+// rounded away from zero. All averages are computed this way. Dividing by zero
+// or a negative count returns zero.
+func (m Money) DivRound(n int) Money {
+	if n <= 0 {
+		return 0
+	}
+
+	divisor := int64(n)
+	quotient, remainder := int64(m)/divisor, int64(m)%divisor
+	if remainder < 0 {
+		remainder = -remainder
+	}
+	if 2*remainder >= divisor {
+		if m < 0 {
+			quotient--
+		} else {
+			quotient++
+		}
+	}
+
+	return Money(quotient)
+}
+
+// Scan implements sql.Scanner for DECIMAL columns and aggregates of them.
+func (m *Money) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return m.scanString(string(v))
+	case string:
+		return m.scanString(v)
+	case int64:
+		*m = Money(v * centsPerUnit)
+		return nil
+	case float64:
+		// Only drivers without a decimal type report floats, MySQL does not.
+		*m = Money(math.Round(v * centsPerUnit))
+		return nil
+	case nil:
+		return fmt.Errorf("can not scan NULL into Money")
+	default:
+		return fmt.Errorf("can not scan %T into Money", src)
+	}
+}
+
+func (m *Money) scanString(s string) error {
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value implements driver.Valuer, the amount is sent as a decimal string so
+// MySQL converts it without going through a float.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
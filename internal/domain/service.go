@@ -1,16 +1,358 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"io"
+	"time"
+)
 
 type Service interface {
 	GetCountryPlayerStats(ctx context.Context, req GetCountryPlayerStatsRequest) (GetCountryPlayerStatsResponse, error)
+	GetRegionPlayerStats(ctx context.Context, req GetRegionPlayerStatsRequest) (GetRegionPlayerStatsResponse, error)
+	GetNeighborComparison(ctx context.Context, req GetNeighborComparisonRequest) (GetNeighborComparisonResponse, error)
+	GetLeaderboard(ctx context.Context, req GetLeaderboardRequest) (GetLeaderboardResponse, error)
+	GetCountryActivityTimeSeries(ctx context.Context, req GetCountryActivityTimeSeriesRequest) (GetCountryActivityTimeSeriesResponse, error)
+
+	CreatePlayer(ctx context.Context, req CreatePlayerRequest) (CreatePlayerResponse, error)
+	GetPlayer(ctx context.Context, req GetPlayerRequest) (GetPlayerResponse, error)
+	ListPlayers(ctx context.Context, req ListPlayersRequest) (ListPlayersResponse, error)
+	UpdatePlayer(ctx context.Context, req UpdatePlayerRequest) (UpdatePlayerResponse, error)
+	DeletePlayer(ctx context.Context, req DeletePlayerRequest) error
+	GetPlayerStats(ctx context.Context, req GetPlayerStatsRequest) (GetPlayerStatsResponse, error)
+	ErasePlayer(ctx context.Context, req ErasePlayerRequest) (ErasePlayerResponse, error)
+	ListPlayerErasures(ctx context.Context, req ListPlayerErasuresRequest) (ListPlayerErasuresResponse, error)
+
+	PlaceBet(ctx context.Context, req PlaceBetRequest) (PlaceBetResponse, error)
+	SettleBet(ctx context.Context, req SettleBetRequest) (SettleBetResponse, error)
+
+	CreateExchangeRate(ctx context.Context, req CreateExchangeRateRequest) (CreateExchangeRateResponse, error)
+	ListExchangeRates(ctx context.Context, req ListExchangeRatesRequest) (ListExchangeRatesResponse, error)
+
+	Import(ctx context.Context, req ImportRequest) (ImportResponse, error)
+
+	CheckCountryStatsRollup(ctx context.Context, req CheckCountryStatsRollupRequest) (CheckCountryStatsRollupResponse, error)
+	CheckCountryCodes(ctx context.Context, req CheckCountryCodesRequest) (CheckCountryCodesResponse, error)
+
+	ListAuditEntries(ctx context.Context, req ListAuditEntriesRequest) (ListAuditEntriesResponse, error)
 }
 
 type (
 	GetCountryPlayerStatsRequest struct {
 		Limit int
+		// From and To bound the bets taken into account, zero values leave the window open.
+		From   time.Time
+		To     time.Time
+		SortBy CountryStatsSortField
+		Order  SortOrder
+		// Cursor is the NextCursor of a previous response, empty for the first page.
+		Cursor string
+		// IncludeDistribution adds a BetDistribution to every returned country,
+		// HistogramEdges are the ascending lower bounds of its histogram buckets.
+		IncludeDistribution bool
+		HistogramEdges      []float64
+		// Currency is the currency totals are reported in, BaseCurrency when empty.
+		Currency string
 	}
 	GetCountryPlayerStatsResponse struct {
-		Stats []CountryPlayerStatsWithInfo
+		// Currency is the currency all totals are reported in.
+		Currency string
+		Stats    []CountryPlayerStatsWithInfo
+		// NextCursor is empty when there are no more pages.
+		NextCursor string
+	}
+)
+
+type (
+	GetRegionPlayerStatsRequest struct {
+		From     time.Time
+		To       time.Time
+		SortBy   CountryStatsSortField
+		Order    SortOrder
+		Currency string
+	}
+	GetRegionPlayerStatsResponse struct {
+		Currency string
+		Regions  []RegionPlayerStats
+	}
+)
+
+type (
+	GetNeighborComparisonRequest struct {
+		CountryCode string
+		From        time.Time
+		To          time.Time
+		Currency    string
+	}
+	GetNeighborComparisonResponse struct {
+		Currency string
+		Country  CountryPlayerStatsWithInfo
+		// Neighbors follow the order of CountryInfo.Borders, countries without
+		// players are included with zero stats.
+		Neighbors []CountryPlayerStatsWithInfo
+	}
+)
+
+type (
+	GetLeaderboardRequest struct {
+		// Limit is a rank cutoff rather than a row count, players tied on a rank
+		// share it, so the result can be longer. It never holds more than the
+		// service's hard limit of entries.
+		Limit int
+		// CountryCode restricts the leaderboard to one country, empty means global.
+		CountryCode string
+		From        time.Time
+		To          time.Time
+		Currency    string
+	}
+	GetLeaderboardResponse struct {
+		Currency string
+		Entries  []LeaderboardEntry
+	}
+)
+
+type (
+	GetCountryActivityTimeSeriesRequest struct {
+		From     time.Time
+		To       time.Time
+		Interval BucketInterval
+		// Location defines the bucket boundaries, nil means UTC.
+		Location *time.Location
+		// CountryCode restricts the series to one country, empty means all countries.
+		CountryCode string
+		Currency    string
+	}
+	GetCountryActivityTimeSeriesResponse struct {
+		Currency string
+		Series   []CountryActivitySeries
+	}
+)
+
+type (
+	CreatePlayerRequest struct {
+		Name        string
+		Email       string
+		CountryCode string
+	}
+	CreatePlayerResponse struct {
+		Player Player
+	}
+
+	GetPlayerRequest struct {
+		ID int
+	}
+	GetPlayerResponse struct {
+		Player Player
+	}
+
+	ListPlayersRequest struct {
+		Limit  int
+		Offset int
+	}
+	ListPlayersResponse struct {
+		Players []Player
+	}
+
+	UpdatePlayerRequest struct {
+		ID          int
+		Name        string
+		Email       string
+		CountryCode string
+	}
+	UpdatePlayerResponse struct {
+		Player Player
+	}
+
+	DeletePlayerRequest struct {
+		ID int
+	}
+
+	GetPlayerStatsRequest struct {
+		PlayerID int
+		Currency string
+	}
+	GetPlayerStatsResponse struct {
+		Currency string
+		Stats    PlayerStatsWithInfo
+	}
+
+	ErasePlayerRequest struct {
+		ID          int
+		RequestedBy string
+		Reason      string
+	}
+	ErasePlayerResponse struct {
+		Erasure  PlayerErasure
+		Replayed bool
+	}
+
+	ListPlayerErasuresRequest struct {
+		PlayerID int
+		Limit    int
+		Offset   int
+	}
+	ListPlayerErasuresResponse struct {
+		Erasures []PlayerErasure
+	}
+)
+
+type (
+	PlaceBetRequest struct {
+		PlayerID int
+		Amount   Money
+		// Currency is the currency of Amount, BaseCurrency when empty.
+		Currency       string
+		IdempotencyKey string
+	}
+	PlaceBetResponse struct {
+		Bet Bet
+		// Replayed is true when the bet was already recorded under the same idempotency key.
+		Replayed bool
+	}
+)
+
+type (
+	SettleBetRequest struct {
+		ID     int
+		Status BetStatus
+		// Payout is required for won bets and must be zero otherwise.
+		Payout Money
+	}
+	SettleBetResponse struct {
+		Bet Bet
+		// Replayed is true when the bet was already settled the same way.
+		Replayed bool
+	}
+)
+
+type (
+	CreateExchangeRateRequest struct {
+		Currency      string
+		EffectiveFrom time.Time
+		Rate          float64
+	}
+	CreateExchangeRateResponse struct {
+		Rate ExchangeRate
+	}
+
+	ListExchangeRatesRequest struct {
+		Currency string
+	}
+	ListExchangeRatesResponse struct {
+		Rates []ExchangeRate
+	}
+)
+
+type (
+	CheckCountryStatsRollupRequest struct {
+		// From and To are the days to check, they must be midnight UTC and zero
+		// values leave the window open.
+		From time.Time
+		To   time.Time
+		// Repair rebuilds the days that do not match from the bets.
+		Repair bool
+	}
+	CheckCountryStatsRollupResponse struct {
+		Stats    []CountryDailyStatsMismatch
+		Activity []CountryDailyActivityMismatch
+		// Repaired lists the days that were rebuilt.
+		Repaired []time.Time
+	}
+
+	// CountryDailyStatsMismatch is a row of the rollup that differs from the
+	// bets, a side without the row has zero counts.
+	CountryDailyStatsMismatch struct {
+		Rollup CountryDailyStats
+		Bets   CountryDailyStats
+	}
+	CountryDailyActivityMismatch struct {
+		Rollup CountryDailyActivity
+		Bets   CountryDailyActivity
+	}
+)
+
+type (
+	CheckCountryCodesRequest struct {
+		// Fix moves the players of codes that are a known alias to the code
+		// they stand for.
+		Fix bool
+	}
+	CheckCountryCodesResponse struct {
+		Invalid []InvalidCountryCode
+	}
+
+	// InvalidCountryCode is a country code players have that is not an ISO
+	// 3166-1 alpha-2 code.
+	InvalidCountryCode struct {
+		CountryCode string
+		PlayerCount int
+		// Normalized is the code CountryCode stands for, empty when it is
+		// unknown.
+		Normalized string
+		// Fixed counts the players that were moved to Normalized.
+		Fixed int
+	}
+)
+
+type ImportKind string
+
+const (
+	ImportKindPlayers ImportKind = "players"
+	ImportKindBets    ImportKind = "bets"
+)
+
+type ImportFormat string
+
+const (
+	// ImportFormatCSV has a header row naming the columns of the rows after it.
+	ImportFormatCSV ImportFormat = "csv"
+	// ImportFormatNDJSON has one JSON object per line.
+	ImportFormatNDJSON ImportFormat = "ndjson"
+)
+
+type (
+	ImportRequest struct {
+		Kind   ImportKind
+		Format ImportFormat
+		// Body is read row by row, it is never held in memory as a whole.
+		Body io.Reader
+		// DryRun validates every row, including against the database, without
+		// writing anything.
+		DryRun bool
+	}
+	ImportResponse struct {
+		Rows     int
+		Imported int
+		// Skipped counts rows an earlier import already created.
+		Skipped int
+		Failed  int
+		// Errors are those of the first 1000 rejected rows, ErrorsDropped
+		// counts the rejected rows after them.
+		Errors        []ImportRowError
+		ErrorsDropped int
+	}
+
+	// ImportRowError reports why a row was rejected. Row is the 1-based number
+	// of the row in the body, not counting the CSV header.
+	ImportRowError struct {
+		Row        int
+		ExternalID string
+		Message    string
+	}
+)
+
+type (
+	// ListAuditEntriesRequest filters the audit log, zero values match every
+	// entry. EntityID needs EntityType.
+	ListAuditEntriesRequest struct {
+		EntityType AuditEntityType
+		EntityID   int
+		Actor      string
+		From       time.Time
+		To         time.Time
+		Limit      int
+		Offset     int
+	}
+	ListAuditEntriesResponse struct {
+		Entries []AuditEntry
 	}
 )
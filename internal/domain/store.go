@@ -1,16 +1,313 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Store interface {
 	GetTopCountriesByPlayerActivity(ctx context.Context, query GetTopCountriesByPlayerActivityQuery) (*GetTopCountriesByPlayerActivityResult, error)
+	GetCountryStats(ctx context.Context, query GetCountryStatsQuery) (*GetCountryStatsResult, error)
+	GetBetDistributionByCountry(ctx context.Context, query GetBetDistributionByCountryQuery) (*GetBetDistributionByCountryResult, error)
+	GetTopPlayersByTotalWagered(ctx context.Context, query GetTopPlayersByTotalWageredQuery) (*GetTopPlayersByTotalWageredResult, error)
+	GetCountryActivityByBucket(ctx context.Context, query GetCountryActivityByBucketQuery) (*GetCountryActivityByBucketResult, error)
+
+	CreatePlayer(ctx context.Context, query CreatePlayerQuery) (*CreatePlayerResult, error)
+	GetPlayer(ctx context.Context, query GetPlayerQuery) (*GetPlayerResult, error)
+	ListPlayers(ctx context.Context, query ListPlayersQuery) (*ListPlayersResult, error)
+	UpdatePlayer(ctx context.Context, query UpdatePlayerQuery) (*UpdatePlayerResult, error)
+	DeletePlayer(ctx context.Context, query DeletePlayerQuery) error
+	GetPlayerStats(ctx context.Context, query GetPlayerStatsQuery) (*GetPlayerStatsResult, error)
+	CountPlayersByCountry(ctx context.Context, query CountPlayersByCountryQuery) (*CountPlayersByCountryResult, error)
+	ErasePlayer(ctx context.Context, query ErasePlayerQuery) (*ErasePlayerResult, error)
+	ListPlayerErasures(ctx context.Context, query ListPlayerErasuresQuery) (*ListPlayerErasuresResult, error)
+
+	CreateBet(ctx context.Context, query CreateBetQuery) (*CreateBetResult, error)
+	SettleBet(ctx context.Context, query SettleBetQuery) (*SettleBetResult, error)
+
+	CreateExchangeRate(ctx context.Context, query CreateExchangeRateQuery) (*CreateExchangeRateResult, error)
+	ListExchangeRates(ctx context.Context, query ListExchangeRatesQuery) (*ListExchangeRatesResult, error)
+
+	ImportPlayers(ctx context.Context, query ImportPlayersQuery) (*ImportPlayersResult, error)
+	ImportBets(ctx context.Context, query ImportBetsQuery) (*ImportBetsResult, error)
+
+	GetCountryDailyStats(ctx context.Context, query GetCountryDailyStatsQuery) (*GetCountryDailyStatsResult, error)
+	RebuildCountryDailyStats(ctx context.Context, query RebuildCountryDailyStatsQuery) error
+
+	ListAuditEntries(ctx context.Context, query ListAuditEntriesQuery) (*ListAuditEntriesResult, error)
 }
 
 type (
 	GetTopCountriesByPlayerActivityQuery struct {
-		Limit int
+		Limit  int
+		From   time.Time
+		To     time.Time
+		SortBy CountryStatsSortField
+		Order  SortOrder
+		After  *CountryStatsCursor
+		// Currency is the currency bet amounts are converted into.
+		Currency string
 	}
 	GetTopCountriesByPlayerActivityResult struct {
 		Stats []CountryPlayerStats
+		Next  *CountryStatsCursor
+	}
+)
+
+type (
+	GetCountryStatsQuery struct {
+		CountryCodes []string
+		From         time.Time
+		To           time.Time
+		Currency     string
+	}
+	GetCountryStatsResult struct {
+		// Stats only holds countries that have players, counted the same way
+		// as GetTopCountriesByPlayerActivity does.
+		Stats []CountryPlayerStats
+	}
+)
+
+type (
+	GetBetDistributionByCountryQuery struct {
+		CountryCodes   []string
+		From           time.Time
+		To             time.Time
+		HistogramEdges []float64
+		Currency       string
+	}
+	GetBetDistributionByCountryResult struct {
+		// Distributions only holds countries that have bets in the window.
+		Distributions map[string]BetDistribution
+	}
+)
+
+type (
+	GetTopPlayersByTotalWageredQuery struct {
+		// MaxRank is a rank cutoff, every player tied on the last rank is
+		// returned. MaxEntries caps the rows these ties can add up to.
+		MaxRank     int
+		MaxEntries  int
+		CountryCode string
+		From        time.Time
+		To          time.Time
+		Currency    string
+	}
+	GetTopPlayersByTotalWageredResult struct {
+		Entries []LeaderboardEntry
+	}
+)
+
+type (
+	GetCountryActivityByBucketQuery struct {
+		Buckets     []TimeRange
+		CountryCode string
+		Currency    string
+	}
+	GetCountryActivityByBucketResult struct {
+		Rows []CountryBucketActivity
+	}
+
+	CountryBucketActivity struct {
+		CountryCode string
+		// Bucket is the index into GetCountryActivityByBucketQuery.Buckets.
+		Bucket        int
+		TotalBets     Money
+		BetCount      int
+		ActivePlayers int
+	}
+)
+
+type (
+	CreatePlayerQuery struct {
+		Name        string
+		Email       string
+		CountryCode string
+	}
+	CreatePlayerResult struct {
+		Player Player
+	}
+
+	GetPlayerQuery struct {
+		ID int
+	}
+	GetPlayerResult struct {
+		Player Player
+	}
+
+	ListPlayersQuery struct {
+		// CountryCode only lists the players of a country when set.
+		CountryCode string
+		Limit       int
+		Offset      int
+	}
+	ListPlayersResult struct {
+		Players []Player
+	}
+
+	UpdatePlayerQuery struct {
+		ID          int
+		Name        string
+		Email       string
+		CountryCode string
+	}
+	UpdatePlayerResult struct {
+		Player Player
+	}
+
+	DeletePlayerQuery struct {
+		ID int
+	}
+
+	GetPlayerStatsQuery struct {
+		PlayerID int
+		Currency string
+	}
+	GetPlayerStatsResult struct {
+		Stats PlayerStats
+	}
+
+	CountPlayersByCountryQuery  struct{}
+	CountPlayersByCountryResult struct {
+		// Counts has every country code players have, as stored.
+		Counts []CountryPlayerCount
+	}
+
+	// ErasePlayerQuery replaces the personal data of a player with Name and
+	// Email and logs the erasure, unless the player was erased before.
+	ErasePlayerQuery struct {
+		ID          int
+		Name        string
+		Email       string
+		RequestedBy string
+		Reason      string
+	}
+	ErasePlayerResult struct {
+		Erasure PlayerErasure
+		// Replayed is set when the player was erased before, Erasure is the
+		// first erasure then.
+		Replayed bool
+	}
+
+	ListPlayerErasuresQuery struct {
+		// PlayerID only lists the erasures of a player when set.
+		PlayerID int
+		Limit    int
+		Offset   int
+	}
+	ListPlayerErasuresResult struct {
+		Erasures []PlayerErasure
+	}
+)
+
+type (
+	CreateBetQuery struct {
+		PlayerID       int
+		Amount         Money
+		Currency       string
+		IdempotencyKey string
+	}
+	CreateBetResult struct {
+		Bet      Bet
+		Replayed bool
+	}
+)
+
+type (
+	SettleBetQuery struct {
+		ID     int
+		Status BetStatus
+		Payout Money
+	}
+	SettleBetResult struct {
+		Bet Bet
+		// Replayed is true when the bet was already settled the same way.
+		Replayed bool
+	}
+)
+
+type (
+	CreateExchangeRateQuery struct {
+		Rate ExchangeRate
+	}
+	CreateExchangeRateResult struct {
+		Rate ExchangeRate
+	}
+)
+
+type (
+	ListExchangeRatesQuery struct {
+		// Currency restricts the rates to a single currency, empty lists all of them.
+		Currency string
+	}
+	ListExchangeRatesResult struct {
+		Rates []ExchangeRate
+	}
+)
+
+type (
+	// ImportPlayersQuery imports a batch of players in one transaction, which
+	// is rolled back when DryRun is set. Players whose ExternalID was imported
+	// before are skipped.
+	ImportPlayersQuery struct {
+		Players []ImportedPlayer
+		DryRun  bool
+	}
+	// ImportPlayersResult holds the outcome of every player, index aligned
+	// with the query.
+	ImportPlayersResult struct {
+		Rows []ImportRowResult
+	}
+
+	// ImportBetsQuery imports a batch of bets the same way ImportPlayersQuery
+	// imports players. The players of the bets must have been imported before.
+	ImportBetsQuery struct {
+		Bets   []ImportedBet
+		DryRun bool
+	}
+	ImportBetsResult struct {
+		Rows []ImportRowResult
+	}
+)
+
+type (
+	// GetCountryDailyStatsQuery selects the days of the country stats rollup,
+	// From and To must be midnight UTC and zero values leave the window open.
+	GetCountryDailyStatsQuery struct {
+		From time.Time
+		To   time.Time
+	}
+	// GetCountryDailyStatsResult holds the rollup next to the same aggregates
+	// computed from the bets, both are read from the same snapshot.
+	GetCountryDailyStatsResult struct {
+		RollupStats    []CountryDailyStats
+		BetStats       []CountryDailyStats
+		RollupActivity []CountryDailyActivity
+		BetActivity    []CountryDailyActivity
+	}
+
+	// RebuildCountryDailyStatsQuery replaces the rollup of the days in the
+	// window with aggregates of the bets, the window is the same as in
+	// GetCountryDailyStatsQuery.
+	RebuildCountryDailyStatsQuery struct {
+		From time.Time
+		To   time.Time
+	}
+)
+
+type (
+	// ListAuditEntriesQuery filters the audit log, zero values match every
+	// entry. From and To bound the time of the change.
+	ListAuditEntriesQuery struct {
+		EntityType AuditEntityType
+		EntityID   int
+		Actor      string
+		From       time.Time
+		To         time.Time
+		Limit      int
+		Offset     int
+	}
+	ListAuditEntriesResult struct {
+		Entries []AuditEntry
 	}
 )
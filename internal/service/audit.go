@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+func (s Service) ListAuditEntries(ctx context.Context, req domain.ListAuditEntriesRequest) (domain.ListAuditEntriesResponse, error) {
+	if req.EntityType != "" && !req.EntityType.Valid() {
+		return domain.ListAuditEntriesResponse{}, fmt.Errorf("unknown entity type %q: %w", req.EntityType, domain.ErrInvalidArgument)
+	}
+	if req.EntityID != 0 && req.EntityType == "" {
+		return domain.ListAuditEntriesResponse{}, fmt.Errorf("entity_id needs entity_type: %w", domain.ErrInvalidArgument)
+	}
+	if err := validateWindow(req.From, req.To); err != nil {
+		return domain.ListAuditEntriesResponse{}, err
+	}
+
+	result, err := s.store.ListAuditEntries(ctx, domain.ListAuditEntriesQuery{
+		EntityType: req.EntityType,
+		EntityID:   req.EntityID,
+		Actor:      req.Actor,
+		From:       req.From,
+		To:         req.To,
+		Limit:      req.Limit,
+		Offset:     req.Offset,
+	})
+	if err != nil {
+		return domain.ListAuditEntriesResponse{}, err
+	}
+
+	return domain.ListAuditEntriesResponse{
+		Entries: result.Entries,
+	}, nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+const (
+	// maxBetAmount is the largest value that fits the DECIMAL(10, 2) amount column.
+	maxBetAmount = domain.Money(9999999999)
+	// maxPayout is the largest value that fits the DECIMAL(12, 2) payout column.
+	maxPayout               = domain.Money(999999999999)
+	maxIdempotencyKeyLength = 255
+)
+
+func (s Service) PlaceBet(ctx context.Context, req domain.PlaceBetRequest) (domain.PlaceBetResponse, error) {
+	if err := validateBetAmount(req.Amount); err != nil {
+		return domain.PlaceBetResponse{}, err
+	}
+	currency, err := normalizeCurrency(req.Currency)
+	if err != nil {
+		return domain.PlaceBetResponse{}, err
+	}
+	if err := s.requireExchangeRate(ctx, currency); err != nil {
+		return domain.PlaceBetResponse{}, err
+	}
+	if len(req.IdempotencyKey) > maxIdempotencyKeyLength {
+		return domain.PlaceBetResponse{}, fmt.Errorf("idempotency key must be at most %d characters: %w", maxIdempotencyKeyLength, domain.ErrInvalidArgument)
+	}
+
+	result, err := s.store.CreateBet(ctx, domain.CreateBetQuery{
+		PlayerID:       req.PlayerID,
+		Amount:         req.Amount,
+		Currency:       currency,
+		IdempotencyKey: req.IdempotencyKey,
+	})
+	if err != nil {
+		return domain.PlaceBetResponse{}, err
+	}
+
+	return domain.PlaceBetResponse{
+		Bet:      result.Bet,
+		Replayed: result.Replayed,
+	}, nil
+}
+
+func (s Service) SettleBet(ctx context.Context, req domain.SettleBetRequest) (domain.SettleBetResponse, error) {
+	if err := validateSettlement(req.Status, req.Payout); err != nil {
+		return domain.SettleBetResponse{}, err
+	}
+
+	result, err := s.store.SettleBet(ctx, domain.SettleBetQuery{
+		ID:     req.ID,
+		Status: req.Status,
+		Payout: req.Payout,
+	})
+	if err != nil {
+		return domain.SettleBetResponse{}, err
+	}
+
+	return domain.SettleBetResponse{
+		Bet:      result.Bet,
+		Replayed: result.Replayed,
+	}, nil
+}
+
+func validateSettlement(status domain.BetStatus, payout domain.Money) error {
+	switch status {
+	case domain.BetStatusWon:
+		if payout <= 0 || payout > maxPayout {
+			return fmt.Errorf("payout of a won bet must be between 0.01 and %s: %w", maxPayout, domain.ErrInvalidArgument)
+		}
+	case domain.BetStatusLost, domain.BetStatusVoid:
+		if payout != 0 {
+			return fmt.Errorf("only won bets have a payout: %w", domain.ErrInvalidArgument)
+		}
+	default:
+		return fmt.Errorf("bets can only be settled as won, lost or void, not %q: %w", status, domain.ErrInvalidArgument)
+	}
+	return nil
+}
+
+func validateBetAmount(amount domain.Money) error {
+	if amount <= 0 || amount > maxBetAmount {
+		return fmt.Errorf("amount must be between 0.01 and %s: %w", maxBetAmount, domain.ErrInvalidArgument)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+// countryCodeFixBatch is the number of players moved to the code of an alias
+// per store query.
+const countryCodeFixBatch = 100
+
+// CheckCountryCodes lists the country codes of players that are not ISO
+// 3166-1 alpha-2 codes, which could be stored before codes were validated, and
+// optionally moves the players of known aliases to the code they stand for.
+func (s Service) CheckCountryCodes(ctx context.Context, req domain.CheckCountryCodesRequest) (domain.CheckCountryCodesResponse, error) {
+	result, err := s.store.CountPlayersByCountry(ctx, domain.CountPlayersByCountryQuery{})
+	if err != nil {
+		return domain.CheckCountryCodesResponse{}, err
+	}
+
+	res := domain.CheckCountryCodesResponse{
+		Invalid: []domain.InvalidCountryCode{},
+	}
+	for _, count := range result.Counts {
+		if domain.IsCountryCode(count.CountryCode) {
+			continue
+		}
+		invalid := domain.InvalidCountryCode{
+			CountryCode: count.CountryCode,
+			PlayerCount: count.PlayerCount,
+		}
+		invalid.Normalized, _ = domain.NormalizeCountryCode(count.CountryCode)
+		res.Invalid = append(res.Invalid, invalid)
+	}
+	if !req.Fix {
+		return res, nil
+	}
+
+	for i, invalid := range res.Invalid {
+		if invalid.Normalized == "" {
+			continue
+		}
+		res.Invalid[i].Fixed, err = s.moveCountryPlayers(ctx, invalid.CountryCode, invalid.Normalized)
+		if err != nil {
+			return res, fmt.Errorf("failed to move players from %s to %s: %w", invalid.CountryCode, invalid.Normalized, err)
+		}
+	}
+
+	return res, nil
+}
+
+// moveCountryPlayers updates the players of a country one by one, so the
+// rollup follows them and the audit log records every move. Erased players can
+// not be updated and keep the code.
+func (s Service) moveCountryPlayers(ctx context.Context, from, to string) (int, error) {
+	moved, erased := 0, 0
+	for {
+		// Moved players no longer match, so every batch starts at the first
+		// player left after the erased ones.
+		result, err := s.store.ListPlayers(ctx, domain.ListPlayersQuery{
+			CountryCode: from,
+			Limit:       countryCodeFixBatch,
+			Offset:      erased,
+		})
+		if err != nil {
+			return moved, err
+		}
+		if len(result.Players) == 0 {
+			return moved, nil
+		}
+
+		for _, player := range result.Players {
+			if !player.ErasedAt.IsZero() {
+				erased++
+				continue
+			}
+			_, err := s.store.UpdatePlayer(ctx, domain.UpdatePlayerQuery{
+				ID:          player.ID,
+				Name:        player.Name,
+				Email:       player.Email,
+				CountryCode: to,
+			})
+			if errors.Is(err, domain.ErrNotFound) {
+				// Deleted since it was listed.
+				continue
+			}
+			if errors.Is(err, domain.ErrConflict) {
+				// Erased since it was listed.
+				erased++
+				continue
+			}
+			if err != nil {
+				return moved, err
+			}
+			moved++
+		}
+	}
+}
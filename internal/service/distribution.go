@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+var defaultHistogramEdges = []float64{0, 10, 50, 100, 500, 1000, 5000}
+
+const maxHistogramEdges = 50
+
+// betDistributions returns the distribution of every country in the query,
+// countries without bets in the window get an empty distribution.
+func (s Service) betDistributions(ctx context.Context, query domain.GetBetDistributionByCountryQuery) (map[string]domain.BetDistribution, error) {
+	result, err := s.store.GetBetDistributionByCountry(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, countryCode := range query.CountryCodes {
+		if _, ok := result.Distributions[countryCode]; !ok {
+			result.Distributions[countryCode] = domain.BetDistribution{
+				Histogram: domain.NewHistogram(query.HistogramEdges),
+			}
+		}
+	}
+
+	return result.Distributions, nil
+}
+
+func validateHistogramEdges(edges []float64) error {
+	if len(edges) > maxHistogramEdges {
+		return fmt.Errorf("at most %d histogram edges are allowed: %w", maxHistogramEdges, domain.ErrInvalidArgument)
+	}
+	for i := 1; i < len(edges); i++ {
+		if edges[i] <= edges[i-1] {
+			return fmt.Errorf("histogram edges must be strictly ascending: %w", domain.ErrInvalidArgument)
+		}
+	}
+	return nil
+}
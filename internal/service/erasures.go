@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+const (
+	erasedPlayerName = "Erased Player"
+
+	maxRequestedByLength   = 255
+	maxErasureReasonLength = 1000
+)
+
+// erasedPlayerEmail is the email of an erased player. It stays unique and can
+// not reach anyone, .invalid is reserved for that.
+func erasedPlayerEmail(id int) string {
+	return fmt.Sprintf("erased-%d@erased.invalid", id)
+}
+
+// ErasePlayer honors a right to be forgotten request by replacing the name and
+// email of a player. The player keeps their country and bets, so statistics do
+// not change, and the erasure is logged. Erasing a player again returns the
+// first erasure.
+func (s Service) ErasePlayer(ctx context.Context, req domain.ErasePlayerRequest) (domain.ErasePlayerResponse, error) {
+	requestedBy := strings.TrimSpace(req.RequestedBy)
+	if requestedBy == "" {
+		return domain.ErasePlayerResponse{}, fmt.Errorf("requested_by is required: %w", domain.ErrInvalidArgument)
+	}
+	if len(requestedBy) > maxRequestedByLength {
+		return domain.ErasePlayerResponse{}, fmt.Errorf("requested_by must be at most %d characters: %w", maxRequestedByLength, domain.ErrInvalidArgument)
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if len(reason) > maxErasureReasonLength {
+		return domain.ErasePlayerResponse{}, fmt.Errorf("reason must be at most %d characters: %w", maxErasureReasonLength, domain.ErrInvalidArgument)
+	}
+
+	result, err := s.store.ErasePlayer(ctx, domain.ErasePlayerQuery{
+		ID:          req.ID,
+		Name:        erasedPlayerName,
+		Email:       erasedPlayerEmail(req.ID),
+		RequestedBy: requestedBy,
+		Reason:      reason,
+	})
+	if err != nil {
+		return domain.ErasePlayerResponse{}, err
+	}
+
+	return domain.ErasePlayerResponse{
+		Erasure:  result.Erasure,
+		Replayed: result.Replayed,
+	}, nil
+}
+
+func (s Service) ListPlayerErasures(ctx context.Context, req domain.ListPlayerErasuresRequest) (domain.ListPlayerErasuresResponse, error) {
+	result, err := s.store.ListPlayerErasures(ctx, domain.ListPlayerErasuresQuery{
+		PlayerID: req.PlayerID,
+		Limit:    req.Limit,
+		Offset:   req.Offset,
+	})
+	if err != nil {
+		return domain.ListPlayerErasuresResponse{}, err
+	}
+
+	return domain.ListPlayerErasuresResponse{
+		Erasures: result.Erasures,
+	}, nil
+}
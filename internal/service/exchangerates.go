@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+func (s Service) CreateExchangeRate(ctx context.Context, req domain.CreateExchangeRateRequest) (domain.CreateExchangeRateResponse, error) {
+	if strings.TrimSpace(req.Currency) == "" {
+		return domain.CreateExchangeRateResponse{}, fmt.Errorf("currency is required: %w", domain.ErrInvalidArgument)
+	}
+	currency, err := normalizeCurrency(req.Currency)
+	if err != nil {
+		return domain.CreateExchangeRateResponse{}, err
+	}
+	if req.Rate <= 0 {
+		return domain.CreateExchangeRateResponse{}, fmt.Errorf("rate must be positive: %w", domain.ErrInvalidArgument)
+	}
+	if currency == domain.BaseCurrency && req.Rate != 1 {
+		return domain.CreateExchangeRateResponse{}, fmt.Errorf("the rate of %s is always 1: %w", domain.BaseCurrency, domain.ErrInvalidArgument)
+	}
+	if req.EffectiveFrom.IsZero() {
+		return domain.CreateExchangeRateResponse{}, fmt.Errorf("effective from date is required: %w", domain.ErrInvalidArgument)
+	}
+
+	y, m, d := req.EffectiveFrom.Date()
+
+	result, err := s.store.CreateExchangeRate(ctx, domain.CreateExchangeRateQuery{
+		Rate: domain.ExchangeRate{
+			Currency:      currency,
+			EffectiveFrom: time.Date(y, m, d, 0, 0, 0, 0, time.UTC),
+			Rate:          req.Rate,
+		},
+	})
+	if err != nil {
+		return domain.CreateExchangeRateResponse{}, err
+	}
+
+	return domain.CreateExchangeRateResponse{
+		Rate: result.Rate,
+	}, nil
+}
+
+func (s Service) ListExchangeRates(ctx context.Context, req domain.ListExchangeRatesRequest) (domain.ListExchangeRatesResponse, error) {
+	var currency string
+	if strings.TrimSpace(req.Currency) != "" {
+		var err error
+		if currency, err = normalizeCurrency(req.Currency); err != nil {
+			return domain.ListExchangeRatesResponse{}, err
+		}
+	}
+
+	result, err := s.store.ListExchangeRates(ctx, domain.ListExchangeRatesQuery{
+		Currency: currency,
+	})
+	if err != nil {
+		return domain.ListExchangeRatesResponse{}, err
+	}
+
+	return domain.ListExchangeRatesResponse{
+		Rates: result.Rates,
+	}, nil
+}
+
+// normalizeCurrency upper cases an ISO 4217 currency code, an empty code is
+// the base currency.
+func normalizeCurrency(currency string) (string, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(currency))
+	if normalized == "" {
+		return domain.BaseCurrency, nil
+	}
+	if len(normalized) != 3 || strings.Trim(normalized, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return "", fmt.Errorf("invalid currency %q: %w", currency, domain.ErrInvalidArgument)
+	}
+	return normalized, nil
+}
+
+// reportingCurrency normalizes the currency a report is converted into and
+// checks that amounts can be converted into it.
+func (s Service) reportingCurrency(ctx context.Context, currency string) (string, error) {
+	normalized, err := normalizeCurrency(currency)
+	if err != nil {
+		return "", err
+	}
+	if err := s.requireExchangeRate(ctx, normalized); err != nil {
+		return "", err
+	}
+	return normalized, nil
+}
+
+// requireExchangeRate fails for currencies without any exchange rate, bets in
+// them could not be converted and would silently drop out of reports.
+func (s Service) requireExchangeRate(ctx context.Context, currency string) error {
+	result, err := s.store.ListExchangeRates(ctx, domain.ListExchangeRatesQuery{
+		Currency: currency,
+	})
+	if err != nil {
+		return err
+	}
+	if len(result.Rates) == 0 {
+		return fmt.Errorf("no exchange rate for currency %s: %w", currency, domain.ErrInvalidArgument)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+// maxImportLineSize bounds a single NDJSON line, so a body without newlines
+// can not be buffered whole.
+const maxImportLineSize = 1 << 20
+
+// importRecord maps the lower case column names of a row to their values.
+type importRecord map[string]string
+
+// recordReader reads the rows of an import one by one. Read returns io.EOF
+// after the last row. A rowError rejects only the current row and reading goes
+// on, any other error ends the import.
+type recordReader interface {
+	Read() (importRecord, error)
+}
+
+// rowError rejects a single row of an import.
+type rowError struct {
+	err error
+}
+
+func (e rowError) Error() string {
+	return e.err.Error()
+}
+
+func newRecordReader(format domain.ImportFormat, body io.Reader) (recordReader, error) {
+	switch format {
+	case domain.ImportFormatCSV:
+		return newCSVRecords(body)
+	case domain.ImportFormatNDJSON:
+		scanner := bufio.NewScanner(body)
+		scanner.Buffer(nil, maxImportLineSize)
+		return &ndjsonRecords{scanner: scanner}, nil
+	default:
+		return nil, fmt.Errorf("unknown import format %q: %w", format, domain.ErrInvalidArgument)
+	}
+}
+
+type csvRecords struct {
+	reader *csv.Reader
+	header []string
+}
+
+func newCSVRecords(body io.Reader) (*csvRecords, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("csv header is missing: %w", domain.ErrInvalidArgument)
+		}
+		return nil, fmt.Errorf("invalid csv header: %v: %w", err, domain.ErrInvalidArgument)
+	}
+	// Spreadsheet exports often start with a byte order mark.
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	for i, column := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(column))
+	}
+
+	return &csvRecords{reader: reader, header: header}, nil
+}
+
+func (r *csvRecords) Read() (importRecord, error) {
+	fields, err := r.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, rowError{fmt.Errorf("invalid csv row: %w", parseErr.Err)}
+		}
+		return nil, err
+	}
+
+	record := make(importRecord, len(fields))
+	for i, field := range fields {
+		record[r.header[i]] = strings.TrimSpace(field)
+	}
+	return record, nil
+}
+
+type ndjsonRecords struct {
+	scanner *bufio.Scanner
+}
+
+func (r *ndjsonRecords) Read() (importRecord, error) {
+	var line []byte
+	for len(line) == 0 {
+		if !r.scanner.Scan() {
+			if err := r.scanner.Err(); err != nil {
+				if errors.Is(err, bufio.ErrTooLong) {
+					return nil, fmt.Errorf("ndjson line longer than %d bytes: %w", maxImportLineSize, domain.ErrInvalidArgument)
+				}
+				return nil, fmt.Errorf("failed to read ndjson line: %w", err)
+			}
+			return nil, io.EOF
+		}
+		line = bytes.TrimSpace(r.scanner.Bytes())
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+
+	var object map[string]any
+	if err := decoder.Decode(&object); err != nil {
+		return nil, rowError{fmt.Errorf("invalid json: %w", err)}
+	}
+
+	record := make(importRecord, len(object))
+	for key, value := range object {
+		var s string
+		switch v := value.(type) {
+		case nil:
+		case string:
+			s = v
+		case json.Number:
+			s = v.String()
+		case bool:
+			s = strconv.FormatBool(v)
+		default:
+			return nil, rowError{fmt.Errorf("field %q must be a string or a number", key)}
+		}
+		record[strings.ToLower(key)] = strings.TrimSpace(s)
+	}
+	return record, nil
+}
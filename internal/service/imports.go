@@ -0,0 +1,298 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+const (
+	// importBatchSize is the number of rows written per transaction.
+	importBatchSize     = 500
+	maxExternalIDLength = 255
+	// maxImportErrors is the number of rejected rows reported, the errors of
+	// the rows after them are only counted.
+	maxImportErrors = 1000
+)
+
+// importTimeLayouts are the timestamp formats imports accept, timestamps
+// without a zone are UTC.
+var importTimeLayouts = []string{time.RFC3339Nano, time.DateTime, time.DateOnly}
+
+// Import validates every row of the body and writes the valid ones in batches,
+// each batch in a transaction of its own. Rejected rows are reported in the
+// response and do not stop the import. When an error is returned, the batches
+// before it stay imported.
+func (s Service) Import(ctx context.Context, req domain.ImportRequest) (domain.ImportResponse, error) {
+	records, err := newRecordReader(req.Format, req.Body)
+	if err != nil {
+		return domain.ImportResponse{}, err
+	}
+
+	switch req.Kind {
+	case domain.ImportKindPlayers:
+		return runImport(ctx, records, parseImportedPlayer, func(ctx context.Context, players []domain.ImportedPlayer) ([]domain.ImportRowResult, error) {
+			result, err := s.store.ImportPlayers(ctx, domain.ImportPlayersQuery{Players: players, DryRun: req.DryRun})
+			if err != nil {
+				return nil, err
+			}
+			return result.Rows, nil
+		})
+	case domain.ImportKindBets:
+		currencies := make(map[string]error)
+		parse := func(record importRecord) (domain.ImportedBet, error) {
+			return s.parseImportedBet(ctx, record, currencies)
+		}
+		return runImport(ctx, records, parse, func(ctx context.Context, bets []domain.ImportedBet) ([]domain.ImportRowResult, error) {
+			result, err := s.store.ImportBets(ctx, domain.ImportBetsQuery{Bets: bets, DryRun: req.DryRun})
+			if err != nil {
+				return nil, err
+			}
+			return result.Rows, nil
+		})
+	default:
+		return domain.ImportResponse{}, fmt.Errorf("unknown import kind %q: %w", req.Kind, domain.ErrInvalidArgument)
+	}
+}
+
+// runImport reads records until the end of the import, parses them with parse
+// and hands every full batch of parsed rows to write.
+func runImport[T any](
+	ctx context.Context,
+	records recordReader,
+	parse func(record importRecord) (T, error),
+	write func(ctx context.Context, batch []T) ([]domain.ImportRowResult, error),
+) (domain.ImportResponse, error) {
+	var (
+		resp  domain.ImportResponse
+		batch []T
+		// rows identifies the rows of batch in error reports.
+		rows []domain.ImportRowError
+	)
+
+	fail := func(row domain.ImportRowError, err error) {
+		row.Message = err.Error()
+		resp.Failed++
+		resp.Errors = append(resp.Errors, row)
+		// Trimming once twice as many are collected keeps the lowest rows
+		// even though rows rejected by the store are reported late.
+		if len(resp.Errors) == 2*maxImportErrors {
+			keepFirstErrors(&resp)
+		}
+	}
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		results, err := write(ctx, batch)
+		if err != nil {
+			return err
+		}
+		for i, result := range results {
+			switch {
+			case result.Err != nil:
+				fail(rows[i], result.Err)
+			case result.Skipped:
+				resp.Skipped++
+			default:
+				resp.Imported++
+			}
+		}
+
+		batch, rows = batch[:0], rows[:0]
+		return nil
+	}
+
+	for {
+		record, err := records.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var rowErr rowError
+		if err != nil && !errors.As(err, &rowErr) {
+			return domain.ImportResponse{}, err
+		}
+
+		resp.Rows++
+		row := domain.ImportRowError{Row: resp.Rows, ExternalID: record["external_id"]}
+
+		var item T
+		if err == nil {
+			item, err = parse(record)
+		}
+		if err != nil {
+			fail(row, err)
+			continue
+		}
+
+		batch = append(batch, item)
+		rows = append(rows, row)
+		if len(batch) == importBatchSize {
+			if err := flush(); err != nil {
+				return domain.ImportResponse{}, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return domain.ImportResponse{}, err
+	}
+
+	keepFirstErrors(&resp)
+
+	return resp, nil
+}
+
+// keepFirstErrors sorts the errors by row and drops all but the first
+// maxImportErrors. Rows rejected by the store are reported when their batch is
+// written, after the invalid rows read since.
+func keepFirstErrors(resp *domain.ImportResponse) {
+	slices.SortFunc(resp.Errors, func(a, b domain.ImportRowError) int {
+		return cmp.Compare(a.Row, b.Row)
+	})
+	if len(resp.Errors) > maxImportErrors {
+		resp.ErrorsDropped += len(resp.Errors) - maxImportErrors
+		resp.Errors = resp.Errors[:maxImportErrors]
+	}
+}
+
+func parseImportedPlayer(record importRecord) (domain.ImportedPlayer, error) {
+	externalID, err := parseExternalID(record, "external_id")
+	if err != nil {
+		return domain.ImportedPlayer{}, err
+	}
+
+	name, email, countryCode, err := normalizePlayer(record["name"], record["email"], record["country_code"])
+	if err != nil {
+		return domain.ImportedPlayer{}, err
+	}
+
+	createdAt, err := parseImportTime(record, "created_at")
+	if err != nil {
+		return domain.ImportedPlayer{}, err
+	}
+
+	return domain.ImportedPlayer{
+		ExternalID:  externalID,
+		Name:        name,
+		Email:       email,
+		CountryCode: countryCode,
+		CreatedAt:   createdAt,
+	}, nil
+}
+
+// parseImportedBet validates a bet row. currencies caches the outcome of the
+// exchange rate check of every currency seen so far.
+func (s Service) parseImportedBet(ctx context.Context, record importRecord, currencies map[string]error) (domain.ImportedBet, error) {
+	var (
+		b   domain.ImportedBet
+		err error
+	)
+
+	if b.ExternalID, err = parseExternalID(record, "external_id"); err != nil {
+		return b, err
+	}
+	if b.PlayerExternalID, err = parseExternalID(record, "player_external_id"); err != nil {
+		return b, err
+	}
+
+	if b.Amount, err = domain.ParseMoney(record["amount"]); err != nil {
+		return b, fmt.Errorf("invalid amount %q: %w", record["amount"], domain.ErrInvalidArgument)
+	}
+	if err := validateBetAmount(b.Amount); err != nil {
+		return b, err
+	}
+
+	if b.Currency, err = normalizeCurrency(record["currency"]); err != nil {
+		return b, err
+	}
+	rateErr, checked := currencies[b.Currency]
+	if !checked {
+		rateErr = s.requireExchangeRate(ctx, b.Currency)
+		if rateErr != nil && !errors.Is(rateErr, domain.ErrInvalidArgument) {
+			return b, rateErr
+		}
+		currencies[b.Currency] = rateErr
+	}
+	if rateErr != nil {
+		return b, rateErr
+	}
+
+	b.Status = domain.BetStatusOpen
+	if status := record["status"]; status != "" {
+		b.Status = domain.BetStatus(status)
+	}
+	if payout := record["payout"]; payout != "" {
+		if b.Payout, err = domain.ParseMoney(payout); err != nil {
+			return b, fmt.Errorf("invalid payout %q: %w", payout, domain.ErrInvalidArgument)
+		}
+	}
+
+	if b.CreatedAt, err = parseImportTime(record, "created_at"); err != nil {
+		return b, err
+	}
+	if b.SettledAt, err = parseImportTime(record, "settled_at"); err != nil {
+		return b, err
+	}
+
+	if b.Status == domain.BetStatusOpen {
+		if b.Payout != 0 {
+			return b, fmt.Errorf("only won bets have a payout: %w", domain.ErrInvalidArgument)
+		}
+		if !b.SettledAt.IsZero() {
+			return b, fmt.Errorf("open bets can not have settled_at: %w", domain.ErrInvalidArgument)
+		}
+		return b, nil
+	}
+
+	if err := validateSettlement(b.Status, b.Payout); err != nil {
+		return b, err
+	}
+	// Exports of platforms that do not track settlement times only say how a
+	// bet ended, it counts as settled when it was placed.
+	if b.SettledAt.IsZero() {
+		b.SettledAt = b.CreatedAt
+		if b.SettledAt.IsZero() {
+			b.SettledAt = time.Now()
+		}
+	}
+	if !b.CreatedAt.IsZero() && b.SettledAt.Before(b.CreatedAt) {
+		return b, fmt.Errorf("settled_at must not be before created_at: %w", domain.ErrInvalidArgument)
+	}
+
+	return b, nil
+}
+
+func parseExternalID(record importRecord, column string) (string, error) {
+	id := record[column]
+	if id == "" {
+		return "", fmt.Errorf("%s is required: %w", column, domain.ErrInvalidArgument)
+	}
+	if len(id) > maxExternalIDLength {
+		return "", fmt.Errorf("%s must be at most %d characters: %w", column, maxExternalIDLength, domain.ErrInvalidArgument)
+	}
+	return id, nil
+}
+
+// parseImportTime parses an optional timestamp column, an empty value is the
+// zero time.
+func parseImportTime(record importRecord, column string) (time.Time, error) {
+	value := record[column]
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range importTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid %s %q, expected RFC 3339: %w", column, value, domain.ErrInvalidArgument)
+}
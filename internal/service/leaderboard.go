@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+// maxLeaderboardEntries bounds the rows a leaderboard returns however many
+// players are tied on its ranks.
+const maxLeaderboardEntries = 1000
+
+func (s Service) GetLeaderboard(ctx context.Context, req domain.GetLeaderboardRequest) (domain.GetLeaderboardResponse, error) {
+	if req.Limit < 1 {
+		return domain.GetLeaderboardResponse{}, fmt.Errorf("limit must be positive: %w", domain.ErrInvalidArgument)
+	}
+	if err := validateWindow(req.From, req.To); err != nil {
+		return domain.GetLeaderboardResponse{}, err
+	}
+
+	countryCode, err := normalizeOptionalCountryCode(req.CountryCode)
+	if err != nil {
+		return domain.GetLeaderboardResponse{}, err
+	}
+	currency, err := s.reportingCurrency(ctx, req.Currency)
+	if err != nil {
+		return domain.GetLeaderboardResponse{}, err
+	}
+
+	result, err := s.store.GetTopPlayersByTotalWagered(ctx, domain.GetTopPlayersByTotalWageredQuery{
+		MaxRank:     req.Limit,
+		MaxEntries:  maxLeaderboardEntries,
+		CountryCode: countryCode,
+		From:        req.From,
+		To:          req.To,
+		Currency:    currency,
+	})
+	if err != nil {
+		return domain.GetLeaderboardResponse{}, err
+	}
+
+	return domain.GetLeaderboardResponse{
+		Currency: currency,
+		Entries:  result.Entries,
+	}, nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+func (s Service) GetNeighborComparison(ctx context.Context, req domain.GetNeighborComparisonRequest) (domain.GetNeighborComparisonResponse, error) {
+	countryCode, err := normalizeCountryCode(req.CountryCode)
+	if err != nil {
+		return domain.GetNeighborComparisonResponse{}, err
+	}
+	if err := validateWindow(req.From, req.To); err != nil {
+		return domain.GetNeighborComparisonResponse{}, err
+	}
+	currency, err := s.reportingCurrency(ctx, req.Currency)
+	if err != nil {
+		return domain.GetNeighborComparisonResponse{}, err
+	}
+
+	// Without the borders there is nothing to compare against, so unlike the
+	// other endpoints a failing country API is an error here.
+	info, err := s.countryAPIClient.GetCountryInfo(ctx, countryCode)
+	if err != nil {
+		return domain.GetNeighborComparisonResponse{}, fmt.Errorf("failed to fetch country info for %s: %w: %w", countryCode, domain.ErrUnavailable, err)
+	}
+
+	// Borders are alpha-3 codes while players are stored with alpha-2 ones.
+	// The registry resolves them, the country API only adds the names.
+	borderInfos, err := s.countryInfos(ctx, info.Borders)
+	if err != nil {
+		return domain.GetNeighborComparisonResponse{}, err
+	}
+
+	neighborCodes := make([]string, len(info.Borders))
+	codes := make([]string, 0, len(info.Borders)+1)
+	codes = append(codes, countryCode)
+	for i, border := range info.Borders {
+		code, ok := domain.CountryCodeFromAlpha3(border)
+		if !ok {
+			code = borderInfos[i].Code
+		}
+		if code == "" {
+			// Neither knows the border, it is reported as is without players.
+			slog.Warn("unknown alpha-3 country code", "country_code", border)
+			neighborCodes[i] = border
+			continue
+		}
+		neighborCodes[i] = code
+		codes = append(codes, code)
+	}
+
+	result, err := s.store.GetCountryStats(ctx, domain.GetCountryStatsQuery{
+		CountryCodes: codes,
+		From:         req.From,
+		To:           req.To,
+		Currency:     currency,
+	})
+	if err != nil {
+		return domain.GetNeighborComparisonResponse{}, err
+	}
+
+	stats := make(map[string]domain.CountryPlayerStats, len(result.Stats))
+	for _, stat := range result.Stats {
+		stats[stat.CountryCode] = stat
+	}
+
+	withInfo := func(code string, info domain.CountryInfo) domain.CountryPlayerStatsWithInfo {
+		stat, ok := stats[code]
+		if !ok {
+			stat = domain.CountryPlayerStats{CountryCode: code}
+		}
+		return domain.CountryPlayerStatsWithInfo{
+			CountryPlayerStats: stat,
+			CountryInfo:        info,
+		}
+	}
+
+	res := domain.GetNeighborComparisonResponse{
+		Currency:  currency,
+		Country:   withInfo(countryCode, info),
+		Neighbors: make([]domain.CountryPlayerStatsWithInfo, 0, len(neighborCodes)),
+	}
+	for i, code := range neighborCodes {
+		res.Neighbors = append(res.Neighbors, withInfo(code, borderInfos[i]))
+	}
+
+	return res, nil
+}
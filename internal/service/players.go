@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+func (s Service) CreatePlayer(ctx context.Context, req domain.CreatePlayerRequest) (domain.CreatePlayerResponse, error) {
+	name, email, countryCode, err := normalizePlayer(req.Name, req.Email, req.CountryCode)
+	if err != nil {
+		return domain.CreatePlayerResponse{}, err
+	}
+
+	result, err := s.store.CreatePlayer(ctx, domain.CreatePlayerQuery{
+		Name:        name,
+		Email:       email,
+		CountryCode: countryCode,
+	})
+	if err != nil {
+		return domain.CreatePlayerResponse{}, err
+	}
+
+	return domain.CreatePlayerResponse{
+		Player: result.Player,
+	}, nil
+}
+
+func (s Service) GetPlayer(ctx context.Context, req domain.GetPlayerRequest) (domain.GetPlayerResponse, error) {
+	result, err := s.store.GetPlayer(ctx, domain.GetPlayerQuery{
+		ID: req.ID,
+	})
+	if err != nil {
+		return domain.GetPlayerResponse{}, err
+	}
+
+	return domain.GetPlayerResponse{
+		Player: result.Player,
+	}, nil
+}
+
+func (s Service) ListPlayers(ctx context.Context, req domain.ListPlayersRequest) (domain.ListPlayersResponse, error) {
+	result, err := s.store.ListPlayers(ctx, domain.ListPlayersQuery{
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	})
+	if err != nil {
+		return domain.ListPlayersResponse{}, err
+	}
+
+	return domain.ListPlayersResponse{
+		Players: result.Players,
+	}, nil
+}
+
+func (s Service) UpdatePlayer(ctx context.Context, req domain.UpdatePlayerRequest) (domain.UpdatePlayerResponse, error) {
+	name, email, countryCode, err := normalizePlayer(req.Name, req.Email, req.CountryCode)
+	if err != nil {
+		return domain.UpdatePlayerResponse{}, err
+	}
+
+	result, err := s.store.UpdatePlayer(ctx, domain.UpdatePlayerQuery{
+		ID:          req.ID,
+		Name:        name,
+		Email:       email,
+		CountryCode: countryCode,
+	})
+	if err != nil {
+		return domain.UpdatePlayerResponse{}, err
+	}
+
+	return domain.UpdatePlayerResponse{
+		Player: result.Player,
+	}, nil
+}
+
+func (s Service) DeletePlayer(ctx context.Context, req domain.DeletePlayerRequest) error {
+	return s.store.DeletePlayer(ctx, domain.DeletePlayerQuery{
+		ID: req.ID,
+	})
+}
+
+func (s Service) GetPlayerStats(ctx context.Context, req domain.GetPlayerStatsRequest) (domain.GetPlayerStatsResponse, error) {
+	currency, err := s.reportingCurrency(ctx, req.Currency)
+	if err != nil {
+		return domain.GetPlayerStatsResponse{}, err
+	}
+
+	result, err := s.store.GetPlayerStats(ctx, domain.GetPlayerStatsQuery{
+		PlayerID: req.PlayerID,
+		Currency: currency,
+	})
+	if err != nil {
+		return domain.GetPlayerStatsResponse{}, err
+	}
+
+	return domain.GetPlayerStatsResponse{
+		Currency: currency,
+		Stats: domain.PlayerStatsWithInfo{
+			PlayerStats: result.Stats,
+			CountryInfo: s.countryInfo(ctx, result.Stats.Player.CountryCode),
+		},
+	}, nil
+}
+
+func normalizePlayer(name, email, countryCode string) (string, string, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", "", "", fmt.Errorf("name is required: %w", domain.ErrInvalidArgument)
+	}
+
+	email = strings.ToLower(strings.TrimSpace(email))
+	if _, err := mail.ParseAddress(email); err != nil {
+		return "", "", "", fmt.Errorf("invalid email %q: %w", email, domain.ErrInvalidArgument)
+	}
+
+	countryCode, err := normalizeCountryCode(countryCode)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	return name, email, countryCode, nil
+}
+
+// normalizeCountryCode returns the ISO 3166-1 alpha-2 code of countryCode,
+// known aliases like UK are replaced by their code.
+func normalizeCountryCode(countryCode string) (string, error) {
+	normalized, ok := domain.NormalizeCountryCode(countryCode)
+	if !ok {
+		return "", fmt.Errorf("invalid country code %q: %w", countryCode, domain.ErrInvalidArgument)
+	}
+	return normalized, nil
+}
+
+// normalizeOptionalCountryCode is normalizeCountryCode for filters where an
+// empty code means no filter.
+func normalizeOptionalCountryCode(countryCode string) (string, error) {
+	if strings.TrimSpace(countryCode) == "" {
+		return "", nil
+	}
+	return normalizeCountryCode(countryCode)
+}
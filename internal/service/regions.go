@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+// maxCountries comfortably exceeds the number of ISO 3166-1 countries, so a
+// single page holds all of them.
+const maxCountries = 1000
+
+func (s Service) GetRegionPlayerStats(ctx context.Context, req domain.GetRegionPlayerStatsRequest) (domain.GetRegionPlayerStatsResponse, error) {
+	if err := validateWindow(req.From, req.To); err != nil {
+		return domain.GetRegionPlayerStatsResponse{}, err
+	}
+
+	sortBy, order, err := normalizeSort(req.SortBy, req.Order)
+	if err != nil {
+		return domain.GetRegionPlayerStatsResponse{}, err
+	}
+	currency, err := s.reportingCurrency(ctx, req.Currency)
+	if err != nil {
+		return domain.GetRegionPlayerStatsResponse{}, err
+	}
+
+	result, err := s.store.GetTopCountriesByPlayerActivity(ctx, domain.GetTopCountriesByPlayerActivityQuery{
+		Limit:  maxCountries,
+		From:   req.From,
+		To:     req.To,
+		SortBy: domain.SortByPlayerCount,
+		Order:  domain.SortOrderDesc,
+
+		Currency: currency,
+	})
+	if err != nil {
+		return domain.GetRegionPlayerStatsResponse{}, err
+	}
+
+	codes := make([]string, 0, len(result.Stats))
+	for _, stat := range result.Stats {
+		codes = append(codes, stat.CountryCode)
+	}
+
+	infos, err := s.countryInfos(ctx, codes)
+	if err != nil {
+		return domain.GetRegionPlayerStatsResponse{}, err
+	}
+
+	byRegion := make(map[string]*domain.RegionPlayerStats)
+	for i, stat := range result.Stats {
+		region := infos[i].Region
+		if region == "" {
+			region = domain.UnknownRegion
+		}
+
+		r, ok := byRegion[region]
+		if !ok {
+			r = &domain.RegionPlayerStats{Region: region}
+			byRegion[region] = r
+		}
+		r.CountryCodes = append(r.CountryCodes, stat.CountryCode)
+		r.PlayerCount += stat.PlayerCount
+		r.TotalBets += stat.TotalBets
+		r.BetCount += stat.BetCount
+		r.Settlement.Add(stat.Settlement)
+	}
+
+	res := domain.GetRegionPlayerStatsResponse{
+		Currency: currency,
+		Regions:  make([]domain.RegionPlayerStats, 0, len(byRegion)),
+	}
+	for _, r := range byRegion {
+		r.AvgBetPerPlayer = r.TotalBets.DivRound(r.PlayerCount)
+		sort.Strings(r.CountryCodes)
+		res.Regions = append(res.Regions, *r)
+	}
+
+	sortRegions(res.Regions, sortBy, order)
+
+	return res, nil
+}
+
+// sortRegions orders regions the same way countries are ranked: by the chosen
+// metric, then by total bets and finally by name.
+func sortRegions(regions []domain.RegionPlayerStats, sortBy domain.CountryStatsSortField, order domain.SortOrder) {
+	metric := func(r domain.RegionPlayerStats) int64 {
+		switch sortBy {
+		case domain.SortByTotalBets:
+			return r.TotalBets.Cents()
+		case domain.SortByAvgBetPerPlayer:
+			return r.AvgBetPerPlayer.Cents()
+		case domain.SortByBetCount:
+			return int64(r.BetCount)
+		default:
+			return int64(r.PlayerCount)
+		}
+	}
+
+	sort.Slice(regions, func(i, j int) bool {
+		a, b := regions[i], regions[j]
+		if ma, mb := metric(a), metric(b); ma != mb {
+			if order == domain.SortOrderAsc {
+				return ma < mb
+			}
+			return ma > mb
+		}
+		if a.TotalBets != b.TotalBets {
+			return a.TotalBets > b.TotalBets
+		}
+		return a.Region < b.Region
+	})
+}
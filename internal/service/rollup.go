@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+// CheckCountryStatsRollup compares the country stats rollup against the bets
+// and optionally rebuilds the days that differ.
+func (s Service) CheckCountryStatsRollup(ctx context.Context, req domain.CheckCountryStatsRollupRequest) (domain.CheckCountryStatsRollupResponse, error) {
+	if err := validateWindow(req.From, req.To); err != nil {
+		return domain.CheckCountryStatsRollupResponse{}, err
+	}
+	for _, t := range []time.Time{req.From, req.To} {
+		if !t.IsZero() && !t.Equal(t.UTC().Truncate(24*time.Hour)) {
+			return domain.CheckCountryStatsRollupResponse{}, fmt.Errorf("rollup days start at midnight UTC, %s does not: %w", t.Format(time.RFC3339), domain.ErrInvalidArgument)
+		}
+	}
+
+	result, err := s.store.GetCountryDailyStats(ctx, domain.GetCountryDailyStatsQuery{
+		From: req.From,
+		To:   req.To,
+	})
+	if err != nil {
+		return domain.CheckCountryStatsRollupResponse{}, err
+	}
+
+	res := domain.CheckCountryStatsRollupResponse{
+		Stats:    compareDailyStats(result.RollupStats, result.BetStats),
+		Activity: compareDailyActivity(result.RollupActivity, result.BetActivity),
+	}
+	if !req.Repair {
+		return res, nil
+	}
+
+	days := mismatchDays(res)
+	slices.SortFunc(days, time.Time.Compare)
+	days = slices.CompactFunc(days, time.Time.Equal)
+
+	for _, day := range days {
+		err := s.store.RebuildCountryDailyStats(ctx, domain.RebuildCountryDailyStatsQuery{
+			From: day,
+			To:   day.AddDate(0, 0, 1),
+		})
+		if err != nil {
+			return res, fmt.Errorf("failed to rebuild %s: %w", day.Format(time.DateOnly), err)
+		}
+		res.Repaired = append(res.Repaired, day)
+	}
+
+	return res, nil
+}
+
+func mismatchDays(res domain.CheckCountryStatsRollupResponse) []time.Time {
+	days := make([]time.Time, 0, len(res.Stats)+len(res.Activity))
+	for _, m := range res.Stats {
+		days = append(days, m.Bets.Day)
+	}
+	for _, m := range res.Activity {
+		days = append(days, m.Bets.Day)
+	}
+	return days
+}
+
+type dailyKey struct {
+	countryCode string
+	day         time.Time
+	currency    string
+}
+
+func compareDailyStats(rollup, bets []domain.CountryDailyStats) []domain.CountryDailyStatsMismatch {
+	key := func(s domain.CountryDailyStats) dailyKey {
+		return dailyKey{countryCode: s.CountryCode, day: s.Day.UTC(), currency: s.Currency}
+	}
+	empty := func(k dailyKey) domain.CountryDailyStats {
+		return domain.CountryDailyStats{CountryCode: k.countryCode, Day: k.day, Currency: k.currency}
+	}
+
+	fromBets := make(map[dailyKey]domain.CountryDailyStats, len(bets))
+	for _, stat := range bets {
+		fromBets[key(stat)] = stat
+	}
+
+	mismatches := []domain.CountryDailyStatsMismatch{}
+	for _, stat := range rollup {
+		k := key(stat)
+		betStat, ok := fromBets[k]
+		if !ok {
+			betStat = empty(k)
+		}
+		delete(fromBets, k)
+		stat.Day, betStat.Day = k.day, k.day
+		if stat != betStat {
+			mismatches = append(mismatches, domain.CountryDailyStatsMismatch{Rollup: stat, Bets: betStat})
+		}
+	}
+	// Whatever is left has no rollup row at all.
+	for _, stat := range bets {
+		k := key(stat)
+		if _, ok := fromBets[k]; ok {
+			stat.Day = k.day
+			mismatches = append(mismatches, domain.CountryDailyStatsMismatch{Rollup: empty(k), Bets: stat})
+		}
+	}
+
+	return mismatches
+}
+
+func compareDailyActivity(rollup, bets []domain.CountryDailyActivity) []domain.CountryDailyActivityMismatch {
+	key := func(a domain.CountryDailyActivity) dailyKey {
+		return dailyKey{countryCode: a.CountryCode, day: a.Day.UTC()}
+	}
+
+	fromBets := make(map[dailyKey]int, len(bets))
+	for _, a := range bets {
+		fromBets[key(a)] = a.ActivePlayers
+	}
+
+	mismatches := []domain.CountryDailyActivityMismatch{}
+	for _, a := range rollup {
+		k := key(a)
+		active, ok := fromBets[k]
+		delete(fromBets, k)
+		if !ok || active != a.ActivePlayers {
+			mismatches = append(mismatches, domain.CountryDailyActivityMismatch{
+				Rollup: domain.CountryDailyActivity{CountryCode: k.countryCode, Day: k.day, ActivePlayers: a.ActivePlayers},
+				Bets:   domain.CountryDailyActivity{CountryCode: k.countryCode, Day: k.day, ActivePlayers: active},
+			})
+		}
+	}
+	for _, a := range bets {
+		k := key(a)
+		if _, ok := fromBets[k]; ok {
+			mismatches = append(mismatches, domain.CountryDailyActivityMismatch{
+				Rollup: domain.CountryDailyActivity{CountryCode: k.countryCode, Day: k.day},
+				Bets:   domain.CountryDailyActivity{CountryCode: k.countryCode, Day: k.day, ActivePlayers: a.ActivePlayers},
+			})
+		}
+	}
+
+	return mismatches
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
 	"golang.org/x/sync/errgroup"
@@ -23,8 +24,54 @@ func New(store domain.Store, countryAPIClient domain.CountryAPIClient) Service {
 }
 
 func (s Service) GetCountryPlayerStats(ctx context.Context, req domain.GetCountryPlayerStatsRequest) (domain.GetCountryPlayerStatsResponse, error) {
+	if req.Limit < 1 {
+		return domain.GetCountryPlayerStatsResponse{}, fmt.Errorf("limit must be positive: %w", domain.ErrInvalidArgument)
+	}
+	if err := validateWindow(req.From, req.To); err != nil {
+		return domain.GetCountryPlayerStatsResponse{}, err
+	}
+
+	var err error
+	req.SortBy, req.Order, err = normalizeSort(req.SortBy, req.Order)
+	if err != nil {
+		return domain.GetCountryPlayerStatsResponse{}, err
+	}
+	req.Currency, err = s.reportingCurrency(ctx, req.Currency)
+	if err != nil {
+		return domain.GetCountryPlayerStatsResponse{}, err
+	}
+
+	if req.IncludeDistribution {
+		if len(req.HistogramEdges) == 0 {
+			req.HistogramEdges = defaultHistogramEdges
+		}
+		if err := validateHistogramEdges(req.HistogramEdges); err != nil {
+			return domain.GetCountryPlayerStatsResponse{}, err
+		}
+	}
+
 	query := domain.GetTopCountriesByPlayerActivityQuery{
-		Limit: req.Limit,
+		Limit:  req.Limit,
+		From:   req.From,
+		To:     req.To,
+		SortBy: req.SortBy,
+		Order:  req.Order,
+
+		Currency: req.Currency,
+	}
+
+	if req.Cursor != "" {
+		cursor, err := domain.DecodeCountryStatsCursor(req.Cursor)
+		if err != nil {
+			return domain.GetCountryPlayerStatsResponse{}, err
+		}
+		if cursor.SortBy != req.SortBy || cursor.Order != req.Order {
+			return domain.GetCountryPlayerStatsResponse{}, fmt.Errorf("cursor was issued for a different ordering: %w", domain.ErrInvalidArgument)
+		}
+		if cursor.Currency != req.Currency {
+			return domain.GetCountryPlayerStatsResponse{}, fmt.Errorf("cursor was issued for a different currency: %w", domain.ErrInvalidArgument)
+		}
+		query.After = &cursor
 	}
 
 	result, err := s.store.GetTopCountriesByPlayerActivity(ctx, query)
@@ -32,30 +79,93 @@ func (s Service) GetCountryPlayerStats(ctx context.Context, req domain.GetCountr
 		return domain.GetCountryPlayerStatsResponse{}, err
 	}
 
+	codes := make([]string, 0, len(result.Stats))
+	for _, stat := range result.Stats {
+		codes = append(codes, stat.CountryCode)
+	}
+
+	infos, err := s.countryInfos(ctx, codes)
+	if err != nil {
+		return domain.GetCountryPlayerStatsResponse{}, err
+	}
+
+	var distributions map[string]domain.BetDistribution
+	if req.IncludeDistribution {
+		distributions, err = s.betDistributions(ctx, domain.GetBetDistributionByCountryQuery{
+			CountryCodes:   codes,
+			From:           req.From,
+			To:             req.To,
+			HistogramEdges: req.HistogramEdges,
+			Currency:       req.Currency,
+		})
+		if err != nil {
+			return domain.GetCountryPlayerStatsResponse{}, err
+		}
+	}
+
 	res := domain.GetCountryPlayerStatsResponse{
-		Stats: make([]domain.CountryPlayerStatsWithInfo, 0, len(result.Stats)),
+		Currency: req.Currency,
+		Stats:    make([]domain.CountryPlayerStatsWithInfo, 0, len(result.Stats)),
+	}
+	for i, stat := range result.Stats {
+		statWithInfo := domain.CountryPlayerStatsWithInfo{
+			CountryPlayerStats: stat,
+			CountryInfo:        infos[i],
+		}
+		if distribution, ok := distributions[stat.CountryCode]; ok {
+			statWithInfo.Distribution = &distribution
+		}
+		res.Stats = append(res.Stats, statWithInfo)
+	}
+	if result.Next != nil {
+		res.NextCursor = result.Next.Encode()
 	}
 
+	return res, nil
+}
+
+// normalizeSort applies the default ranking, most players first, and
+// validates the given one.
+func normalizeSort(sortBy domain.CountryStatsSortField, order domain.SortOrder) (domain.CountryStatsSortField, domain.SortOrder, error) {
+	if sortBy == "" {
+		sortBy = domain.SortByPlayerCount
+	}
+	if !sortBy.Valid() {
+		return "", "", fmt.Errorf("unknown sort field %q: %w", sortBy, domain.ErrInvalidArgument)
+	}
+	if order == "" {
+		order = domain.SortOrderDesc
+	}
+	if !order.Valid() {
+		return "", "", fmt.Errorf("unknown sort order %q: %w", order, domain.ErrInvalidArgument)
+	}
+	return sortBy, order, nil
+}
+
+// validateWindow checks a from/to time window where zero values leave that
+// side of the window open.
+func validateWindow(from, to time.Time) error {
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return fmt.Errorf("from must be before to: %w", domain.ErrInvalidArgument)
+	}
+	return nil
+}
+
+// countryInfos fetches the info of every country concurrently, the result is
+// index aligned with countryCodes.
+func (s Service) countryInfos(ctx context.Context, countryCodes []string) ([]domain.CountryInfo, error) {
 	g, ctx := errgroup.WithContext(ctx)
 	g.SetLimit(10)
 
 	var mu sync.Mutex
-	statsWithInfo := make([]domain.CountryPlayerStatsWithInfo, len(result.Stats))
+	infos := make([]domain.CountryInfo, len(countryCodes))
 
-	for i, stat := range result.Stats {
+	for i, countryCode := range countryCodes {
 		g.Go(func() error {
-			countryInfo, err := s.countryAPIClient.GetCountryInfo(ctx, stat.CountryCode)
-			if err != nil {
-				slog.Error("failed to fetch country info", "country_code", stat.CountryCode, "error", err)
-				// Graceful degradation
-				countryInfo = domain.CountryInfo{}
-			}
+			countryInfo := s.countryInfo(ctx, countryCode)
 
 			mu.Lock()
-			statsWithInfo[i] = domain.CountryPlayerStatsWithInfo{
-				CountryPlayerStats: stat,
-				CountryInfo:        countryInfo,
-			}
+			infos[i] = countryInfo
 			mu.Unlock()
 
 			return nil
@@ -63,10 +173,21 @@ func (s Service) GetCountryPlayerStats(ctx context.Context, req domain.GetCountr
 	}
 
 	if err := g.Wait(); err != nil {
-		return domain.GetCountryPlayerStatsResponse{}, fmt.Errorf("failed to wait: %w", err)
+		return nil, fmt.Errorf("failed to wait: %w", err)
 	}
 
-	res.Stats = statsWithInfo
+	return infos, nil
+}
 
-	return res, nil
+// countryInfo returns an empty CountryInfo when the country API fails, the
+// stats are still useful without it.
+func (s Service) countryInfo(ctx context.Context, countryCode string) domain.CountryInfo {
+	countryInfo, err := s.countryAPIClient.GetCountryInfo(ctx, countryCode)
+	if err != nil {
+		slog.Error("failed to fetch country info", "country_code", countryCode, "error", err)
+		// Graceful degradation
+		return domain.CountryInfo{}
+	}
+
+	return countryInfo
 }
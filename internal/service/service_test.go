@@ -4,74 +4,39 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"path/filepath"
+	"math"
+	"strings"
 	"testing"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"github.com/testcontainers/testcontainers-go"
-	"github.com/testcontainers/testcontainers-go/modules/mysql"
-	"github.com/testcontainers/testcontainers-go/wait"
 	"go.uber.org/mock/gomock"
 
 	"github.com/Nikola-Milovic/vyking-interview/internal/clients/mock"
 	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
 	"github.com/Nikola-Milovic/vyking-interview/internal/service"
 	"github.com/Nikola-Milovic/vyking-interview/internal/store"
+	"github.com/Nikola-Milovic/vyking-interview/internal/store/sqlite"
 	"github.com/Nikola-Milovic/vyking-interview/internal/testutil"
 )
 
-func setupTestDB(t *testing.T) (*sql.DB, func()) {
-	ctx := context.Background()
-
-	mysqlContainer, err := mysql.Run(ctx,
-		"mysql:lts",
-		mysql.WithDatabase("player_activity_test"),
-		mysql.WithUsername("test_user"),
-		mysql.WithPassword("test_pass"),
-		testcontainers.WithWaitStrategy(
-			wait.ForLog("port: 3306  MySQL Community Server").
-				WithOccurrence(1).
-				WithStartupTimeout(30*time.Second),
-		),
-	)
-	require.NoError(t, err)
-
-	host, err := mysqlContainer.Host(ctx)
-	require.NoError(t, err)
-
-	port, err := mysqlContainer.MappedPort(ctx, "3306")
-	require.NoError(t, err)
-
-	dsn := "test_user:test_pass@tcp(" + host + ":" + port.Port() + ")/player_activity_test?parseTime=true&multiStatements=true"
-	db, err := sql.Open("mysql", dsn)
-	require.NoError(t, err)
-
-	// Run migrations
-	migrationsPath := filepath.Join("..", "..", "migrations")
-	err = testutil.RunMigrations(db, migrationsPath)
-	require.NoError(t, err)
-
-	cleanup := func() {
-		db.Close()
-		if err := testcontainers.TerminateContainer(mysqlContainer); err != nil {
-			t.Logf("failed to terminate container: %s", err)
-		}
+// newTestStore returns the store for the database testutil.SetupDB started.
+func newTestStore(db *sql.DB) domain.Store {
+	if testutil.UseSQLite() {
+		return sqlite.New(db)
 	}
-
-	return db, cleanup
+	return store.New(db)
 }
 
 func TestService_GetCountryPlayerStats(t *testing.T) {
-	db, cleanup := setupTestDB(t)
+	db, cleanup := testutil.SetupDB(t)
 	defer cleanup()
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	store := store.New(db)
+	store := newTestStore(db)
 	mockCountryClient := mock.NewMockCountryAPIClient(ctrl)
 	svc := service.New(store, mockCountryClient)
 
@@ -103,7 +68,7 @@ func TestService_GetCountryPlayerStats(t *testing.T) {
 		Times(1)
 
 	mockCountryClient.EXPECT().
-		GetCountryInfo(gomock.Any(), "UK").
+		GetCountryInfo(gomock.Any(), "GB").
 		Return(domain.CountryInfo{
 			Name:    "United Kingdom",
 			Region:  "Europe",
@@ -133,8 +98,8 @@ func TestService_GetCountryPlayerStats(t *testing.T) {
 	firstStat := resp.Stats[0]
 	assert.Equal(t, "RS", firstStat.CountryCode)
 	assert.Equal(t, 10, firstStat.PlayerCount)
-	assert.Greater(t, firstStat.TotalBets, 0.0)
-	assert.Greater(t, firstStat.AvgBetPerPlayer, 0.0)
+	assert.Positive(t, firstStat.TotalBets)
+	assert.Positive(t, firstStat.AvgBetPerPlayer)
 	assert.NotNil(t, firstStat.CountryInfo)
 	assert.Equal(t, "Serbia", firstStat.CountryInfo.Name)
 	assert.Equal(t, "Europe", firstStat.CountryInfo.Region)
@@ -142,13 +107,13 @@ func TestService_GetCountryPlayerStats(t *testing.T) {
 }
 
 func TestService_GetCountryPlayerStats_WithAPIError(t *testing.T) {
-	db, cleanup := setupTestDB(t)
+	db, cleanup := testutil.SetupDB(t)
 	defer cleanup()
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	store := store.New(db)
+	store := newTestStore(db)
 	mockCountryClient := mock.NewMockCountryAPIClient(ctrl)
 	svc := service.New(store, mockCountryClient)
 
@@ -180,3 +145,1412 @@ func TestService_GetCountryPlayerStats_WithAPIError(t *testing.T) {
 	assert.True(t, resp.Stats[1].CountryInfo.IsZero())
 	assert.True(t, resp.Stats[2].CountryInfo.IsZero())
 }
+
+func TestService_PlayerLifecycle(t *testing.T) {
+	db, cleanup := testutil.SetupDB(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := newTestStore(db)
+	svc := service.New(store, mock.NewMockCountryAPIClient(ctrl))
+
+	ctx := context.Background()
+
+	created, err := svc.CreatePlayer(ctx, domain.CreatePlayerRequest{
+		Name:        "Petar Petrović",
+		Email:       " Petar.Petrovic@Example.com ",
+		CountryCode: "rs",
+	})
+	require.NoError(t, err)
+	assert.NotZero(t, created.Player.ID)
+	assert.Equal(t, "petar.petrovic@example.com", created.Player.Email)
+	assert.Equal(t, "RS", created.Player.CountryCode)
+
+	got, err := svc.GetPlayer(ctx, domain.GetPlayerRequest{ID: created.Player.ID})
+	require.NoError(t, err)
+	assert.Equal(t, created.Player, got.Player)
+
+	updated, err := svc.UpdatePlayer(ctx, domain.UpdatePlayerRequest{
+		ID:          created.Player.ID,
+		Name:        "Petar Petrović",
+		Email:       "petar@example.com",
+		CountryCode: "DE",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "petar@example.com", updated.Player.Email)
+	assert.Equal(t, "DE", updated.Player.CountryCode)
+
+	err = svc.DeletePlayer(ctx, domain.DeletePlayerRequest{ID: created.Player.ID})
+	require.NoError(t, err)
+
+	_, err = svc.GetPlayer(ctx, domain.GetPlayerRequest{ID: created.Player.ID})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	err = svc.DeletePlayer(ctx, domain.DeletePlayerRequest{ID: created.Player.ID})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestService_PlayerDuplicateEmail(t *testing.T) {
+	db, cleanup := testutil.SetupDB(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := newTestStore(db)
+	svc := service.New(store, mock.NewMockCountryAPIClient(ctrl))
+
+	ctx := context.Background()
+
+	_, err := svc.CreatePlayer(ctx, domain.CreatePlayerRequest{
+		Name:        "Marko Marković",
+		Email:       "marko.markovic@example.com",
+		CountryCode: "RS",
+	})
+	assert.ErrorIs(t, err, domain.ErrConflict)
+
+	_, err = svc.UpdatePlayer(ctx, domain.UpdatePlayerRequest{
+		ID:          1,
+		Name:        "Marko Marković",
+		Email:       "ana.petrovic@example.com",
+		CountryCode: "RS",
+	})
+	assert.ErrorIs(t, err, domain.ErrConflict)
+
+	_, err = svc.UpdatePlayer(ctx, domain.UpdatePlayerRequest{
+		ID:          999999,
+		Name:        "Nobody",
+		Email:       "nobody@example.com",
+		CountryCode: "RS",
+	})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestService_PlaceBet_Idempotency(t *testing.T) {
+	db, cleanup := testutil.SetupDB(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := newTestStore(db)
+	svc := service.New(store, mock.NewMockCountryAPIClient(ctrl))
+
+	ctx := context.Background()
+	req := domain.PlaceBetRequest{
+		PlayerID:       1,
+		Amount:         domain.MoneyFromCents(2550),
+		IdempotencyKey: "retry-me",
+	}
+
+	first, err := svc.PlaceBet(ctx, req)
+	require.NoError(t, err)
+	assert.False(t, first.Replayed)
+	assert.Equal(t, domain.MoneyFromCents(2550), first.Bet.Amount)
+
+	second, err := svc.PlaceBet(ctx, req)
+	require.NoError(t, err)
+	assert.True(t, second.Replayed)
+	assert.Equal(t, first.Bet.ID, second.Bet.ID)
+
+	req.Amount = domain.MoneyFromCents(3000)
+	_, err = svc.PlaceBet(ctx, req)
+	assert.ErrorIs(t, err, domain.ErrConflict)
+
+	_, err = svc.PlaceBet(ctx, domain.PlaceBetRequest{PlayerID: 999999, Amount: domain.MoneyFromCents(1000)})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	_, err = svc.PlaceBet(ctx, domain.PlaceBetRequest{PlayerID: 1, Amount: domain.MoneyFromCents(10000000000)})
+	assert.ErrorIs(t, err, domain.ErrInvalidArgument)
+}
+
+func TestService_GetCountryPlayerStats_TimeWindow(t *testing.T) {
+	db, cleanup := testutil.SetupDB(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := newTestStore(db)
+	mockCountryClient := mock.NewMockCountryAPIClient(ctrl)
+	svc := service.New(store, mockCountryClient)
+
+	mockCountryClient.EXPECT().
+		GetCountryInfo(gomock.Any(), gomock.Any()).
+		Return(domain.CountryInfo{}, nil).
+		AnyTimes()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	all, err := svc.GetCountryPlayerStats(ctx, domain.GetCountryPlayerStatsRequest{
+		Limit: 10,
+	})
+	require.NoError(t, err)
+
+	windowed, err := svc.GetCountryPlayerStats(ctx, domain.GetCountryPlayerStatsRequest{
+		Limit: 10,
+		From:  now.AddDate(-1, 0, 0),
+		To:    now.Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.LessOrEqual(t, len(windowed.Stats), len(all.Stats))
+
+	// Seed bets are all within the last 90 days, so only players that never
+	// bet can drop out of the windowed totals.
+	totals := make(map[string]domain.CountryPlayerStats, len(all.Stats))
+	for _, stat := range all.Stats {
+		totals[stat.CountryCode] = stat.CountryPlayerStats
+	}
+	for _, stat := range windowed.Stats {
+		assert.Equal(t, totals[stat.CountryCode].TotalBets, stat.TotalBets)
+		assert.LessOrEqual(t, stat.PlayerCount, totals[stat.CountryCode].PlayerCount)
+	}
+
+	future, err := svc.GetCountryPlayerStats(ctx, domain.GetCountryPlayerStatsRequest{
+		Limit: 10,
+		From:  now.Add(24 * time.Hour),
+	})
+	require.NoError(t, err)
+	assert.Empty(t, future.Stats)
+
+	_, err = svc.GetCountryPlayerStats(ctx, domain.GetCountryPlayerStatsRequest{
+		Limit: 10,
+		From:  now,
+		To:    now.Add(-time.Hour),
+	})
+	assert.ErrorIs(t, err, domain.ErrInvalidArgument)
+}
+
+func TestService_GetCountryPlayerStats_Sorting(t *testing.T) {
+	db, cleanup := testutil.SetupDB(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := newTestStore(db)
+	mockCountryClient := mock.NewMockCountryAPIClient(ctrl)
+	svc := service.New(store, mockCountryClient)
+
+	mockCountryClient.EXPECT().
+		GetCountryInfo(gomock.Any(), gomock.Any()).
+		Return(domain.CountryInfo{}, nil).
+		AnyTimes()
+
+	ctx := context.Background()
+
+	asc, err := svc.GetCountryPlayerStats(ctx, domain.GetCountryPlayerStatsRequest{
+		Limit:  10,
+		SortBy: domain.SortByPlayerCount,
+		Order:  domain.SortOrderAsc,
+	})
+	require.NoError(t, err)
+	require.Len(t, asc.Stats, 5)
+	assert.Equal(t, "ES", asc.Stats[0].CountryCode)
+	for i := 1; i < len(asc.Stats); i++ {
+		assert.LessOrEqual(t, asc.Stats[i-1].PlayerCount, asc.Stats[i].PlayerCount)
+	}
+
+	byTotal, err := svc.GetCountryPlayerStats(ctx, domain.GetCountryPlayerStatsRequest{
+		Limit:  10,
+		SortBy: domain.SortByTotalBets,
+		Order:  domain.SortOrderDesc,
+	})
+	require.NoError(t, err)
+	for i := 1; i < len(byTotal.Stats); i++ {
+		assert.GreaterOrEqual(t, byTotal.Stats[i-1].TotalBets, byTotal.Stats[i].TotalBets)
+	}
+
+	_, err = svc.GetCountryPlayerStats(ctx, domain.GetCountryPlayerStatsRequest{
+		Limit:  10,
+		SortBy: "name; DROP TABLE players",
+	})
+	assert.ErrorIs(t, err, domain.ErrInvalidArgument)
+}
+
+func TestService_GetCountryPlayerStats_Pagination(t *testing.T) {
+	db, cleanup := testutil.SetupDB(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := newTestStore(db)
+	mockCountryClient := mock.NewMockCountryAPIClient(ctrl)
+	svc := service.New(store, mockCountryClient)
+
+	// Enrichment only runs for the countries of the returned pages: for each
+	// ordering once for the full listing and once across the paged walk.
+	mockCountryClient.EXPECT().
+		GetCountryInfo(gomock.Any(), gomock.Any()).
+		Return(domain.CountryInfo{}, nil).
+		Times(2 * (5 + 5))
+
+	ctx := context.Background()
+
+	for _, sortBy := range []domain.CountryStatsSortField{domain.SortByPlayerCount, domain.SortByAvgBetPerPlayer} {
+		all, err := svc.GetCountryPlayerStats(ctx, domain.GetCountryPlayerStatsRequest{
+			Limit:  10,
+			SortBy: sortBy,
+		})
+		require.NoError(t, err)
+		assert.Empty(t, all.NextCursor)
+
+		var (
+			paged  []string
+			cursor string
+		)
+		for page := 0; page < 3; page++ {
+			resp, err := svc.GetCountryPlayerStats(ctx, domain.GetCountryPlayerStatsRequest{
+				Limit:  2,
+				SortBy: sortBy,
+				Cursor: cursor,
+			})
+			require.NoError(t, err)
+			for _, stat := range resp.Stats {
+				paged = append(paged, stat.CountryCode)
+			}
+			cursor = resp.NextCursor
+			if cursor == "" {
+				break
+			}
+		}
+		assert.Empty(t, cursor)
+
+		expected := make([]string, 0, len(all.Stats))
+		for _, stat := range all.Stats {
+			expected = append(expected, stat.CountryCode)
+		}
+		assert.Equal(t, expected, paged)
+	}
+}
+
+func TestService_GetCountryPlayerStats_CursorMismatch(t *testing.T) {
+	db, cleanup := testutil.SetupDB(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := newTestStore(db)
+	mockCountryClient := mock.NewMockCountryAPIClient(ctrl)
+	svc := service.New(store, mockCountryClient)
+
+	mockCountryClient.EXPECT().
+		GetCountryInfo(gomock.Any(), gomock.Any()).
+		Return(domain.CountryInfo{}, nil).
+		AnyTimes()
+
+	ctx := context.Background()
+
+	first, err := svc.GetCountryPlayerStats(ctx, domain.GetCountryPlayerStatsRequest{Limit: 1})
+	require.NoError(t, err)
+	require.NotEmpty(t, first.NextCursor)
+
+	_, err = svc.GetCountryPlayerStats(ctx, domain.GetCountryPlayerStatsRequest{
+		Limit:  1,
+		SortBy: domain.SortByTotalBets,
+		Cursor: first.NextCursor,
+	})
+	assert.ErrorIs(t, err, domain.ErrInvalidArgument)
+
+	_, err = svc.GetCountryPlayerStats(ctx, domain.GetCountryPlayerStatsRequest{
+		Limit:  1,
+		Cursor: "not-a-cursor",
+	})
+	assert.ErrorIs(t, err, domain.ErrInvalidArgument)
+}
+
+func TestService_GetPlayerStats(t *testing.T) {
+	db, cleanup := testutil.SetupDB(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := newTestStore(db)
+	mockCountryClient := mock.NewMockCountryAPIClient(ctrl)
+	svc := service.New(store, mockCountryClient)
+
+	mockCountryClient.EXPECT().
+		GetCountryInfo(gomock.Any(), "DE").
+		Return(domain.CountryInfo{
+			Name:    "Germany",
+			Region:  "Europe",
+			Borders: []string{"AT", "BE", "CZ", "DK", "FR", "LU", "NL", "PL", "CH"},
+		}, nil).
+		Times(2)
+
+	ctx := context.Background()
+
+	created, err := svc.CreatePlayer(ctx, domain.CreatePlayerRequest{
+		Name:        "Lena Braun",
+		Email:       "lena.braun@example.com",
+		CountryCode: "DE",
+	})
+	require.NoError(t, err)
+
+	empty, err := svc.GetPlayerStats(ctx, domain.GetPlayerStatsRequest{PlayerID: created.Player.ID})
+	require.NoError(t, err)
+	assert.Zero(t, empty.Stats.BetCount)
+	assert.True(t, empty.Stats.FirstBetAt.IsZero())
+	assert.Equal(t, "Germany", empty.Stats.CountryInfo.Name)
+
+	for _, amount := range []domain.Money{1000, 2000, 6000} {
+		_, err := svc.PlaceBet(ctx, domain.PlaceBetRequest{PlayerID: created.Player.ID, Amount: amount})
+		require.NoError(t, err)
+	}
+
+	resp, err := svc.GetPlayerStats(ctx, domain.GetPlayerStatsRequest{PlayerID: created.Player.ID})
+	require.NoError(t, err)
+	assert.Equal(t, created.Player.ID, resp.Stats.Player.ID)
+	assert.Equal(t, 3, resp.Stats.BetCount)
+	assert.Equal(t, domain.MoneyFromCents(9000), resp.Stats.TotalWagered)
+	assert.Equal(t, domain.MoneyFromCents(3000), resp.Stats.AvgBet)
+	assert.Equal(t, domain.MoneyFromCents(1000), resp.Stats.MinBet)
+	assert.Equal(t, domain.MoneyFromCents(6000), resp.Stats.MaxBet)
+	assert.False(t, resp.Stats.FirstBetAt.IsZero())
+	assert.False(t, resp.Stats.LastBetAt.Before(resp.Stats.FirstBetAt))
+
+	_, err = svc.GetPlayerStats(ctx, domain.GetPlayerStatsRequest{PlayerID: 999999})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestService_GetLeaderboard(t *testing.T) {
+	db, cleanup := testutil.SetupDB(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := newTestStore(db)
+	svc := service.New(store, mock.NewMockCountryAPIClient(ctrl))
+
+	ctx := context.Background()
+
+	wagers := []struct {
+		email  string
+		amount domain.Money
+	}{
+		{"rui@example.com", 30000},
+		{"ines@example.com", 30000},
+		{"tiago@example.com", 20000},
+		{"sofia@example.com", 10000},
+	}
+	for _, w := range wagers {
+		created, err := svc.CreatePlayer(ctx, domain.CreatePlayerRequest{
+			Name:        w.email,
+			Email:       w.email,
+			CountryCode: "PT",
+		})
+		require.NoError(t, err)
+
+		_, err = svc.PlaceBet(ctx, domain.PlaceBetRequest{PlayerID: created.Player.ID, Amount: w.amount})
+		require.NoError(t, err)
+	}
+
+	resp, err := svc.GetLeaderboard(ctx, domain.GetLeaderboardRequest{
+		Limit:       2,
+		CountryCode: "pt",
+	})
+	require.NoError(t, err)
+
+	// The two tied players share the first rank, the next one is ranked second.
+	require.Len(t, resp.Entries, 3)
+	assert.Equal(t, 1, resp.Entries[0].Rank)
+	assert.Equal(t, 1, resp.Entries[1].Rank)
+	assert.Equal(t, 2, resp.Entries[2].Rank)
+	assert.Equal(t, domain.MoneyFromCents(20000), resp.Entries[2].TotalWagered)
+	for _, entry := range resp.Entries {
+		assert.Equal(t, "PT", entry.CountryCode)
+	}
+
+	global, err := svc.GetLeaderboard(ctx, domain.GetLeaderboardRequest{Limit: 3})
+	require.NoError(t, err)
+	require.NotEmpty(t, global.Entries)
+	for i := 1; i < len(global.Entries); i++ {
+		assert.GreaterOrEqual(t, global.Entries[i-1].TotalWagered, global.Entries[i].TotalWagered)
+	}
+
+	future, err := svc.GetLeaderboard(ctx, domain.GetLeaderboardRequest{
+		Limit: 3,
+		From:  time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	assert.Empty(t, future.Entries)
+}
+
+func TestService_GetCountryActivityTimeSeries(t *testing.T) {
+	db, cleanup := testutil.SetupDB(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := newTestStore(db)
+	svc := service.New(store, mock.NewMockCountryAPIClient(ctrl))
+
+	ctx := context.Background()
+	belgrade, err := time.LoadLocation("Europe/Belgrade")
+	require.NoError(t, err)
+
+	created, err := svc.CreatePlayer(ctx, domain.CreatePlayerRequest{
+		Name:        "Luka Ilić",
+		Email:       "luka.ilic@example.com",
+		CountryCode: "ME",
+	})
+	require.NoError(t, err)
+	_, err = svc.PlaceBet(ctx, domain.PlaceBetRequest{PlayerID: created.Player.ID, Amount: domain.MoneyFromCents(4000)})
+	require.NoError(t, err)
+
+	now := time.Now()
+	resp, err := svc.GetCountryActivityTimeSeries(ctx, domain.GetCountryActivityTimeSeriesRequest{
+		From:        now.AddDate(0, 0, -6),
+		To:          now.Add(time.Minute),
+		Interval:    domain.BucketDay,
+		Location:    belgrade,
+		CountryCode: "me",
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Series, 1)
+
+	series := resp.Series[0]
+	assert.Equal(t, "ME", series.CountryCode)
+	require.Len(t, series.Buckets, 7)
+
+	// Only today's bucket has activity, the earlier days are zero filled.
+	for _, bucket := range series.Buckets[:6] {
+		assert.Zero(t, bucket.BetCount)
+		assert.Zero(t, bucket.ActivePlayers)
+	}
+	today := series.Buckets[6]
+	assert.Equal(t, 1, today.BetCount)
+	assert.Equal(t, 1, today.ActivePlayers)
+	assert.Equal(t, domain.MoneyFromCents(4000), today.TotalBets)
+	assert.Equal(t, belgrade, today.Start.Location())
+	assert.Zero(t, today.Start.Hour())
+
+	// Buckets stay aligned to local midnight across the DST change.
+	dst, err := svc.GetCountryActivityTimeSeries(ctx, domain.GetCountryActivityTimeSeriesRequest{
+		From:        time.Date(2025, 3, 29, 0, 0, 0, 0, belgrade),
+		To:          time.Date(2025, 4, 1, 0, 0, 0, 0, belgrade),
+		Interval:    domain.BucketDay,
+		Location:    belgrade,
+		CountryCode: "ME",
+	})
+	require.NoError(t, err)
+	require.Len(t, dst.Series, 1)
+	require.Len(t, dst.Series[0].Buckets, 3)
+	assert.Equal(t, 23*time.Hour, dst.Series[0].Buckets[2].Start.Sub(dst.Series[0].Buckets[1].Start))
+
+	_, err = svc.GetCountryActivityTimeSeries(ctx, domain.GetCountryActivityTimeSeriesRequest{
+		From:     now.AddDate(-5, 0, 0),
+		To:       now,
+		Interval: domain.BucketDay,
+	})
+	assert.ErrorIs(t, err, domain.ErrInvalidArgument)
+}
+
+func TestService_GetCountryPlayerStats_Distribution(t *testing.T) {
+	db, cleanup := testutil.SetupDB(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := newTestStore(db)
+	mockCountryClient := mock.NewMockCountryAPIClient(ctrl)
+	svc := service.New(store, mockCountryClient)
+
+	mockCountryClient.EXPECT().
+		GetCountryInfo(gomock.Any(), gomock.Any()).
+		Return(domain.CountryInfo{}, nil).
+		AnyTimes()
+
+	ctx := context.Background()
+
+	created, err := svc.CreatePlayer(ctx, domain.CreatePlayerRequest{
+		Name:        "Diogo Sousa",
+		Email:       "diogo.sousa@example.com",
+		CountryCode: "PT",
+	})
+	require.NoError(t, err)
+	for _, amount := range []domain.Money{100, 200, 300, 400, 10000} {
+		_, err := svc.PlaceBet(ctx, domain.PlaceBetRequest{PlayerID: created.Player.ID, Amount: amount})
+		require.NoError(t, err)
+	}
+
+	resp, err := svc.GetCountryPlayerStats(ctx, domain.GetCountryPlayerStatsRequest{
+		Limit:               1,
+		SortBy:              domain.SortByPlayerCount,
+		Order:               domain.SortOrderAsc,
+		IncludeDistribution: true,
+		HistogramEdges:      []float64{0, 2.5, 50},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Stats, 1)
+	require.Equal(t, "PT", resp.Stats[0].CountryCode)
+	require.NotNil(t, resp.Stats[0].Distribution)
+
+	distribution := resp.Stats[0].Distribution
+	assert.Equal(t, 5, distribution.Count)
+	assert.InDelta(t, 1.0, distribution.Min, 0.001)
+	assert.InDelta(t, 100.0, distribution.Max, 0.001)
+	assert.InDelta(t, 3.0, distribution.Median, 0.001)
+	assert.InDelta(t, 61.6, distribution.P90, 0.001)
+	assert.InDelta(t, 96.16, distribution.P99, 0.001)
+	assert.InDelta(t, 39.01, distribution.StdDev, 0.01)
+
+	require.Len(t, distribution.Histogram, 3)
+	assert.Equal(t, 2, distribution.Histogram[0].Count)
+	assert.Equal(t, 2, distribution.Histogram[1].Count)
+	assert.Equal(t, 1, distribution.Histogram[2].Count)
+	assert.True(t, math.IsInf(distribution.Histogram[2].UpperBound, 1))
+
+	withoutDistribution, err := svc.GetCountryPlayerStats(ctx, domain.GetCountryPlayerStatsRequest{Limit: 1})
+	require.NoError(t, err)
+	assert.Nil(t, withoutDistribution.Stats[0].Distribution)
+
+	_, err = svc.GetCountryPlayerStats(ctx, domain.GetCountryPlayerStatsRequest{
+		Limit:               1,
+		IncludeDistribution: true,
+		HistogramEdges:      []float64{10, 5},
+	})
+	assert.ErrorIs(t, err, domain.ErrInvalidArgument)
+}
+
+func TestService_GetRegionPlayerStats(t *testing.T) {
+	db, cleanup := testutil.SetupDB(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := newTestStore(db)
+	mockCountryClient := mock.NewMockCountryAPIClient(ctrl)
+	svc := service.New(store, mockCountryClient)
+
+	for _, code := range []string{"RS", "DE", "GB", "ES"} {
+		mockCountryClient.EXPECT().
+			GetCountryInfo(gomock.Any(), code).
+			Return(domain.CountryInfo{Name: code, Region: "Europe"}, nil).
+			AnyTimes()
+	}
+	mockCountryClient.EXPECT().
+		GetCountryInfo(gomock.Any(), "BR").
+		Return(domain.CountryInfo{}, fmt.Errorf("API error")).
+		AnyTimes()
+
+	ctx := context.Background()
+
+	countries, err := svc.GetCountryPlayerStats(ctx, domain.GetCountryPlayerStatsRequest{Limit: 10})
+	require.NoError(t, err)
+
+	var europe domain.RegionPlayerStats
+	for _, stat := range countries.Stats {
+		if stat.CountryCode == "BR" {
+			continue
+		}
+		europe.PlayerCount += stat.PlayerCount
+		europe.TotalBets += stat.TotalBets
+		europe.BetCount += stat.BetCount
+	}
+
+	resp, err := svc.GetRegionPlayerStats(ctx, domain.GetRegionPlayerStatsRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.Regions, 2)
+
+	assert.Equal(t, "Europe", resp.Regions[0].Region)
+	assert.Equal(t, []string{"DE", "ES", "GB", "RS"}, resp.Regions[0].CountryCodes)
+	assert.Equal(t, europe.PlayerCount, resp.Regions[0].PlayerCount)
+	assert.Equal(t, europe.BetCount, resp.Regions[0].BetCount)
+	assert.Equal(t, europe.TotalBets, resp.Regions[0].TotalBets)
+	assert.Equal(t, europe.TotalBets.DivRound(europe.PlayerCount), resp.Regions[0].AvgBetPerPlayer)
+
+	assert.Equal(t, domain.UnknownRegion, resp.Regions[1].Region)
+	assert.Equal(t, []string{"BR"}, resp.Regions[1].CountryCodes)
+	assert.Equal(t, 7, resp.Regions[1].PlayerCount)
+}
+
+func TestService_GetNeighborComparison(t *testing.T) {
+	db, cleanup := testutil.SetupDB(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := newTestStore(db)
+	mockCountryClient := mock.NewMockCountryAPIClient(ctrl)
+	svc := service.New(store, mockCountryClient)
+
+	mockCountryClient.EXPECT().
+		GetCountryInfo(gomock.Any(), "RS").
+		Return(domain.CountryInfo{Code: "RS", Name: "Serbia", Region: "Europe", Borders: []string{"HUN", "XKX", "BGR"}}, nil).
+		AnyTimes()
+	mockCountryClient.EXPECT().
+		GetCountryInfo(gomock.Any(), "HUN").
+		Return(domain.CountryInfo{Code: "HU", Name: "Hungary", Region: "Europe"}, nil).
+		AnyTimes()
+	mockCountryClient.EXPECT().
+		GetCountryInfo(gomock.Any(), "BGR").
+		Return(domain.CountryInfo{Code: "BG", Name: "Bulgaria", Region: "Europe"}, nil).
+		AnyTimes()
+	mockCountryClient.EXPECT().
+		GetCountryInfo(gomock.Any(), "XKX").
+		Return(domain.CountryInfo{}, fmt.Errorf("API error")).
+		AnyTimes()
+	ctx := context.Background()
+
+	created, err := svc.CreatePlayer(ctx, domain.CreatePlayerRequest{Name: "Hungarian", Email: "hu@example.com", CountryCode: "HU"})
+	require.NoError(t, err)
+	_, err = svc.PlaceBet(ctx, domain.PlaceBetRequest{PlayerID: created.Player.ID, Amount: domain.MoneyFromCents(4250)})
+	require.NoError(t, err)
+
+	resp, err := svc.GetNeighborComparison(ctx, domain.GetNeighborComparisonRequest{CountryCode: "rs"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "RS", resp.Country.CountryCode)
+	assert.Equal(t, "Serbia", resp.Country.CountryInfo.Name)
+	assert.Positive(t, resp.Country.PlayerCount)
+
+	// Every border is reported, XKX through the registry although the country
+	// API failed for it.
+	require.Len(t, resp.Neighbors, 3)
+
+	assert.Equal(t, "HU", resp.Neighbors[0].CountryCode)
+	assert.Equal(t, "Hungary", resp.Neighbors[0].CountryInfo.Name)
+	assert.Equal(t, 1, resp.Neighbors[0].PlayerCount)
+	assert.Equal(t, 1, resp.Neighbors[0].BetCount)
+	assert.Equal(t, domain.MoneyFromCents(4250), resp.Neighbors[0].TotalBets)
+
+	assert.Equal(t, domain.CountryPlayerStats{CountryCode: "XK"}, resp.Neighbors[1].CountryPlayerStats)
+	assert.Empty(t, resp.Neighbors[1].CountryInfo.Name)
+
+	assert.Equal(t, "BG", resp.Neighbors[2].CountryCode)
+	assert.Equal(t, "Bulgaria", resp.Neighbors[2].CountryInfo.Name)
+	assert.Equal(t, domain.CountryPlayerStats{CountryCode: "BG"}, resp.Neighbors[2].CountryPlayerStats)
+
+	_, err = svc.GetNeighborComparison(ctx, domain.GetNeighborComparisonRequest{CountryCode: "SRB"})
+	assert.ErrorIs(t, err, domain.ErrInvalidArgument)
+}
+
+func TestService_GetNeighborComparison_APIError(t *testing.T) {
+	db, cleanup := testutil.SetupDB(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := newTestStore(db)
+	mockCountryClient := mock.NewMockCountryAPIClient(ctrl)
+	svc := service.New(store, mockCountryClient)
+
+	mockCountryClient.EXPECT().
+		GetCountryInfo(gomock.Any(), "RS").
+		Return(domain.CountryInfo{}, fmt.Errorf("API error"))
+
+	_, err := svc.GetNeighborComparison(context.Background(), domain.GetNeighborComparisonRequest{CountryCode: "RS"})
+	assert.ErrorIs(t, err, domain.ErrUnavailable)
+}
+
+func TestService_GetPlayerStats_AverageRounding(t *testing.T) {
+	db, cleanup := testutil.SetupDB(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := newTestStore(db)
+	mockCountryClient := mock.NewMockCountryAPIClient(ctrl)
+	svc := service.New(store, mockCountryClient)
+
+	mockCountryClient.EXPECT().
+		GetCountryInfo(gomock.Any(), "PT").
+		Return(domain.CountryInfo{Name: "Portugal"}, nil).
+		AnyTimes()
+
+	ctx := context.Background()
+
+	created, err := svc.CreatePlayer(ctx, domain.CreatePlayerRequest{
+		Name:        "Beatriz Lopes",
+		Email:       "beatriz.lopes@example.com",
+		CountryCode: "PT",
+	})
+	require.NoError(t, err)
+
+	// Averages are rounded to the cent with halves away from zero.
+	expected := []domain.Money{1, 2, 1}
+	for i, amount := range []domain.Money{1, 2, 1} {
+		_, err := svc.PlaceBet(ctx, domain.PlaceBetRequest{PlayerID: created.Player.ID, Amount: amount})
+		require.NoError(t, err)
+
+		resp, err := svc.GetPlayerStats(ctx, domain.GetPlayerStatsRequest{PlayerID: created.Player.ID})
+		require.NoError(t, err)
+		assert.Equal(t, expected[i], resp.Stats.AvgBet, "after %d bets", i+1)
+	}
+}
+
+func TestService_GetCountryPlayerStats_Currency(t *testing.T) {
+	db, cleanup := testutil.SetupDB(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := newTestStore(db)
+	mockCountryClient := mock.NewMockCountryAPIClient(ctrl)
+	svc := service.New(store, mockCountryClient)
+
+	mockCountryClient.EXPECT().
+		GetCountryInfo(gomock.Any(), gomock.Any()).
+		Return(domain.CountryInfo{}, nil).
+		AnyTimes()
+
+	ctx := context.Background()
+
+	created, err := svc.CreatePlayer(ctx, domain.CreatePlayerRequest{
+		Name:        "Petar Trajkovski",
+		Email:       "petar.trajkovski@example.com",
+		CountryCode: "MK",
+	})
+	require.NoError(t, err)
+
+	// 11717.00 RSD is 100.00 EUR at the seeded rate of 117.17.
+	_, err = svc.PlaceBet(ctx, domain.PlaceBetRequest{PlayerID: created.Player.ID, Amount: domain.MoneyFromCents(1171700), Currency: "rsd"})
+	require.NoError(t, err)
+	bet, err := svc.PlaceBet(ctx, domain.PlaceBetRequest{PlayerID: created.Player.ID, Amount: domain.MoneyFromCents(1000)})
+	require.NoError(t, err)
+	assert.Equal(t, domain.BaseCurrency, bet.Bet.Currency)
+
+	_, err = svc.PlaceBet(ctx, domain.PlaceBetRequest{PlayerID: created.Player.ID, Amount: domain.MoneyFromCents(1000), Currency: "USD"})
+	assert.ErrorIs(t, err, domain.ErrInvalidArgument)
+
+	// A rate that only applies in the future must not touch the bets placed today.
+	_, err = svc.CreateExchangeRate(ctx, domain.CreateExchangeRateRequest{
+		Currency:      "RSD",
+		EffectiveFrom: time.Now().AddDate(0, 0, 2),
+		Rate:          200,
+	})
+	require.NoError(t, err)
+
+	macedonia := func(currency string) domain.CountryPlayerStatsWithInfo {
+		resp, err := svc.GetCountryPlayerStats(ctx, domain.GetCountryPlayerStatsRequest{Limit: 100, Currency: currency})
+		require.NoError(t, err)
+		for _, stat := range resp.Stats {
+			if stat.CountryCode == "MK" {
+				return stat
+			}
+		}
+		t.Fatalf("MK missing from %s stats", currency)
+		return domain.CountryPlayerStatsWithInfo{}
+	}
+
+	assert.Equal(t, domain.MoneyFromCents(11000), macedonia("").TotalBets)
+	assert.Equal(t, domain.MoneyFromCents(1288870), macedonia("RSD").TotalBets)
+
+	// The distribution, leaderboard, time series and player stats convert the
+	// bets the same way.
+	withDistribution, err := svc.GetCountryPlayerStats(ctx, domain.GetCountryPlayerStatsRequest{Limit: 100, Currency: "RSD", IncludeDistribution: true})
+	require.NoError(t, err)
+	var distribution *domain.BetDistribution
+	for _, stat := range withDistribution.Stats {
+		if stat.CountryCode == "MK" {
+			distribution = stat.Distribution
+		}
+	}
+	require.NotNil(t, distribution)
+	assert.InDelta(t, 1171.70, distribution.Min, 1e-9)
+	assert.InDelta(t, 11717.00, distribution.Max, 1e-9)
+
+	leaderboard, err := svc.GetLeaderboard(ctx, domain.GetLeaderboardRequest{Limit: 1, CountryCode: "MK", Currency: "rsd"})
+	require.NoError(t, err)
+	assert.Equal(t, "RSD", leaderboard.Currency)
+	require.Len(t, leaderboard.Entries, 1)
+	assert.Equal(t, domain.MoneyFromCents(1288870), leaderboard.Entries[0].TotalWagered)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	series, err := svc.GetCountryActivityTimeSeries(ctx, domain.GetCountryActivityTimeSeriesRequest{
+		From:        today,
+		To:          today.AddDate(0, 0, 1),
+		Interval:    domain.BucketDay,
+		CountryCode: "MK",
+		Currency:    "RSD",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "RSD", series.Currency)
+	require.Len(t, series.Series, 1)
+	assert.Equal(t, domain.MoneyFromCents(1288870), series.Series[0].Buckets[0].TotalBets)
+
+	playerStats, err := svc.GetPlayerStats(ctx, domain.GetPlayerStatsRequest{PlayerID: created.Player.ID, Currency: "RSD"})
+	require.NoError(t, err)
+	assert.Equal(t, "RSD", playerStats.Currency)
+	assert.Equal(t, domain.MoneyFromCents(1288870), playerStats.Stats.TotalWagered)
+	assert.Equal(t, domain.MoneyFromCents(117170), playerStats.Stats.MinBet)
+	assert.Equal(t, domain.MoneyFromCents(1171700), playerStats.Stats.MaxBet)
+
+	_, err = svc.GetPlayerStats(ctx, domain.GetPlayerStatsRequest{PlayerID: created.Player.ID, Currency: "USD"})
+	assert.ErrorIs(t, err, domain.ErrInvalidArgument)
+
+	_, err = svc.GetCountryPlayerStats(ctx, domain.GetCountryPlayerStatsRequest{Limit: 10, Currency: "USD"})
+	assert.ErrorIs(t, err, domain.ErrInvalidArgument)
+
+	page, err := svc.GetCountryPlayerStats(ctx, domain.GetCountryPlayerStatsRequest{Limit: 1})
+	require.NoError(t, err)
+	require.NotEmpty(t, page.NextCursor)
+	_, err = svc.GetCountryPlayerStats(ctx, domain.GetCountryPlayerStatsRequest{Limit: 1, Cursor: page.NextCursor, Currency: "RSD"})
+	assert.ErrorIs(t, err, domain.ErrInvalidArgument)
+}
+
+func TestService_SettleBet(t *testing.T) {
+	db, cleanup := testutil.SetupDB(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := newTestStore(db)
+	mockCountryClient := mock.NewMockCountryAPIClient(ctrl)
+	svc := service.New(store, mockCountryClient)
+
+	mockCountryClient.EXPECT().
+		GetCountryInfo(gomock.Any(), gomock.Any()).
+		Return(domain.CountryInfo{}, nil).
+		AnyTimes()
+
+	ctx := context.Background()
+
+	created, err := svc.CreatePlayer(ctx, domain.CreatePlayerRequest{
+		Name:        "Luca Borg",
+		Email:       "luca.borg@example.com",
+		CountryCode: "MT",
+	})
+	require.NoError(t, err)
+
+	settlements := []domain.SettleBetRequest{
+		{Status: domain.BetStatusWon, Payout: domain.MoneyFromCents(18000)},
+		{Status: domain.BetStatusLost},
+		{Status: domain.BetStatusVoid},
+		{},
+	}
+	for i, amount := range []domain.Money{10000, 5000, 3000, 2000} {
+		placed, err := svc.PlaceBet(ctx, domain.PlaceBetRequest{PlayerID: created.Player.ID, Amount: amount})
+		require.NoError(t, err)
+		assert.Equal(t, domain.BetStatusOpen, placed.Bet.Status)
+		settlements[i].ID = placed.Bet.ID
+	}
+
+	for _, req := range settlements[:3] {
+		settled, err := svc.SettleBet(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, req.Status, settled.Bet.Status)
+		assert.Equal(t, req.Payout, settled.Bet.Payout)
+		assert.False(t, settled.Bet.SettledAt.IsZero())
+		assert.False(t, settled.Replayed)
+	}
+
+	replayed, err := svc.SettleBet(ctx, settlements[0])
+	require.NoError(t, err)
+	assert.True(t, replayed.Replayed)
+
+	_, err = svc.SettleBet(ctx, domain.SettleBetRequest{ID: settlements[0].ID, Status: domain.BetStatusLost})
+	assert.ErrorIs(t, err, domain.ErrConflict)
+
+	_, err = svc.SettleBet(ctx, domain.SettleBetRequest{ID: settlements[3].ID, Status: domain.BetStatusWon})
+	assert.ErrorIs(t, err, domain.ErrInvalidArgument)
+
+	_, err = svc.SettleBet(ctx, domain.SettleBetRequest{ID: settlements[3].ID, Status: domain.BetStatusLost, Payout: domain.MoneyFromCents(1)})
+	assert.ErrorIs(t, err, domain.ErrInvalidArgument)
+
+	_, err = svc.SettleBet(ctx, domain.SettleBetRequest{ID: 999999, Status: domain.BetStatusLost})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	resp, err := svc.GetCountryPlayerStats(ctx, domain.GetCountryPlayerStatsRequest{Limit: 100})
+	require.NoError(t, err)
+
+	var malta *domain.CountryPlayerStatsWithInfo
+	for i := range resp.Stats {
+		if resp.Stats[i].CountryCode == "MT" {
+			malta = &resp.Stats[i]
+		}
+	}
+	require.NotNil(t, malta)
+
+	// The void and the open bet count as stakes but not towards GGR.
+	assert.Equal(t, domain.MoneyFromCents(20000), malta.TotalBets)
+	assert.Equal(t, domain.MoneyFromCents(15000), malta.SettledStakes)
+	assert.Equal(t, domain.MoneyFromCents(-3000), malta.GGR)
+	assert.Equal(t, 1, malta.WinCount)
+	assert.InDelta(t, -20.0, malta.HoldPercentage(), 0.001)
+}
+
+func TestService_CheckCountryStatsRollup(t *testing.T) {
+	db, cleanup := testutil.SetupDB(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := newTestStore(db)
+	mockCountryClient := mock.NewMockCountryAPIClient(ctrl)
+	svc := service.New(store, mockCountryClient)
+
+	mockCountryClient.EXPECT().
+		GetCountryInfo(gomock.Any(), gomock.Any()).
+		Return(domain.CountryInfo{}, nil).
+		AnyTimes()
+
+	ctx := context.Background()
+
+	// The migration builds the rollup from the seeded bets.
+	res, err := svc.CheckCountryStatsRollup(ctx, domain.CheckCountryStatsRollupRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, res.Stats)
+	assert.Empty(t, res.Activity)
+
+	created, err := svc.CreatePlayer(ctx, domain.CreatePlayerRequest{
+		Name:        "Kristjan Tamm",
+		Email:       "kristjan.tamm@example.com",
+		CountryCode: "EE",
+	})
+	require.NoError(t, err)
+
+	won, err := svc.PlaceBet(ctx, domain.PlaceBetRequest{PlayerID: created.Player.ID, Amount: domain.MoneyFromCents(4000)})
+	require.NoError(t, err)
+	_, err = svc.PlaceBet(ctx, domain.PlaceBetRequest{PlayerID: created.Player.ID, Amount: domain.MoneyFromCents(117170), Currency: "RSD"})
+	require.NoError(t, err)
+	_, err = svc.SettleBet(ctx, domain.SettleBetRequest{ID: won.Bet.ID, Status: domain.BetStatusWon, Payout: domain.MoneyFromCents(10000)})
+	require.NoError(t, err)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	estonia := func(from, to time.Time) domain.CountryPlayerStatsWithInfo {
+		resp, err := svc.GetCountryPlayerStats(ctx, domain.GetCountryPlayerStatsRequest{Limit: 100, From: from, To: to})
+		require.NoError(t, err)
+		for _, stat := range resp.Stats {
+			if stat.CountryCode == "EE" {
+				return stat
+			}
+		}
+		t.Fatalf("EE missing from stats between %s and %s", from, to)
+		return domain.CountryPlayerStatsWithInfo{}
+	}
+
+	// Whole days are read from the rollup, anything else from the bets, both
+	// have to agree.
+	fromRollup := estonia(today, today.AddDate(0, 0, 1))
+	fromBets := estonia(today.Add(-time.Hour), today.AddDate(0, 0, 1).Add(time.Hour))
+	assert.Equal(t, fromBets.CountryPlayerStats, fromRollup.CountryPlayerStats)
+	assert.Equal(t, 1, fromRollup.PlayerCount)
+	assert.Equal(t, 2, fromRollup.BetCount)
+	assert.Equal(t, domain.MoneyFromCents(5000), fromRollup.TotalBets)
+	assert.Equal(t, domain.MoneyFromCents(-6000), fromRollup.GGR)
+
+	// Moving the player moves the bets in the rollup.
+	_, err = svc.UpdatePlayer(ctx, domain.UpdatePlayerRequest{
+		ID:          created.Player.ID,
+		Name:        "Kristjan Tamm",
+		Email:       "kristjan.tamm@example.com",
+		CountryCode: "LV",
+	})
+	require.NoError(t, err)
+
+	res, err = svc.CheckCountryStatsRollup(ctx, domain.CheckCountryStatsRollupRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, res.Stats)
+	assert.Empty(t, res.Activity)
+
+	_, err = db.ExecContext(ctx, "UPDATE country_daily_stats SET bet_count = bet_count + 1 WHERE country_code = 'LV'")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "DELETE FROM player_activity_days WHERE player_id = ?", created.Player.ID)
+	require.NoError(t, err)
+
+	res, err = svc.CheckCountryStatsRollup(ctx, domain.CheckCountryStatsRollupRequest{From: today, Repair: true})
+	require.NoError(t, err)
+	require.Len(t, res.Stats, 2)
+	for _, m := range res.Stats {
+		assert.Equal(t, "LV", m.Bets.CountryCode)
+		assert.Equal(t, m.Bets.BetCount+1, m.Rollup.BetCount)
+	}
+	require.Len(t, res.Activity, 1)
+	assert.Equal(t, 0, res.Activity[0].Rollup.ActivePlayers)
+	assert.Equal(t, 1, res.Activity[0].Bets.ActivePlayers)
+	assert.Equal(t, []time.Time{today}, res.Repaired)
+
+	res, err = svc.CheckCountryStatsRollup(ctx, domain.CheckCountryStatsRollupRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, res.Stats)
+	assert.Empty(t, res.Activity)
+
+	_, err = svc.CheckCountryStatsRollup(ctx, domain.CheckCountryStatsRollupRequest{From: today.Add(time.Hour)})
+	assert.ErrorIs(t, err, domain.ErrInvalidArgument)
+}
+
+func TestService_GetCountryPlayerStats_UnhealthyReplica(t *testing.T) {
+	if testutil.UseSQLite() {
+		t.Skip("read replicas are only supported on MySQL")
+	}
+
+	db, cleanup := testutil.SetupDB(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// A closed replica fails its health check, so reports are read from the
+	// primary.
+	replica, err := sql.Open("mysql", "test_user:test_password@tcp(127.0.0.1:1)/player_activity")
+	require.NoError(t, err)
+	require.NoError(t, replica.Close())
+
+	store := store.NewWithReplicas(db, []*sql.DB{replica}, time.Second)
+	mockCountryClient := mock.NewMockCountryAPIClient(ctrl)
+	svc := service.New(store, mockCountryClient)
+
+	mockCountryClient.EXPECT().
+		GetCountryInfo(gomock.Any(), gomock.Any()).
+		Return(domain.CountryInfo{}, nil).
+		AnyTimes()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	store.MonitorReplicas(ctx, time.Second)
+
+	resp, err := svc.GetCountryPlayerStats(context.Background(), domain.GetCountryPlayerStatsRequest{Limit: 3})
+	require.NoError(t, err)
+
+	require.Len(t, resp.Stats, 3)
+	assert.Equal(t, "RS", resp.Stats[0].CountryCode)
+}
+
+func TestService_Import(t *testing.T) {
+	db, cleanup := testutil.SetupDB(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := newTestStore(db)
+	mockCountryClient := mock.NewMockCountryAPIClient(ctrl)
+	svc := service.New(store, mockCountryClient)
+
+	ctx := context.Background()
+
+	players := "\ufeffExternal_ID,name,email,country_code,created_at\n" +
+		"p-1,Ana Novak,ana.novak@example.com,si,2024-03-01T10:00:00Z\n" +
+		"p-2,Jan Horvat,jan.horvat@example.com,SI,\n" +
+		",No Id,no.id@example.com,SI,\n" +
+		"p-4,Bad Email,not-an-email,SI,\n" +
+		"p-5,\"Unterminated,x@example.com,SI,\n"
+
+	dryRun, err := svc.Import(ctx, domain.ImportRequest{
+		Kind:   domain.ImportKindPlayers,
+		Format: domain.ImportFormatCSV,
+		Body:   strings.NewReader(players),
+		DryRun: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, dryRun.Imported)
+
+	resp, err := svc.Import(ctx, domain.ImportRequest{
+		Kind:   domain.ImportKindPlayers,
+		Format: domain.ImportFormatCSV,
+		Body:   strings.NewReader(players),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 5, resp.Rows)
+	assert.Equal(t, 2, resp.Imported)
+	assert.Equal(t, 0, resp.Skipped)
+	assert.Equal(t, 3, resp.Failed)
+	require.Len(t, resp.Errors, 3)
+	assert.Equal(t, 3, resp.Errors[0].Row)
+	assert.Contains(t, resp.Errors[0].Message, "external_id is required")
+	assert.Equal(t, 4, resp.Errors[1].Row)
+	assert.Equal(t, "p-4", resp.Errors[1].ExternalID)
+	assert.Equal(t, 5, resp.Errors[2].Row)
+
+	bets := `{"external_id": "b-1", "player_external_id": "p-1", "amount": 10.50, "currency": "eur", "created_at": "2024-03-02T12:00:00Z"}
+
+{"external_id": "b-2", "player_external_id": "p-1", "amount": "20", "currency": "EUR", "status": "won", "payout": 45, "created_at": "2024-03-02 13:00:00"}
+{"external_id": "b-3", "player_external_id": "p-2", "amount": 5, "currency": "EUR", "status": "lost", "created_at": "2024-03-03"}
+{"external_id": "b-4", "player_external_id": "p-9", "amount": 5, "currency": "EUR"}
+{"external_id": "b-5", "player_external_id": "p-1", "amount": 5, "currency": "XXX"}
+{"external_id": "b-6", "player_external_id": "p-1", "amount": 5, "currency": "EUR", "status": "open", "payout": 1}
+{"external_id": "b-7", "player_external_id": "p-1", "amount": 5, "currency": "EUR", "status": "lost", "created_at": "2024-03-03T10:00:00Z", "settled_at": "2024-03-03T09:00:00Z"}
+{"external_id": ["b-8"]}
+not json
+`
+	resp, err = svc.Import(ctx, domain.ImportRequest{
+		Kind:   domain.ImportKindBets,
+		Format: domain.ImportFormatNDJSON,
+		Body:   strings.NewReader(bets),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 9, resp.Rows)
+	assert.Equal(t, 3, resp.Imported)
+	assert.Equal(t, 6, resp.Failed)
+	require.Len(t, resp.Errors, 6)
+	assert.Equal(t, 4, resp.Errors[0].Row)
+	assert.Equal(t, "b-4", resp.Errors[0].ExternalID)
+	assert.Contains(t, resp.Errors[0].Message, "not found")
+	assert.Equal(t, 9, resp.Errors[5].Row)
+
+	again, err := svc.Import(ctx, domain.ImportRequest{
+		Kind:   domain.ImportKindBets,
+		Format: domain.ImportFormatNDJSON,
+		Body:   strings.NewReader(bets),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, again.Imported)
+	assert.Equal(t, 3, again.Skipped)
+
+	check, err := svc.CheckCountryStatsRollup(ctx, domain.CheckCountryStatsRollupRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, check.Stats)
+	assert.Empty(t, check.Activity)
+
+	// Only the first 1000 rejected rows are reported.
+	var invalid strings.Builder
+	invalid.WriteString("external_id,name,email,country_code\n")
+	for i := range 2500 {
+		fmt.Fprintf(&invalid, "bad-%d,Bad Email,not-an-email,SI\n", i)
+	}
+	resp, err = svc.Import(ctx, domain.ImportRequest{
+		Kind:   domain.ImportKindPlayers,
+		Format: domain.ImportFormatCSV,
+		Body:   strings.NewReader(invalid.String()),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2500, resp.Failed)
+	require.Len(t, resp.Errors, 1000)
+	assert.Equal(t, 1, resp.Errors[0].Row)
+	assert.Equal(t, 1000, resp.Errors[999].Row)
+	assert.Equal(t, 1500, resp.ErrorsDropped)
+
+	_, err = svc.Import(ctx, domain.ImportRequest{Kind: "wagers", Format: domain.ImportFormatCSV, Body: strings.NewReader("external_id\n")})
+	assert.ErrorIs(t, err, domain.ErrInvalidArgument)
+	_, err = svc.Import(ctx, domain.ImportRequest{Kind: domain.ImportKindPlayers, Format: domain.ImportFormatCSV, Body: strings.NewReader("")})
+	assert.ErrorIs(t, err, domain.ErrInvalidArgument)
+}
+
+func TestService_ErasePlayer(t *testing.T) {
+	db, cleanup := testutil.SetupDB(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := newTestStore(db)
+	svc := service.New(store, mock.NewMockCountryAPIClient(ctrl))
+
+	ctx := context.Background()
+
+	created, err := svc.CreatePlayer(ctx, domain.CreatePlayerRequest{
+		Name:        "Marko Marković",
+		Email:       "marko@example.com",
+		CountryCode: "ME",
+	})
+	require.NoError(t, err)
+	_, err = svc.PlaceBet(ctx, domain.PlaceBetRequest{
+		PlayerID:       created.Player.ID,
+		Amount:         domain.MoneyFromCents(1000),
+		Currency:       "EUR",
+		IdempotencyKey: "erase-bet-1",
+	})
+	require.NoError(t, err)
+
+	_, err = svc.ErasePlayer(ctx, domain.ErasePlayerRequest{ID: created.Player.ID, RequestedBy: "  "})
+	assert.ErrorIs(t, err, domain.ErrInvalidArgument)
+	_, err = svc.ErasePlayer(ctx, domain.ErasePlayerRequest{
+		ID:          created.Player.ID,
+		RequestedBy: "ticket-42",
+		Reason:      strings.Repeat("x", 1001),
+	})
+	assert.ErrorIs(t, err, domain.ErrInvalidArgument)
+
+	erased, err := svc.ErasePlayer(ctx, domain.ErasePlayerRequest{
+		ID:          created.Player.ID,
+		RequestedBy: " ticket-42 ",
+		Reason:      "right to be forgotten",
+	})
+	require.NoError(t, err)
+	assert.False(t, erased.Replayed)
+	assert.Equal(t, "ticket-42", erased.Erasure.RequestedBy)
+
+	got, err := svc.GetPlayer(ctx, domain.GetPlayerRequest{ID: created.Player.ID})
+	require.NoError(t, err)
+	assert.Equal(t, "Erased Player", got.Player.Name)
+	assert.Equal(t, fmt.Sprintf("erased-%d@erased.invalid", created.Player.ID), got.Player.Email)
+	assert.Equal(t, "ME", got.Player.CountryCode)
+	assert.False(t, got.Player.ErasedAt.IsZero())
+
+	// Updating the player would bring the erased data back.
+	_, err = svc.UpdatePlayer(ctx, domain.UpdatePlayerRequest{
+		ID:          created.Player.ID,
+		Name:        "Marko Marković",
+		Email:       "marko@example.com",
+		CountryCode: "ME",
+	})
+	assert.ErrorIs(t, err, domain.ErrConflict)
+	unchanged, err := svc.GetPlayer(ctx, domain.GetPlayerRequest{ID: created.Player.ID})
+	require.NoError(t, err)
+	assert.Equal(t, got.Player, unchanged.Player)
+
+	stats, err := store.GetPlayerStats(ctx, domain.GetPlayerStatsQuery{PlayerID: created.Player.ID, Currency: "EUR"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Stats.BetCount)
+
+	// The original email is free again, the erased player does not hold it.
+	_, err = svc.CreatePlayer(ctx, domain.CreatePlayerRequest{
+		Name:        "Marko Marković",
+		Email:       "marko@example.com",
+		CountryCode: "ME",
+	})
+	require.NoError(t, err)
+
+	replayed, err := svc.ErasePlayer(ctx, domain.ErasePlayerRequest{ID: created.Player.ID, RequestedBy: "ticket-43"})
+	require.NoError(t, err)
+	assert.True(t, replayed.Replayed)
+	assert.Equal(t, erased.Erasure, replayed.Erasure)
+
+	list, err := svc.ListPlayerErasures(ctx, domain.ListPlayerErasuresRequest{PlayerID: created.Player.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, list.Erasures, 1)
+
+	// The log is append-only, the database rejects changes to it.
+	_, err = db.ExecContext(ctx, "UPDATE player_erasures SET reason = 'changed' WHERE id = ?", erased.Erasure.ID)
+	assert.Error(t, err)
+	_, err = db.ExecContext(ctx, "DELETE FROM player_erasures WHERE id = ?", erased.Erasure.ID)
+	assert.Error(t, err)
+
+	_, err = svc.ErasePlayer(ctx, domain.ErasePlayerRequest{ID: math.MaxInt32, RequestedBy: "ticket-44"})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestService_ListAuditEntries(t *testing.T) {
+	db, cleanup := testutil.SetupDB(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := newTestStore(db)
+	svc := service.New(store, mock.NewMockCountryAPIClient(ctrl))
+
+	ctx := domain.WithActor(context.Background(), "importer")
+
+	_, err := svc.ListAuditEntries(ctx, domain.ListAuditEntriesRequest{EntityType: "exchange_rate", Limit: 10})
+	assert.ErrorIs(t, err, domain.ErrInvalidArgument)
+	_, err = svc.ListAuditEntries(ctx, domain.ListAuditEntriesRequest{EntityID: 1, Limit: 10})
+	assert.ErrorIs(t, err, domain.ErrInvalidArgument)
+	now := time.Now()
+	_, err = svc.ListAuditEntries(ctx, domain.ListAuditEntriesRequest{From: now, To: now.Add(-time.Hour), Limit: 10})
+	assert.ErrorIs(t, err, domain.ErrInvalidArgument)
+
+	imported, err := svc.Import(ctx, domain.ImportRequest{
+		Kind:   domain.ImportKindPlayers,
+		Format: domain.ImportFormatCSV,
+		Body:   strings.NewReader("external_id,name,email,country_code\naudit-1,Audit Import,audit.import@example.com,AD\n"),
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, imported.Imported)
+
+	// Dry runs are rolled back with their entries.
+	_, err = svc.Import(ctx, domain.ImportRequest{
+		Kind:   domain.ImportKindPlayers,
+		Format: domain.ImportFormatCSV,
+		Body:   strings.NewReader("external_id,name,email,country_code\naudit-2,Audit Dry Run,audit.dry@example.com,AD\n"),
+		DryRun: true,
+	})
+	require.NoError(t, err)
+
+	// Nothing else runs as the importer, so its entries are the import's.
+	resp, err := svc.ListAuditEntries(ctx, domain.ListAuditEntriesRequest{Actor: "importer", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, resp.Entries, 1)
+	entry := resp.Entries[0]
+	assert.Equal(t, domain.AuditEntityPlayer, entry.EntityType)
+	assert.Equal(t, domain.AuditActionImport, entry.Action)
+	assert.Equal(t, "audit.import@example.com", entry.After["email"])
+
+	// Changes without an actor are still recorded.
+	_, err = svc.CreatePlayer(context.Background(), domain.CreatePlayerRequest{Name: "No Actor", Email: "no.actor@example.com", CountryCode: "AD"})
+	require.NoError(t, err)
+	resp, err = svc.ListAuditEntries(ctx, domain.ListAuditEntriesRequest{Actor: domain.UnknownActor, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, resp.Entries, 1)
+
+	// The log is append-only, the database rejects changes to it other than
+	// redacting an entry.
+	_, err = db.ExecContext(ctx, "UPDATE audit_log SET actor = 'someone else' WHERE id = ?", entry.ID)
+	assert.Error(t, err)
+	_, err = db.ExecContext(ctx, "UPDATE audit_log SET actor = 'someone else', redacted_at = CURRENT_TIMESTAMP WHERE id = ?", entry.ID)
+	assert.Error(t, err)
+	_, err = db.ExecContext(ctx, "DELETE FROM audit_log WHERE id = ?", entry.ID)
+	assert.Error(t, err)
+}
+
+func TestService_CheckCountryCodes(t *testing.T) {
+	db, cleanup := testutil.SetupDB(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := newTestStore(db)
+	svc := service.New(store, mock.NewMockCountryAPIClient(ctrl))
+
+	ctx := domain.WithActor(context.Background(), "countrycheck")
+
+	// Aliases and case are normalized on write, unknown codes are rejected.
+	created, err := svc.CreatePlayer(ctx, domain.CreatePlayerRequest{Name: "Alias", Email: "alias@example.com", CountryCode: " uk"})
+	require.NoError(t, err)
+	assert.Equal(t, "GB", created.Player.CountryCode)
+	_, err = svc.CreatePlayer(ctx, domain.CreatePlayerRequest{Name: "Unknown", Email: "unknown@example.com", CountryCode: "XX"})
+	assert.ErrorIs(t, err, domain.ErrInvalidArgument)
+
+	// Codes stored before validation can be aliases or anything else.
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO players (name, email, country_code) VALUES
+			('Legacy', 'legacy@example.com', 'ZZ'),
+			('Legacy UK', 'legacy.uk@example.com', 'UK'),
+			('Legacy UK 2', 'legacy.uk2@example.com', 'UK')`)
+	require.NoError(t, err)
+	ukPlayers := 2
+	var ukPlayer int
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT id FROM players WHERE email = 'legacy.uk@example.com'").Scan(&ukPlayer))
+	_, err = svc.PlaceBet(ctx, domain.PlaceBetRequest{PlayerID: ukPlayer, Amount: domain.MoneyFromCents(1000), Currency: "EUR"})
+	require.NoError(t, err)
+
+	res, err := svc.CheckCountryCodes(ctx, domain.CheckCountryCodesRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []domain.InvalidCountryCode{
+		{CountryCode: "UK", PlayerCount: ukPlayers, Normalized: "GB"},
+		{CountryCode: "ZZ", PlayerCount: 1},
+	}, res.Invalid)
+
+	res, err = svc.CheckCountryCodes(ctx, domain.CheckCountryCodesRequest{Fix: true})
+	require.NoError(t, err)
+	require.Len(t, res.Invalid, 2)
+	assert.Equal(t, ukPlayers, res.Invalid[0].Fixed)
+	assert.Zero(t, res.Invalid[1].Fixed)
+
+	res, err = svc.CheckCountryCodes(ctx, domain.CheckCountryCodesRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []domain.InvalidCountryCode{{CountryCode: "ZZ", PlayerCount: 1}}, res.Invalid)
+
+	// The players moved with their bets, and every move was recorded.
+	rollup, err := svc.CheckCountryStatsRollup(ctx, domain.CheckCountryStatsRollupRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, rollup.Stats)
+	assert.Empty(t, rollup.Activity)
+
+	audit, err := svc.ListAuditEntries(ctx, domain.ListAuditEntriesRequest{Actor: "countrycheck", EntityType: domain.AuditEntityPlayer, Limit: 100})
+	require.NoError(t, err)
+	require.Len(t, audit.Entries, 1+ukPlayers)
+	moved := audit.Entries[1]
+	assert.Equal(t, domain.AuditActionUpdate, moved.Action)
+	assert.Equal(t, "UK", moved.Before["country_code"])
+	assert.Equal(t, "GB", moved.After["country_code"])
+}
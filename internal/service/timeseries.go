@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+// maxTimeSeriesBuckets bounds the work a single request can cause, it fits a
+// year of daily buckets.
+const maxTimeSeriesBuckets = 400
+
+func (s Service) GetCountryActivityTimeSeries(ctx context.Context, req domain.GetCountryActivityTimeSeriesRequest) (domain.GetCountryActivityTimeSeriesResponse, error) {
+	if req.From.IsZero() || req.To.IsZero() {
+		return domain.GetCountryActivityTimeSeriesResponse{}, fmt.Errorf("from and to are required: %w", domain.ErrInvalidArgument)
+	}
+	if err := validateWindow(req.From, req.To); err != nil {
+		return domain.GetCountryActivityTimeSeriesResponse{}, err
+	}
+	if !req.Interval.Valid() {
+		return domain.GetCountryActivityTimeSeriesResponse{}, fmt.Errorf("unknown interval %q: %w", req.Interval, domain.ErrInvalidArgument)
+	}
+
+	countryCode, err := normalizeOptionalCountryCode(req.CountryCode)
+	if err != nil {
+		return domain.GetCountryActivityTimeSeriesResponse{}, err
+	}
+	currency, err := s.reportingCurrency(ctx, req.Currency)
+	if err != nil {
+		return domain.GetCountryActivityTimeSeriesResponse{}, err
+	}
+
+	loc := req.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	starts, ranges, err := bucketRanges(req.From, req.To, req.Interval, loc)
+	if err != nil {
+		return domain.GetCountryActivityTimeSeriesResponse{}, err
+	}
+
+	result, err := s.store.GetCountryActivityByBucket(ctx, domain.GetCountryActivityByBucketQuery{
+		Buckets:     ranges,
+		CountryCode: countryCode,
+		Currency:    currency,
+	})
+	if err != nil {
+		return domain.GetCountryActivityTimeSeriesResponse{}, err
+	}
+
+	emptySeries := func(countryCode string) domain.CountryActivitySeries {
+		buckets := make([]domain.CountryActivityBucket, len(starts))
+		for i, start := range starts {
+			buckets[i].Start = start
+		}
+		return domain.CountryActivitySeries{
+			CountryCode: countryCode,
+			Buckets:     buckets,
+		}
+	}
+
+	// Rows are ordered by country, every country gets a full, zero filled
+	// series the first time it shows up.
+	res := domain.GetCountryActivityTimeSeriesResponse{
+		Currency: currency,
+		Series:   []domain.CountryActivitySeries{},
+	}
+	for _, row := range result.Rows {
+		if len(res.Series) == 0 || res.Series[len(res.Series)-1].CountryCode != row.CountryCode {
+			res.Series = append(res.Series, emptySeries(row.CountryCode))
+		}
+
+		bucket := &res.Series[len(res.Series)-1].Buckets[row.Bucket]
+		bucket.TotalBets = row.TotalBets
+		bucket.BetCount = row.BetCount
+		bucket.ActivePlayers = row.ActivePlayers
+	}
+
+	// A country that was asked for explicitly is reported even without bets.
+	if countryCode != "" && len(res.Series) == 0 {
+		res.Series = append(res.Series, emptySeries(countryCode))
+	}
+
+	return res, nil
+}
+
+// bucketRanges splits [from, to) into calendar aligned buckets in loc. It
+// returns the calendar start of every bucket and the range each bucket covers,
+// which for the first and last bucket is clipped to [from, to).
+func bucketRanges(from, to time.Time, interval domain.BucketInterval, loc *time.Location) ([]time.Time, []domain.TimeRange, error) {
+	var (
+		starts []time.Time
+		ranges []domain.TimeRange
+	)
+
+	for start := truncateToBucket(from.In(loc), interval); start.Before(to); start = nextBucket(start, interval) {
+		if len(starts) == maxTimeSeriesBuckets {
+			return nil, nil, fmt.Errorf("range spans more than %d buckets: %w", maxTimeSeriesBuckets, domain.ErrInvalidArgument)
+		}
+
+		r := domain.TimeRange{Start: start, End: nextBucket(start, interval)}
+		if r.Start.Before(from) {
+			r.Start = from
+		}
+		if r.End.After(to) {
+			r.End = to
+		}
+
+		starts = append(starts, start)
+		ranges = append(ranges, r)
+	}
+
+	return starts, ranges, nil
+}
+
+// truncateToBucket returns the start of the bucket t falls into, weeks start
+// on Monday.
+func truncateToBucket(t time.Time, interval domain.BucketInterval) time.Time {
+	year, month, day := t.Date()
+
+	switch interval {
+	case domain.BucketWeek:
+		daysSinceMonday := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-daysSinceMonday, 0, 0, 0, 0, t.Location())
+	case domain.BucketMonth:
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	}
+}
+
+// nextBucket steps in calendar units so buckets stay aligned across DST changes.
+func nextBucket(start time.Time, interval domain.BucketInterval) time.Time {
+	year, month, day := start.Date()
+
+	switch interval {
+	case domain.BucketWeek:
+		return time.Date(year, month, day+7, 0, 0, 0, 0, start.Location())
+	case domain.BucketMonth:
+		return time.Date(year, month+1, 1, 0, 0, 0, 0, start.Location())
+	default:
+		return time.Date(year, month, day+1, 0, 0, 0, 0, start.Location())
+	}
+}
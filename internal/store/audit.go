@@ -0,0 +1,274 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+const auditEntryColumns = "id, entity_type, entity_id, action, actor, before_values, after_values, created_at, redacted_at"
+
+// auditSnapshots read the audit values of an entity, nil when it does not
+// exist. They lock its row for the rest of the transaction with lockRow.
+var auditSnapshots = map[domain.AuditEntityType]func(ctx context.Context, tx *sql.Tx, id int, lockRow string) (map[string]any, error){
+	domain.AuditEntityPlayer: func(ctx context.Context, tx *sql.Tx, id int, lockRow string) (map[string]any, error) {
+		player, err := scanPlayer(tx.QueryRowContext(ctx, "SELECT "+playerColumns+" FROM players WHERE id = ?"+lockRow, id))
+		if err != nil {
+			return nil, err
+		}
+		return player.AuditValues(), nil
+	},
+	domain.AuditEntityBet: func(ctx context.Context, tx *sql.Tx, id int, lockRow string) (map[string]any, error) {
+		bet, err := scanBet(tx.QueryRowContext(ctx, "SELECT "+betColumns+" FROM bets WHERE id = ?"+lockRow, id))
+		if err != nil {
+			return nil, err
+		}
+		return bet.AuditValues(), nil
+	},
+}
+
+// auditedWrite is the write hook of the audit log. A write starts it before
+// changing an entity and records it afterwards, in the same transaction, so
+// the change and its entry are committed together.
+type auditedWrite struct {
+	store  *Store
+	entity domain.AuditEntityType
+	action domain.AuditAction
+	id     int
+	before map[string]any
+}
+
+// startAudit reads the values of the entity before a write, entities that are
+// created have no ID yet and are started with 0.
+func (s *Store) startAudit(ctx context.Context, tx *sql.Tx, entity domain.AuditEntityType, action domain.AuditAction, id int) (*auditedWrite, error) {
+	w := &auditedWrite{store: s, entity: entity, action: action, id: id}
+	if id == 0 {
+		return w, nil
+	}
+
+	var err error
+	if w.before, err = s.auditSnapshot(ctx, tx, entity, id); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// record reads the values of the entity after the write and appends the
+// change to the audit log, attributed to the actor of ctx. id is the ID of a
+// created entity and ignored otherwise. Writes that changed nothing are not
+// recorded.
+func (w *auditedWrite) record(ctx context.Context, tx *sql.Tx, id int) error {
+	if w.id == 0 {
+		w.id = id
+	}
+
+	after, err := w.store.auditSnapshot(ctx, tx, w.entity, w.id)
+	if err != nil {
+		return err
+	}
+
+	before, err := marshalAuditValues(w.before)
+	if err != nil {
+		return err
+	}
+	afterValues, err := marshalAuditValues(after)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(before, afterValues) {
+		return nil
+	}
+
+	query := "INSERT INTO audit_log (entity_type, entity_id, action, actor, before_values, after_values) VALUES (?, ?, ?, ?, ?, ?)"
+	_, err = tx.ExecContext(ctx, query,
+		string(w.entity),
+		w.id,
+		string(w.action),
+		domain.ActorFromContext(ctx),
+		nullJSON(before),
+		nullJSON(afterValues),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit entry: %w", err)
+	}
+	return nil
+}
+
+// startPlayerDeleteAudit starts the audits of deleting a player, whose bets go
+// with it through the foreign key.
+func (s *Store) startPlayerDeleteAudit(ctx context.Context, tx *sql.Tx, id int) ([]*auditedWrite, error) {
+	betIDs, err := playerBetIDs(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	audits := make([]*auditedWrite, 0, len(betIDs)+1)
+	for _, betID := range betIDs {
+		audit, err := s.startAudit(ctx, tx, domain.AuditEntityBet, domain.AuditActionDelete, betID)
+		if err != nil {
+			return nil, err
+		}
+		audits = append(audits, audit)
+	}
+
+	audit, err := s.startAudit(ctx, tx, domain.AuditEntityPlayer, domain.AuditActionDelete, id)
+	if err != nil {
+		return nil, err
+	}
+	return append(audits, audit), nil
+}
+
+func playerBetIDs(ctx context.Context, tx *sql.Tx, playerID int) ([]int, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT id FROM bets WHERE player_id = ? ORDER BY id", playerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bets of player: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return ids, nil
+}
+
+func (s *Store) auditSnapshot(ctx context.Context, tx *sql.Tx, entity domain.AuditEntityType, id int) (map[string]any, error) {
+	values, err := auditSnapshots[entity](ctx, tx, id, s.dialect.LockRow)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get %s for audit: %w", entity, err)
+	}
+	return values, nil
+}
+
+func marshalAuditValues(values map[string]any) ([]byte, error) {
+	if values == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit values: %w", err)
+	}
+	return data, nil
+}
+
+func nullJSON(data []byte) sql.NullString {
+	return sql.NullString{String: string(data), Valid: data != nil}
+}
+
+// redactPlayerAudit replaces the name and email in the audit entries of an
+// erased player. It is the only change the audit log allows, once per entry.
+func redactPlayerAudit(ctx context.Context, tx *sql.Tx, id int, name, email string) error {
+	query := `
+		UPDATE audit_log
+		SET before_values = JSON_SET(before_values, '$.name', ?, '$.email', ?),
+			after_values = JSON_SET(after_values, '$.name', ?, '$.email', ?),
+			redacted_at = CURRENT_TIMESTAMP
+		WHERE entity_type = ? AND entity_id = ? AND redacted_at IS NULL`
+
+	_, err := tx.ExecContext(ctx, query, name, email, name, email, string(domain.AuditEntityPlayer), id)
+	if err != nil {
+		return fmt.Errorf("failed to redact audit entries: %w", err)
+	}
+	return nil
+}
+
+func scanAuditEntry(row rowScanner) (domain.AuditEntry, error) {
+	var (
+		e             domain.AuditEntry
+		before, after sql.NullString
+		redactedAt    sql.NullTime
+	)
+	err := row.Scan(
+		&e.ID,
+		&e.EntityType,
+		&e.EntityID,
+		&e.Action,
+		&e.Actor,
+		&before,
+		&after,
+		&e.CreatedAt,
+		&redactedAt,
+	)
+	if err != nil {
+		return e, err
+	}
+	e.RedactedAt = redactedAt.Time
+
+	if before.Valid {
+		if err := json.Unmarshal([]byte(before.String), &e.Before); err != nil {
+			return e, fmt.Errorf("failed to unmarshal audit values: %w", err)
+		}
+	}
+	if after.Valid {
+		if err := json.Unmarshal([]byte(after.String), &e.After); err != nil {
+			return e, fmt.Errorf("failed to unmarshal audit values: %w", err)
+		}
+	}
+	return e, nil
+}
+
+func (s *Store) ListAuditEntries(ctx context.Context, q domain.ListAuditEntriesQuery) (*domain.ListAuditEntriesResult, error) {
+	query := `
+		SELECT ` + auditEntryColumns + `
+		FROM audit_log
+		WHERE (? = '' OR entity_type = ?)
+			AND (? = 0 OR entity_id = ?)
+			AND (? = '' OR actor = ?)
+			AND (? IS NULL OR created_at >= ?)
+			AND (? IS NULL OR created_at < ?)
+		ORDER BY id
+		LIMIT ? OFFSET ?`
+
+	from, to := nullTime(q.From), nullTime(q.To)
+
+	var entries []domain.AuditEntry
+	err := s.withRetry(ctx, func(ctx context.Context) error {
+		rows, err := s.db.QueryContext(ctx, query,
+			string(q.EntityType), string(q.EntityType),
+			q.EntityID, q.EntityID,
+			q.Actor, q.Actor,
+			from, from,
+			to, to,
+			q.Limit, q.Offset,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to list audit entries: %w", err)
+		}
+		defer rows.Close()
+
+		entries = []domain.AuditEntry{}
+		for rows.Next() {
+			entry, err := scanAuditEntry(rows)
+			if err != nil {
+				return fmt.Errorf("failed to scan row: %w", err)
+			}
+			entries = append(entries, entry)
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating rows: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.ListAuditEntriesResult{
+		Entries: entries,
+	}, nil
+}
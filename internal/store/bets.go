@@ -0,0 +1,195 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+const betColumns = "id, player_id, amount, currency, status, payout, settled_at, created_at"
+
+// errBetReplayed aborts the transaction of a bet whose idempotency key is
+// already taken.
+var errBetReplayed = errors.New("bet replayed")
+
+func scanBet(row rowScanner) (domain.Bet, error) {
+	var (
+		b         domain.Bet
+		settledAt sql.NullTime
+	)
+	err := row.Scan(
+		&b.ID,
+		&b.PlayerID,
+		&b.Amount,
+		&b.Currency,
+		&b.Status,
+		&b.Payout,
+		&settledAt,
+		&b.CreatedAt,
+	)
+	b.SettledAt = settledAt.Time
+	return b, err
+}
+
+func (s *Store) CreateBet(ctx context.Context, q domain.CreateBetQuery) (*domain.CreateBetResult, error) {
+	query := "INSERT INTO bets (player_id, amount, currency, idempotency_key) VALUES (?, ?, ?, ?)"
+
+	var idempotencyKey sql.NullString
+	if q.IdempotencyKey != "" {
+		idempotencyKey = sql.NullString{String: q.IdempotencyKey, Valid: true}
+	}
+
+	// A bet with an idempotency key is replayed when it was committed before,
+	// so only those can be retried.
+	withTx := s.withTx
+	if idempotencyKey.Valid {
+		withTx = s.withRetryTx
+	}
+
+	var id int64
+	err := withTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		audit, err := s.startAudit(ctx, tx, domain.AuditEntityBet, domain.AuditActionCreate, 0)
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx, query, q.PlayerID, q.Amount, q.Currency, idempotencyKey)
+		if err != nil {
+			switch {
+			case s.dialect.IsDuplicateEntry(err) && idempotencyKey.Valid:
+				return errBetReplayed
+			case s.dialect.IsForeignKeyViolation(err):
+				return fmt.Errorf("player %d: %w", q.PlayerID, domain.ErrNotFound)
+			}
+			return fmt.Errorf("failed to insert bet: %w", err)
+		}
+
+		if id, err = res.LastInsertId(); err != nil {
+			return fmt.Errorf("failed to get inserted bet id: %w", err)
+		}
+
+		if err := audit.record(ctx, tx, int(id)); err != nil {
+			return err
+		}
+		return s.addBetToRollup(ctx, tx, id)
+	})
+	if errors.Is(err, errBetReplayed) {
+		return s.replayBet(ctx, q)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	bet, err := s.getBet(ctx, int(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bet: %w", err)
+	}
+
+	return &domain.CreateBetResult{
+		Bet: bet,
+	}, nil
+}
+
+// replayBet returns the bet previously recorded under the query's idempotency
+// key, as long as it describes the same wager.
+func (s *Store) replayBet(ctx context.Context, q domain.CreateBetQuery) (*domain.CreateBetResult, error) {
+	query := "SELECT " + betColumns + " FROM bets WHERE idempotency_key = ?"
+
+	var bet domain.Bet
+	err := s.withRetry(ctx, func(ctx context.Context) error {
+		var err error
+		bet, err = scanBet(s.db.QueryRowContext(ctx, query, q.IdempotencyKey))
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("bet for idempotency key %q disappeared: %w", q.IdempotencyKey, domain.ErrConflict)
+		}
+		return nil, fmt.Errorf("failed to get bet by idempotency key: %w", err)
+	}
+
+	if bet.PlayerID != q.PlayerID || bet.Amount != q.Amount || bet.Currency != q.Currency {
+		return nil, fmt.Errorf("idempotency key %q was already used for a different bet: %w", q.IdempotencyKey, domain.ErrConflict)
+	}
+
+	return &domain.CreateBetResult{
+		Bet:      bet,
+		Replayed: true,
+	}, nil
+}
+
+func (s *Store) SettleBet(ctx context.Context, q domain.SettleBetQuery) (*domain.SettleBetResult, error) {
+	query := "UPDATE bets SET status = ?, payout = ?, settled_at = CURRENT_TIMESTAMP WHERE id = ? AND status = 'open'"
+
+	// Settling a bet again the same way is replayed, so the settlement can be
+	// retried.
+	var affected int64
+	err := s.withRetryTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		audit, err := s.startAudit(ctx, tx, domain.AuditEntityBet, domain.AuditActionSettle, q.ID)
+		if err != nil {
+			return err
+		}
+
+		// The settled bet replaces the open one in the rollup, bets that are
+		// not open are left as they are.
+		if err := s.addToRollup(ctx, tx, -1, "b.id = ? AND b.status = 'open'", q.ID); err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx, query, string(q.Status), q.Payout, q.ID)
+		if err != nil {
+			return fmt.Errorf("failed to settle bet: %w", err)
+		}
+
+		if affected, err = res.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to get affected rows: %w", err)
+		}
+		if affected == 0 {
+			return nil
+		}
+
+		if err := audit.record(ctx, tx, q.ID); err != nil {
+			return err
+		}
+		return s.addToRollup(ctx, tx, 1, "b.id = ?", q.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bet, err := s.getBet(ctx, q.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("bet %d: %w", q.ID, domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get bet: %w", err)
+	}
+
+	if affected == 0 {
+		// The bet was settled before, settling it the same way again is a retry.
+		if bet.Status != q.Status || bet.Payout != q.Payout {
+			return nil, fmt.Errorf("bet %d is already settled as %s: %w", q.ID, bet.Status, domain.ErrConflict)
+		}
+		return &domain.SettleBetResult{
+			Bet:      bet,
+			Replayed: true,
+		}, nil
+	}
+
+	return &domain.SettleBetResult{
+		Bet: bet,
+	}, nil
+}
+
+func (s *Store) getBet(ctx context.Context, id int) (domain.Bet, error) {
+	var bet domain.Bet
+	err := s.withRetry(ctx, func(ctx context.Context) error {
+		var err error
+		bet, err = scanBet(s.db.QueryRowContext(ctx, "SELECT "+betColumns+" FROM bets WHERE id = ?", id))
+		return err
+	})
+	return bet, err
+}
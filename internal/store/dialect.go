@@ -0,0 +1,73 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dialect is what differs between the databases the store runs on, the SQL
+// that has no portable form and the classification of driver errors. All other
+// queries are shared and written in the SQL both databases understand.
+type Dialect struct {
+	// LockRow is appended to a SELECT of single rows to lock them for the
+	// rest of the transaction.
+	LockRow string
+	// InsertIgnore starts an INSERT that skips rows whose key exists.
+	InsertIgnore string
+	// UpsertDailyStats ends an INSERT INTO country_daily_stats ... SELECT *
+	// FROM (...) delta WHERE TRUE, adding the counts and amounts of delta to
+	// the rows that exist instead of failing.
+	UpsertDailyStats string
+	// JSONTable returns a table expression reading the JSON array of objects
+	// bound to its one placeholder, with an idx column that numbers the
+	// elements from 1 and the given columns.
+	JSONTable func(columns ...JSONColumn) string
+	// TopCountries and TopCountriesFromRollup return the columns
+	// scanCountryStats scans, aggregated from the bets and from the rollup.
+	// Their placeholders are the limit, from, to, sort field, sort order,
+	// cursor sort value, cursor total bets, cursor country code and currency.
+	TopCountries           string
+	TopCountriesFromRollup string
+
+	IsDuplicateEntry      func(err error) bool
+	IsForeignKeyViolation func(err error) bool
+	// IsTransient reports whether an error may not happen again when the
+	// query or transaction is retried.
+	IsTransient func(err error) bool
+}
+
+// JSONColumn is a column of a JSONTable, read from Path of every element.
+type JSONColumn struct {
+	Name string
+	// Type is the MySQL type of the column, SQLite keeps the JSON value.
+	Type string
+	Path string
+}
+
+// MySQL is the dialect of New, the top countries are aggregated by the stored
+// procedures of the migrations.
+var MySQL = Dialect{
+	LockRow:      " FOR UPDATE",
+	InsertIgnore: "INSERT IGNORE",
+	UpsertDailyStats: `
+		ON DUPLICATE KEY UPDATE
+			bet_count = country_daily_stats.bet_count + delta.bet_count,
+			total_amount = country_daily_stats.total_amount + delta.total_amount,
+			settled_stakes = country_daily_stats.settled_stakes + delta.settled_stakes,
+			payouts = country_daily_stats.payouts + delta.payouts,
+			win_count = country_daily_stats.win_count + delta.win_count`,
+	JSONTable:              mysqlJSONTable,
+	TopCountries:           "CALL GetTopCountriesByPlayerActivity(?, ?, ?, ?, ?, ?, ?, ?, ?)",
+	TopCountriesFromRollup: "CALL GetTopCountriesByPlayerActivityFromRollup(?, ?, ?, ?, ?, ?, ?, ?, ?)",
+	IsDuplicateEntry:       isMySQLDuplicateEntry,
+	IsForeignKeyViolation:  isMySQLForeignKeyViolation,
+	IsTransient:            isMySQLTransient,
+}
+
+func mysqlJSONTable(columns ...JSONColumn) string {
+	definitions := []string{"idx FOR ORDINALITY"}
+	for _, c := range columns {
+		definitions = append(definitions, fmt.Sprintf("%s %s PATH '%s'", c.Name, c.Type, c.Path))
+	}
+	return "JSON_TABLE(?, '$[*]' COLUMNS (" + strings.Join(definitions, ", ") + "))"
+}
@@ -0,0 +1,201 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+type histogramBounds struct {
+	Lower float64  `json:"l"`
+	Upper *float64 `json:"u,omitempty"`
+}
+
+// histogramColumns read histogramBounds from a JSON table.
+var histogramColumns = []JSONColumn{
+	{Name: "lower_bound", Type: "DECIMAL(12, 2)", Path: "$.l"},
+	{Name: "upper_bound", Type: "DECIMAL(12, 2)", Path: "$.u"},
+}
+
+func (s *Store) GetBetDistributionByCountry(ctx context.Context, q domain.GetBetDistributionByCountryQuery) (*domain.GetBetDistributionByCountryResult, error) {
+	result := &domain.GetBetDistributionByCountryResult{
+		Distributions: make(map[string]domain.BetDistribution, len(q.CountryCodes)),
+	}
+	if len(q.CountryCodes) == 0 {
+		return result, nil
+	}
+
+	if err := s.getBetDistributionSummary(ctx, q, result.Distributions); err != nil {
+		return nil, err
+	}
+	if err := s.getBetDistributionHistogram(ctx, q, result.Distributions); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// getBetDistributionSummary fills in everything but the histogram. For every
+// percentile the two closest ranks are selected and interpolated afterwards.
+// SQLite has no STDDEV_POP, the deviation is computed from the mean.
+func (s *Store) getBetDistributionSummary(ctx context.Context, q domain.GetBetDistributionByCountryQuery, distributions map[string]domain.BetDistribution) error {
+	query := `
+		SELECT
+			country_code,
+			COUNT(*),
+			MIN(amount),
+			MAX(amount),
+			SQRT(AVG((amount - mean) * (amount - mean))),
+			MAX(CASE WHEN rn = FLOOR(1 + 0.50 * (cnt - 1)) THEN amount END),
+			MAX(CASE WHEN rn = CEIL(1 + 0.50 * (cnt - 1)) THEN amount END),
+			MAX(CASE WHEN rn = FLOOR(1 + 0.90 * (cnt - 1)) THEN amount END),
+			MAX(CASE WHEN rn = CEIL(1 + 0.90 * (cnt - 1)) THEN amount END),
+			MAX(CASE WHEN rn = FLOOR(1 + 0.99 * (cnt - 1)) THEN amount END),
+			MAX(CASE WHEN rn = CEIL(1 + 0.99 * (cnt - 1)) THEN amount END)
+		FROM (
+			SELECT
+				p.country_code,
+				b.amount,
+				ROW_NUMBER() OVER (PARTITION BY p.country_code ORDER BY b.amount) AS rn,
+				COUNT(*) OVER (PARTITION BY p.country_code) AS cnt,
+				AVG(b.amount) OVER (PARTITION BY p.country_code) AS mean
+			FROM (` + ConvertedBets("?") + `) b
+			JOIN players p ON p.id = b.player_id
+			WHERE p.country_code IN (` + placeholders(len(q.CountryCodes)) + `)
+				AND (? IS NULL OR b.created_at >= ?)
+				AND (? IS NULL OR b.created_at < ?)
+		) ranked
+		GROUP BY country_code`
+
+	return s.withRetry(ctx, func(ctx context.Context) error {
+		rows, err := s.queryReport(ctx, query, distributionArgs(q)...)
+		if err != nil {
+			return fmt.Errorf("failed to query bet distribution: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				countryCode  string
+				distribution domain.BetDistribution
+				percentiles  [6]float64
+			)
+			err := rows.Scan(
+				&countryCode,
+				&distribution.Count,
+				&distribution.Min,
+				&distribution.Max,
+				&distribution.StdDev,
+				&percentiles[0],
+				&percentiles[1],
+				&percentiles[2],
+				&percentiles[3],
+				&percentiles[4],
+				&percentiles[5],
+			)
+			if err != nil {
+				return fmt.Errorf("failed to scan row: %w", err)
+			}
+
+			distribution.Median = interpolatePercentile(0.50, distribution.Count, percentiles[0], percentiles[1])
+			distribution.P90 = interpolatePercentile(0.90, distribution.Count, percentiles[2], percentiles[3])
+			distribution.P99 = interpolatePercentile(0.99, distribution.Count, percentiles[4], percentiles[5])
+			distributions[countryCode] = distribution
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating rows: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *Store) getBetDistributionHistogram(ctx context.Context, q domain.GetBetDistributionByCountryQuery, distributions map[string]domain.BetDistribution) error {
+	if len(q.HistogramEdges) == 0 {
+		return nil
+	}
+
+	query := `
+		SELECT p.country_code, h.idx, COUNT(b.id)
+		FROM ` + s.dialect.JSONTable(histogramColumns...) + ` h
+		JOIN (` + ConvertedBets("?") + `) b ON b.amount >= h.lower_bound AND (h.upper_bound IS NULL OR b.amount < h.upper_bound)
+		JOIN players p ON p.id = b.player_id
+		WHERE p.country_code IN (` + placeholders(len(q.CountryCodes)) + `)
+			AND (? IS NULL OR b.created_at >= ?)
+			AND (? IS NULL OR b.created_at < ?)
+		GROUP BY p.country_code, h.idx`
+
+	bounds := make([]histogramBounds, 0, len(q.HistogramEdges))
+	for i, edge := range q.HistogramEdges {
+		b := histogramBounds{Lower: edge}
+		if i+1 < len(q.HistogramEdges) {
+			b.Upper = &q.HistogramEdges[i+1]
+		}
+		bounds = append(bounds, b)
+	}
+	histogram, err := json.Marshal(bounds)
+	if err != nil {
+		return fmt.Errorf("failed to encode histogram: %w", err)
+	}
+
+	args := append([]any{string(histogram)}, distributionArgs(q)...)
+	return s.withRetry(ctx, func(ctx context.Context) error {
+		rows, err := s.queryReport(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to query bet histogram: %w", err)
+		}
+		defer rows.Close()
+
+		for countryCode, distribution := range distributions {
+			distribution.Histogram = domain.NewHistogram(q.HistogramEdges)
+			distributions[countryCode] = distribution
+		}
+
+		for rows.Next() {
+			var (
+				countryCode string
+				bucket      int
+				count       int
+			)
+			if err := rows.Scan(&countryCode, &bucket, &count); err != nil {
+				return fmt.Errorf("failed to scan row: %w", err)
+			}
+			if distribution, ok := distributions[countryCode]; ok {
+				// JSONTable counts from 1.
+				distribution.Histogram[bucket-1].Count = count
+			}
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating rows: %w", err)
+		}
+		return nil
+	})
+}
+
+func distributionArgs(q domain.GetBetDistributionByCountryQuery) []any {
+	from, to := nullTime(q.From), nullTime(q.To)
+
+	args := make([]any, 0, len(q.CountryCodes)+5)
+	args = append(args, q.Currency)
+	for _, countryCode := range q.CountryCodes {
+		args = append(args, countryCode)
+	}
+	return append(args, from, from, to, to)
+}
+
+// interpolatePercentile interpolates between the values at the floor and ceil
+// of the 1-based rank 1 + p*(n-1), like PERCENTILE_CONT.
+func interpolatePercentile(p float64, n int, lower, upper float64) float64 {
+	pos := p * float64(n-1)
+	return lower + (pos-math.Floor(pos))*(upper-lower)
+}
+
+// placeholders returns n comma separated bind parameters for an IN list.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
@@ -0,0 +1,133 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+const playerErasureColumns = "id, player_id, requested_by, reason, erased_at"
+
+func scanPlayerErasure(row rowScanner) (domain.PlayerErasure, error) {
+	var e domain.PlayerErasure
+	err := row.Scan(
+		&e.ID,
+		&e.PlayerID,
+		&e.RequestedBy,
+		&e.Reason,
+		&e.ErasedAt,
+	)
+	return e, err
+}
+
+func (s *Store) ErasePlayer(ctx context.Context, q domain.ErasePlayerQuery) (*domain.ErasePlayerResult, error) {
+	var result domain.ErasePlayerResult
+
+	// Erasing a player again replays the first erasure, so the erasure can be
+	// retried.
+	err := s.withRetryTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		result = domain.ErasePlayerResult{}
+
+		var erasedAt sql.NullTime
+		err := tx.QueryRowContext(ctx, "SELECT erased_at FROM players WHERE id = ?"+s.dialect.LockRow, q.ID).Scan(&erasedAt)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("player %d: %w", q.ID, domain.ErrNotFound)
+			}
+			return fmt.Errorf("failed to get player: %w", err)
+		}
+
+		if erasedAt.Valid {
+			query := "SELECT " + playerErasureColumns + " FROM player_erasures WHERE player_id = ? ORDER BY id LIMIT 1"
+			result.Erasure, err = scanPlayerErasure(tx.QueryRowContext(ctx, query, q.ID))
+			if err != nil {
+				return fmt.Errorf("failed to get player erasure: %w", err)
+			}
+			result.Replayed = true
+			return nil
+		}
+
+		audit, err := s.startAudit(ctx, tx, domain.AuditEntityPlayer, domain.AuditActionErase, q.ID)
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx,
+			"INSERT INTO player_erasures (player_id, requested_by, reason) VALUES (?, ?, ?)",
+			q.ID, q.RequestedBy, q.Reason)
+		if err != nil {
+			return fmt.Errorf("failed to insert player erasure: %w", err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get inserted player erasure id: %w", err)
+		}
+
+		// The player keeps their country and bets, so the rollup stays as it
+		// is. The external ID goes as well, it links to the player elsewhere.
+		query := `
+			UPDATE players
+			SET name = ?, email = ?, external_id = NULL,
+				erased_at = (SELECT erased_at FROM player_erasures WHERE id = ?), updated_at = CURRENT_TIMESTAMP
+			WHERE id = ?`
+		if _, err := tx.ExecContext(ctx, query, q.Name, q.Email, id, q.ID); err != nil {
+			return fmt.Errorf("failed to erase player: %w", err)
+		}
+
+		// The erased name and email must not survive in the audit log either.
+		if err := audit.record(ctx, tx, q.ID); err != nil {
+			return err
+		}
+		if err := redactPlayerAudit(ctx, tx, q.ID, q.Name, q.Email); err != nil {
+			return err
+		}
+
+		query = "SELECT " + playerErasureColumns + " FROM player_erasures WHERE id = ?"
+		result.Erasure, err = scanPlayerErasure(tx.QueryRowContext(ctx, query, id))
+		if err != nil {
+			return fmt.Errorf("failed to get player erasure: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (s *Store) ListPlayerErasures(ctx context.Context, q domain.ListPlayerErasuresQuery) (*domain.ListPlayerErasuresResult, error) {
+	query := "SELECT " + playerErasureColumns + " FROM player_erasures WHERE (? = 0 OR player_id = ?) ORDER BY id LIMIT ? OFFSET ?"
+
+	var erasures []domain.PlayerErasure
+	err := s.withRetry(ctx, func(ctx context.Context) error {
+		rows, err := s.db.QueryContext(ctx, query, q.PlayerID, q.PlayerID, q.Limit, q.Offset)
+		if err != nil {
+			return fmt.Errorf("failed to list player erasures: %w", err)
+		}
+		defer rows.Close()
+
+		erasures = []domain.PlayerErasure{}
+		for rows.Next() {
+			erasure, err := scanPlayerErasure(rows)
+			if err != nil {
+				return fmt.Errorf("failed to scan row: %w", err)
+			}
+			erasures = append(erasures, erasure)
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating rows: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.ListPlayerErasuresResult{
+		Erasures: erasures,
+	}, nil
+}
@@ -0,0 +1,156 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+const exchangeRateColumns = "currency, effective_from, rate"
+
+func scanExchangeRate(row rowScanner) (domain.ExchangeRate, error) {
+	var (
+		r             domain.ExchangeRate
+		effectiveFrom nullTimestamp
+	)
+	err := row.Scan(
+		&r.Currency,
+		&effectiveFrom,
+		&r.Rate,
+	)
+	r.EffectiveFrom = effectiveFrom.Time
+	return r, err
+}
+
+func (s *Store) CreateExchangeRate(ctx context.Context, q domain.CreateExchangeRateQuery) (*domain.CreateExchangeRateResult, error) {
+	query := "INSERT INTO exchange_rates (currency, effective_from, rate) VALUES (?, ?, ?)"
+
+	effectiveFrom := q.Rate.EffectiveFrom.Format(time.DateOnly)
+
+	// A rate is created once, the insert is not retried.
+	err := s.withTimeout(ctx, func(ctx context.Context) error {
+		_, err := s.db.ExecContext(ctx, query, q.Rate.Currency, effectiveFrom, q.Rate.Rate)
+		return err
+	})
+	if err != nil {
+		if s.dialect.IsDuplicateEntry(err) {
+			return nil, fmt.Errorf("%s rate effective from %s already exists: %w", q.Rate.Currency, effectiveFrom, domain.ErrConflict)
+		}
+		return nil, fmt.Errorf("failed to insert exchange rate: %w", err)
+	}
+
+	query = "SELECT " + exchangeRateColumns + " FROM exchange_rates WHERE currency = ? AND effective_from = ?"
+
+	var rate domain.ExchangeRate
+	err = s.withRetry(ctx, func(ctx context.Context) error {
+		var err error
+		rate, err = scanExchangeRate(s.db.QueryRowContext(ctx, query, q.Rate.Currency, effectiveFrom))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange rate: %w", err)
+	}
+
+	return &domain.CreateExchangeRateResult{
+		Rate: rate,
+	}, nil
+}
+
+func (s *Store) ListExchangeRates(ctx context.Context, q domain.ListExchangeRatesQuery) (*domain.ListExchangeRatesResult, error) {
+	query := "SELECT " + exchangeRateColumns + " FROM exchange_rates WHERE (? IS NULL OR currency = ?) ORDER BY currency, effective_from"
+
+	var currency sql.NullString
+	if q.Currency != "" {
+		currency = sql.NullString{String: q.Currency, Valid: true}
+	}
+
+	var rates []domain.ExchangeRate
+	err := s.withRetry(ctx, func(ctx context.Context) error {
+		rows, err := s.db.QueryContext(ctx, query, currency, currency)
+		if err != nil {
+			return fmt.Errorf("failed to list exchange rates: %w", err)
+		}
+		defer rows.Close()
+
+		rates = []domain.ExchangeRate{}
+		for rows.Next() {
+			rate, err := scanExchangeRate(rows)
+			if err != nil {
+				return fmt.Errorf("failed to scan row: %w", err)
+			}
+			rates = append(rates, rate)
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating rows: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.ListExchangeRatesResult{
+		Rates: rates,
+	}, nil
+}
+
+// ConvertedBets returns a query selecting the id, player_id, created_at,
+// status, amount and payout of every bet with the amounts converted into the
+// currency the SQL expression currency evaluates to, usually a placeholder.
+// The rates effective when the bet was placed are used and the converted
+// amounts are rounded to the cent. Only statistics of single bets, like the
+// distribution, convert bet by bet, totals are converted with convertDaily.
+func ConvertedBets(currency string) string {
+	return `
+		SELECT
+			bets.id,
+			bets.player_id,
+			bets.created_at,
+			bets.status,
+			ROUND(bets.amount * tgt.rate / src.rate, 2) AS amount,
+			ROUND(bets.payout * tgt.rate / src.rate, 2) AS payout
+		FROM bets
+		JOIN exchange_rate_periods src
+			ON src.currency = bets.currency
+			AND (src.valid_from IS NULL OR bets.created_at >= src.valid_from)
+			AND (src.valid_to IS NULL OR bets.created_at < src.valid_to)
+		JOIN exchange_rate_periods tgt
+			ON tgt.currency = ` + currency + `
+			AND (tgt.valid_from IS NULL OR bets.created_at >= tgt.valid_from)
+			AND (tgt.valid_to IS NULL OR bets.created_at < tgt.valid_to)`
+}
+
+// convertDaily returns a query selecting the rows of totals, a table or a
+// parenthesized query with day and currency columns, with the columns amounts
+// converted into the currency the SQL expression currency evaluates to and the
+// columns keys as they are. The rates effective on the day are used and every
+// row is rounded to the cent once. Every report sums bets up per day and
+// currency before it converts them, so the same bets add up to the same cents
+// in every report grouping them by day.
+func convertDaily(totals string, keys, amounts []string, currency string) string {
+	columns := make([]string, 0, len(keys)+len(amounts))
+	for _, key := range keys {
+		columns = append(columns, "t."+key)
+	}
+	for _, amount := range amounts {
+		columns = append(columns, fmt.Sprintf("ROUND(t.%[1]s * tgt.rate / src.rate, 2) AS %[1]s", amount))
+	}
+
+	return `
+		SELECT
+			` + strings.Join(columns, ",\n\t\t\t") + `
+		FROM ` + totals + ` t
+		JOIN exchange_rate_periods src
+			ON src.currency = t.currency
+			AND (src.valid_from IS NULL OR t.day >= src.valid_from)
+			AND (src.valid_to IS NULL OR t.day < src.valid_to)
+		JOIN exchange_rate_periods tgt
+			ON tgt.currency = ` + currency + `
+			AND (tgt.valid_from IS NULL OR t.day >= tgt.valid_from)
+			AND (tgt.valid_to IS NULL OR t.day < tgt.valid_to)`
+}
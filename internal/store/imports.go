@@ -0,0 +1,199 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+// importBetKeyPrefix turns the external ID of an imported bet into its
+// idempotency key, so a bet imported twice is recorded once.
+const importBetKeyPrefix = "import:"
+
+// errImportDryRun rolls back the transaction of a dry run import.
+var errImportDryRun = errors.New("import dry run")
+
+// withImportTx runs fn in a transaction like withTx, but rolls it back instead
+// of committing it on dry runs. Rows are imported once per external ID, so the
+// transaction is retried.
+func (s *Store) withImportTx(ctx context.Context, dryRun bool, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	err := s.withRetryTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		if err := fn(ctx, tx); err != nil {
+			return err
+		}
+		if dryRun {
+			return errImportDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errImportDryRun) {
+		return nil
+	}
+	return err
+}
+
+func (s *Store) ImportPlayers(ctx context.Context, q domain.ImportPlayersQuery) (*domain.ImportPlayersResult, error) {
+	result := &domain.ImportPlayersResult{
+		Rows: make([]domain.ImportRowResult, len(q.Players)),
+	}
+
+	err := s.withImportTx(ctx, q.DryRun, func(ctx context.Context, tx *sql.Tx) error {
+		for i, p := range q.Players {
+			var err error
+			if result.Rows[i], err = s.importPlayer(ctx, tx, p); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// importPlayer inserts a player unless one with the same external ID exists.
+// Only failures of the whole transaction are returned as errors, a rejected
+// player is reported in the row result.
+func (s *Store) importPlayer(ctx context.Context, tx *sql.Tx, p domain.ImportedPlayer) (domain.ImportRowResult, error) {
+	id, err := playerIDByExternalID(ctx, tx, p.ExternalID)
+	switch {
+	case err == nil:
+		return domain.ImportRowResult{ID: id, Skipped: true}, nil
+	case !errors.Is(err, domain.ErrNotFound):
+		return domain.ImportRowResult{}, err
+	}
+
+	query := `
+		INSERT INTO players (external_id, name, email, country_code, created_at, updated_at)
+		VALUES (?, ?, ?, ?, COALESCE(?, CURRENT_TIMESTAMP), COALESCE(?, CURRENT_TIMESTAMP))`
+
+	audit, err := s.startAudit(ctx, tx, domain.AuditEntityPlayer, domain.AuditActionImport, 0)
+	if err != nil {
+		return domain.ImportRowResult{}, err
+	}
+
+	createdAt := nullTime(p.CreatedAt)
+	res, err := tx.ExecContext(ctx, query, p.ExternalID, p.Name, p.Email, p.CountryCode, createdAt, createdAt)
+	if err != nil {
+		if s.dialect.IsDuplicateEntry(err) {
+			return domain.ImportRowResult{Err: fmt.Errorf("player with email %q already exists: %w", p.Email, domain.ErrConflict)}, nil
+		}
+		return domain.ImportRowResult{}, fmt.Errorf("failed to insert player: %w", err)
+	}
+
+	inserted, err := res.LastInsertId()
+	if err != nil {
+		return domain.ImportRowResult{}, fmt.Errorf("failed to get inserted player id: %w", err)
+	}
+	if err := audit.record(ctx, tx, int(inserted)); err != nil {
+		return domain.ImportRowResult{}, err
+	}
+
+	return domain.ImportRowResult{ID: int(inserted)}, nil
+}
+
+func (s *Store) ImportBets(ctx context.Context, q domain.ImportBetsQuery) (*domain.ImportBetsResult, error) {
+	result := &domain.ImportBetsResult{
+		Rows: make([]domain.ImportRowResult, len(q.Bets)),
+	}
+
+	err := s.withImportTx(ctx, q.DryRun, func(ctx context.Context, tx *sql.Tx) error {
+		// Exports list many bets of the same player, each is looked up once.
+		players := make(map[string]int)
+		for i, b := range q.Bets {
+			var err error
+			if result.Rows[i], err = s.importBet(ctx, tx, b, players); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// importBet inserts a bet unless it was imported before, and adds it to the
+// rollup. players caches the internal IDs of external player IDs.
+func (s *Store) importBet(ctx context.Context, tx *sql.Tx, b domain.ImportedBet, players map[string]int) (domain.ImportRowResult, error) {
+	key := importBetKeyPrefix + b.ExternalID
+
+	var id int
+	err := tx.QueryRowContext(ctx, "SELECT id FROM bets WHERE idempotency_key = ?", key).Scan(&id)
+	switch {
+	case err == nil:
+		return domain.ImportRowResult{ID: id, Skipped: true}, nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return domain.ImportRowResult{}, fmt.Errorf("failed to get bet by idempotency key: %w", err)
+	}
+
+	playerID, ok := players[b.PlayerExternalID]
+	if !ok {
+		playerID, err = playerIDByExternalID(ctx, tx, b.PlayerExternalID)
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ImportRowResult{Err: err}, nil
+		}
+		if err != nil {
+			return domain.ImportRowResult{}, err
+		}
+		players[b.PlayerExternalID] = playerID
+	}
+
+	query := `
+		INSERT INTO bets (player_id, amount, currency, status, payout, settled_at, idempotency_key, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, COALESCE(?, CURRENT_TIMESTAMP))`
+
+	audit, err := s.startAudit(ctx, tx, domain.AuditEntityBet, domain.AuditActionImport, 0)
+	if err != nil {
+		return domain.ImportRowResult{}, err
+	}
+
+	res, err := tx.ExecContext(ctx, query,
+		playerID,
+		b.Amount,
+		b.Currency,
+		string(b.Status),
+		b.Payout,
+		nullTime(b.SettledAt),
+		key,
+		nullTime(b.CreatedAt),
+	)
+	if err != nil {
+		if s.dialect.IsForeignKeyViolation(err) {
+			return domain.ImportRowResult{Err: fmt.Errorf("player with external id %q: %w", b.PlayerExternalID, domain.ErrNotFound)}, nil
+		}
+		return domain.ImportRowResult{}, fmt.Errorf("failed to insert bet: %w", err)
+	}
+
+	inserted, err := res.LastInsertId()
+	if err != nil {
+		return domain.ImportRowResult{}, fmt.Errorf("failed to get inserted bet id: %w", err)
+	}
+	if err := audit.record(ctx, tx, int(inserted)); err != nil {
+		return domain.ImportRowResult{}, err
+	}
+	if err := s.addBetToRollup(ctx, tx, inserted); err != nil {
+		return domain.ImportRowResult{}, err
+	}
+
+	return domain.ImportRowResult{ID: int(inserted)}, nil
+}
+
+func playerIDByExternalID(ctx context.Context, tx *sql.Tx, externalID string) (int, error) {
+	var id int
+	err := tx.QueryRowContext(ctx, "SELECT id FROM players WHERE external_id = ?", externalID).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("player with external id %q: %w", externalID, domain.ErrNotFound)
+		}
+		return 0, fmt.Errorf("failed to get player by external id: %w", err)
+	}
+	return id, nil
+}
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+func (s *Store) GetTopPlayersByTotalWagered(ctx context.Context, q domain.GetTopPlayersByTotalWageredQuery) (*domain.GetTopPlayersByTotalWageredResult, error) {
+	// Bets are summed up per player, day and currency and converted into the
+	// currency of the query like country stats. Totals are rounded to the
+	// cent so that equal totals of SQLite's float amounts get the same rank.
+	query := `
+		SELECT player_rank, id, name, country_code, total_wagered, bet_count
+		FROM (
+			SELECT
+				totals.*,
+				DENSE_RANK() OVER (ORDER BY totals.total_wagered DESC) AS player_rank
+			FROM (
+				SELECT
+					p.id,
+					p.name,
+					p.country_code,
+					ROUND(SUM(d.total_amount), 2) AS total_wagered,
+					SUM(d.bet_count) AS bet_count
+				FROM (` + convertDaily(`(
+					SELECT
+						b.player_id,
+						DATE(b.created_at) AS day,
+						b.currency,
+						COUNT(*) AS bet_count,
+						ROUND(SUM(b.amount), 2) AS total_amount
+					FROM bets b
+					WHERE (? IS NULL OR b.created_at >= ?)
+						AND (? IS NULL OR b.created_at < ?)
+					GROUP BY b.player_id, DATE(b.created_at), b.currency
+				)`, []string{"player_id", "bet_count"}, []string{"total_amount"}, "?") + `) d
+				JOIN players p ON p.id = d.player_id
+				WHERE ? IS NULL OR p.country_code = ?
+				GROUP BY p.id, p.name, p.country_code
+			) totals
+		) ranked
+		WHERE player_rank <= ?
+		ORDER BY player_rank, id
+		LIMIT ?`
+
+	var countryCode sql.NullString
+	if q.CountryCode != "" {
+		countryCode = sql.NullString{String: q.CountryCode, Valid: true}
+	}
+	from, to := nullTime(q.From), nullTime(q.To)
+
+	var entries []domain.LeaderboardEntry
+	err := s.withRetry(ctx, func(ctx context.Context) error {
+		rows, err := s.queryReport(ctx, query,
+			from, from,
+			to, to,
+			q.Currency,
+			countryCode, countryCode,
+			q.MaxRank,
+			q.MaxEntries,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to query leaderboard: %w", err)
+		}
+		defer rows.Close()
+
+		entries = []domain.LeaderboardEntry{}
+		for rows.Next() {
+			var entry domain.LeaderboardEntry
+			err := rows.Scan(
+				&entry.Rank,
+				&entry.PlayerID,
+				&entry.Name,
+				&entry.CountryCode,
+				&entry.TotalWagered,
+				&entry.BetCount,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to scan row: %w", err)
+			}
+			entries = append(entries, entry)
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating rows: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.GetTopPlayersByTotalWageredResult{
+		Entries: entries,
+	}, nil
+}
@@ -0,0 +1,134 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/mysql"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+
+	"github.com/Nikola-Milovic/vyking-interview/migrations"
+)
+
+// Migrator applies the migrations embedded in the binary. The database it is
+// created with must allow multiple statements per query, the migrations
+// create stored procedures.
+type Migrator struct {
+	m      *migrate.Migrate
+	source source.Driver
+}
+
+// MigrationStatus is the state of the schema compared to the embedded
+// migrations. Version is 0 before the first migration is applied.
+type MigrationStatus struct {
+	Version uint
+	Dirty   bool
+	Latest  uint
+	Pending int
+}
+
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedded migrations: %w", err)
+	}
+
+	driver, err := mysql.WithInstance(db, &mysql.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migration driver: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, "mysql", driver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migration instance: %w", err)
+	}
+	m.Log = migrateLogger{}
+
+	return &Migrator{m: m, source: src}, nil
+}
+
+// Up applies the next steps migrations, or all pending ones when steps is 0.
+func (m *Migrator) Up(steps int) error {
+	var err error
+	if steps == 0 {
+		err = m.m.Up()
+	} else {
+		err = m.m.Steps(steps)
+	}
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
+	return nil
+}
+
+// Down reverts the last steps migrations, or all of them when steps is 0.
+func (m *Migrator) Down(steps int) error {
+	var err error
+	if steps == 0 {
+		err = m.m.Down()
+	} else {
+		err = m.m.Steps(-steps)
+	}
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to rollback migrations: %w", err)
+	}
+	return nil
+}
+
+// Force sets the schema version without running any migration and clears the
+// dirty flag, after a failed migration was fixed by hand. A version of -1
+// means no migration is applied.
+func (m *Migrator) Force(version int) error {
+	if err := m.m.Force(version); err != nil {
+		return fmt.Errorf("failed to force migration version: %w", err)
+	}
+	return nil
+}
+
+func (m *Migrator) Status() (MigrationStatus, error) {
+	var status MigrationStatus
+
+	version, dirty, err := m.m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return status, fmt.Errorf("failed to read migration version: %w", err)
+	}
+	status.Version, status.Dirty = version, dirty
+
+	v, err := m.source.First()
+	for err == nil {
+		status.Latest = v
+		if v > status.Version {
+			status.Pending++
+		}
+		v, err = m.source.Next(v)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return status, fmt.Errorf("failed to read embedded migrations: %w", err)
+	}
+
+	return status, nil
+}
+
+// Close releases the connection the migrator holds, the database it was
+// created with stays open.
+func (m *Migrator) Close() error {
+	sourceErr, dbErr := m.m.Close()
+	return errors.Join(sourceErr, dbErr)
+}
+
+// migrateLogger logs every applied migration.
+type migrateLogger struct{}
+
+func (migrateLogger) Printf(format string, v ...any) {
+	slog.Info("migration applied", "migration", strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (migrateLogger) Verbose() bool {
+	return false
+}
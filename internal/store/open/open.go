@@ -0,0 +1,93 @@
+// Package open connects to the database of the configured driver, so that the
+// server and every command set up their store the same way.
+package open
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/config"
+	"github.com/Nikola-Milovic/vyking-interview/internal/store"
+	"github.com/Nikola-Milovic/vyking-interview/internal/store/sqlite"
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// Handle is an open store and the connections it uses.
+type Handle struct {
+	Store *store.Store
+	// DB is the primary database, for commands that query it directly.
+	DB *sql.DB
+
+	dbs []*sql.DB
+}
+
+// Close closes every connection the store uses.
+func (h *Handle) Close() {
+	for _, db := range h.dbs {
+		db.Close()
+	}
+}
+
+// Database opens the store of cfg.Driver on the primary database only.
+func Database(ctx context.Context, cfg config.DatabaseConfig) (*Handle, error) {
+	return open(ctx, cfg, false)
+}
+
+// DatabaseWithReplicas is Database with reports read from the MySQL replicas
+// of cfg. Replicas are not pinged, the store reads from the primary until the
+// replica monitor, which runs until ctx is done, finds them healthy.
+func DatabaseWithReplicas(ctx context.Context, cfg config.DatabaseConfig) (*Handle, error) {
+	return open(ctx, cfg, true)
+}
+
+func open(ctx context.Context, cfg config.DatabaseConfig, withReplicas bool) (*Handle, error) {
+	slog.Info("connecting to database")
+
+	policy := store.QueryPolicy{Timeout: cfg.QueryTimeout, MaxAttempts: cfg.MaxQueryAttempts}
+
+	if cfg.Driver == config.DriverSQLite {
+		db, err := sqlite.Open(ctx, cfg.SQLitePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open database: %w", err)
+		}
+		slog.Info("database connection established", "path", cfg.SQLitePath)
+
+		s := sqlite.New(db)
+		s.SetQueryPolicy(policy)
+		return &Handle{Store: s, DB: db, dbs: []*sql.DB{db}}, nil
+	}
+
+	db, err := sql.Open("mysql", cfg.DSN())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	h := &Handle{DB: db, dbs: []*sql.DB{db}}
+
+	if err := db.PingContext(ctx); err != nil {
+		h.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	slog.Info("database connection established")
+
+	var replicas []*sql.DB
+	if withReplicas {
+		for _, dsn := range cfg.ReplicaDSNs() {
+			replica, err := sql.Open("mysql", dsn)
+			if err != nil {
+				h.Close()
+				return nil, fmt.Errorf("failed to open replica database: %w", err)
+			}
+			h.dbs = append(h.dbs, replica)
+			replicas = append(replicas, replica)
+		}
+	}
+
+	h.Store = store.NewWithReplicas(db, replicas, cfg.MaxReplicaLag)
+	h.Store.SetQueryPolicy(policy)
+	if len(replicas) > 0 {
+		go h.Store.MonitorReplicas(ctx, cfg.ReplicaCheckInterval)
+	}
+	return h, nil
+}
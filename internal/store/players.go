@@ -0,0 +1,356 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+const playerColumns = "id, name, email, country_code, created_at, updated_at, erased_at"
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPlayer(row rowScanner) (domain.Player, error) {
+	var (
+		p        domain.Player
+		erasedAt sql.NullTime
+	)
+	err := row.Scan(
+		&p.ID,
+		&p.Name,
+		&p.Email,
+		&p.CountryCode,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+		&erasedAt,
+	)
+	p.ErasedAt = erasedAt.Time
+	return p, err
+}
+
+func (s *Store) CreatePlayer(ctx context.Context, q domain.CreatePlayerQuery) (*domain.CreatePlayerResult, error) {
+	query := "INSERT INTO players (name, email, country_code) VALUES (?, ?, ?)"
+
+	var id int64
+	err := s.withTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		audit, err := s.startAudit(ctx, tx, domain.AuditEntityPlayer, domain.AuditActionCreate, 0)
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx, query, q.Name, q.Email, q.CountryCode)
+		if err != nil {
+			if s.dialect.IsDuplicateEntry(err) {
+				return fmt.Errorf("player with email %q already exists: %w", q.Email, domain.ErrConflict)
+			}
+			return fmt.Errorf("failed to insert player: %w", err)
+		}
+
+		if id, err = res.LastInsertId(); err != nil {
+			return fmt.Errorf("failed to get inserted player id: %w", err)
+		}
+
+		return audit.record(ctx, tx, int(id))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	player, err := s.getPlayer(ctx, int(id))
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.CreatePlayerResult{
+		Player: player,
+	}, nil
+}
+
+func (s *Store) GetPlayer(ctx context.Context, q domain.GetPlayerQuery) (*domain.GetPlayerResult, error) {
+	player, err := s.getPlayer(ctx, q.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.GetPlayerResult{
+		Player: player,
+	}, nil
+}
+
+func (s *Store) ListPlayers(ctx context.Context, q domain.ListPlayersQuery) (*domain.ListPlayersResult, error) {
+	query := "SELECT " + playerColumns + " FROM players WHERE (? = '' OR country_code = ?) ORDER BY id LIMIT ? OFFSET ?"
+
+	var players []domain.Player
+	err := s.withRetry(ctx, func(ctx context.Context) error {
+		rows, err := s.db.QueryContext(ctx, query, q.CountryCode, q.CountryCode, q.Limit, q.Offset)
+		if err != nil {
+			return fmt.Errorf("failed to list players: %w", err)
+		}
+		defer rows.Close()
+
+		players = []domain.Player{}
+		for rows.Next() {
+			player, err := scanPlayer(rows)
+			if err != nil {
+				return fmt.Errorf("failed to scan row: %w", err)
+			}
+			players = append(players, player)
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating rows: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.ListPlayersResult{
+		Players: players,
+	}, nil
+}
+
+func (s *Store) UpdatePlayer(ctx context.Context, q domain.UpdatePlayerQuery) (*domain.UpdatePlayerResult, error) {
+	// SQLite has no ON UPDATE CURRENT_TIMESTAMP.
+	query := "UPDATE players SET name = ?, email = ?, country_code = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?"
+
+	// Updating a player to the values it has changes nothing, so the update
+	// can be retried.
+	err := s.withRetryTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		current, err := s.lockPlayer(ctx, tx, q.ID)
+		if err != nil {
+			return err
+		}
+		// Updating an erased player would bring back the personal data the
+		// erasure removed.
+		if !current.ErasedAt.IsZero() {
+			return fmt.Errorf("player %d is erased: %w", q.ID, domain.ErrConflict)
+		}
+		// Not even updated_at changes, so the update is not audited either.
+		if current.Name == q.Name && current.Email == q.Email && current.CountryCode == q.CountryCode {
+			return nil
+		}
+
+		audit, err := s.startAudit(ctx, tx, domain.AuditEntityPlayer, domain.AuditActionUpdate, q.ID)
+		if err != nil {
+			return err
+		}
+
+		// The bets of a player that moves are moved to the new country in the
+		// rollup as well.
+		moved := current.CountryCode != q.CountryCode
+		if moved {
+			if err := s.addToRollup(ctx, tx, -1, "b.player_id = ?", q.ID); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, query, q.Name, q.Email, q.CountryCode, q.ID); err != nil {
+			if s.dialect.IsDuplicateEntry(err) {
+				return fmt.Errorf("player with email %q already exists: %w", q.Email, domain.ErrConflict)
+			}
+			return fmt.Errorf("failed to update player: %w", err)
+		}
+
+		if err := audit.record(ctx, tx, q.ID); err != nil {
+			return err
+		}
+
+		if !moved {
+			return nil
+		}
+		if err := s.addToRollup(ctx, tx, 1, "b.player_id = ?", q.ID); err != nil {
+			return err
+		}
+		return removeEmptyRollupRows(ctx, tx, current.CountryCode)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	player, err := s.getPlayer(ctx, q.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.UpdatePlayerResult{
+		Player: player,
+	}, nil
+}
+
+func (s *Store) DeletePlayer(ctx context.Context, q domain.DeletePlayerQuery) error {
+	query := "DELETE FROM players WHERE id = ?"
+
+	return s.withTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		player, err := s.lockPlayer(ctx, tx, q.ID)
+		if err != nil {
+			return err
+		}
+
+		// The bets that go with the player are recorded as deleted as well.
+		audits, err := s.startPlayerDeleteAudit(ctx, tx, q.ID)
+		if err != nil {
+			return err
+		}
+
+		// The bets go with the player through the foreign key, the rollup has
+		// to be updated before they are gone.
+		if err := s.addToRollup(ctx, tx, -1, "b.player_id = ?", q.ID); err != nil {
+			return err
+		}
+		if err := removeEmptyRollupRows(ctx, tx, player.CountryCode); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, query, q.ID); err != nil {
+			return fmt.Errorf("failed to delete player: %w", err)
+		}
+
+		for _, audit := range audits {
+			if err := audit.record(ctx, tx, 0); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// lockPlayer locks the player's row for the rest of the transaction and
+// returns it.
+func (s *Store) lockPlayer(ctx context.Context, tx *sql.Tx, id int) (domain.Player, error) {
+	player, err := scanPlayer(tx.QueryRowContext(ctx, "SELECT "+playerColumns+" FROM players WHERE id = ?"+s.dialect.LockRow, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Player{}, fmt.Errorf("player %d: %w", id, domain.ErrNotFound)
+		}
+		return domain.Player{}, fmt.Errorf("failed to get player: %w", err)
+	}
+	return player, nil
+}
+
+func (s *Store) GetPlayerStats(ctx context.Context, q domain.GetPlayerStatsQuery) (*domain.GetPlayerStatsResult, error) {
+	// Bets are summed up per day and currency and converted like country
+	// stats. Conversion keeps the order of amounts, so the smallest and
+	// largest bet of a day are converted with it.
+	query := `
+		SELECT
+			p.id, p.name, p.email, p.country_code, p.created_at, p.updated_at,
+			COALESCE(SUM(d.bet_count), 0),
+			COALESCE(ROUND(SUM(d.total_amount), 2), 0),
+			COALESCE(MIN(d.min_amount), 0),
+			COALESCE(MAX(d.max_amount), 0),
+			MIN(d.first_bet_at),
+			MAX(d.last_bet_at)
+		FROM players p
+		LEFT JOIN (` + convertDaily(`(
+			SELECT
+				b.player_id,
+				DATE(b.created_at) AS day,
+				b.currency,
+				COUNT(*) AS bet_count,
+				ROUND(SUM(b.amount), 2) AS total_amount,
+				MIN(b.amount) AS min_amount,
+				MAX(b.amount) AS max_amount,
+				MIN(b.created_at) AS first_bet_at,
+				MAX(b.created_at) AS last_bet_at
+			FROM bets b
+			WHERE b.player_id = ?
+			GROUP BY b.player_id, DATE(b.created_at), b.currency
+		)`, []string{"player_id", "bet_count", "first_bet_at", "last_bet_at"}, []string{"total_amount", "min_amount", "max_amount"}, "?") + `) d ON d.player_id = p.id
+		WHERE p.id = ?
+		GROUP BY p.id, p.name, p.email, p.country_code, p.created_at, p.updated_at`
+
+	var (
+		stats                 domain.PlayerStats
+		firstBetAt, lastBetAt nullTimestamp
+	)
+	err := s.withRetry(ctx, func(ctx context.Context) error {
+		return s.db.QueryRowContext(ctx, query, q.PlayerID, q.Currency, q.PlayerID).Scan(
+			&stats.Player.ID,
+			&stats.Player.Name,
+			&stats.Player.Email,
+			&stats.Player.CountryCode,
+			&stats.Player.CreatedAt,
+			&stats.Player.UpdatedAt,
+			&stats.BetCount,
+			&stats.TotalWagered,
+			&stats.MinBet,
+			&stats.MaxBet,
+			&firstBetAt,
+			&lastBetAt,
+		)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("player %d: %w", q.PlayerID, domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get player stats: %w", err)
+	}
+
+	stats.AvgBet = stats.TotalWagered.DivRound(stats.BetCount)
+	stats.FirstBetAt = firstBetAt.Time
+	stats.LastBetAt = lastBetAt.Time
+
+	return &domain.GetPlayerStatsResult{
+		Stats: stats,
+	}, nil
+}
+
+func (s *Store) CountPlayersByCountry(ctx context.Context, q domain.CountPlayersByCountryQuery) (*domain.CountPlayersByCountryResult, error) {
+	query := "SELECT country_code, COUNT(*) FROM players GROUP BY country_code ORDER BY country_code"
+
+	var counts []domain.CountryPlayerCount
+	err := s.withRetry(ctx, func(ctx context.Context) error {
+		rows, err := s.db.QueryContext(ctx, query)
+		if err != nil {
+			return fmt.Errorf("failed to count players by country: %w", err)
+		}
+		defer rows.Close()
+
+		counts = []domain.CountryPlayerCount{}
+		for rows.Next() {
+			var count domain.CountryPlayerCount
+			if err := rows.Scan(&count.CountryCode, &count.PlayerCount); err != nil {
+				return fmt.Errorf("failed to scan row: %w", err)
+			}
+			counts = append(counts, count)
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating rows: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.CountPlayersByCountryResult{
+		Counts: counts,
+	}, nil
+}
+
+func (s *Store) getPlayer(ctx context.Context, id int) (domain.Player, error) {
+	query := "SELECT " + playerColumns + " FROM players WHERE id = ?"
+
+	var player domain.Player
+	err := s.withRetry(ctx, func(ctx context.Context) error {
+		var err error
+		player, err = scanPlayer(s.db.QueryRowContext(ctx, query, id))
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Player{}, fmt.Errorf("player %d: %w", id, domain.ErrNotFound)
+		}
+		return domain.Player{}, fmt.Errorf("failed to get player: %w", err)
+	}
+
+	return player, nil
+}
@@ -0,0 +1,174 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// replica is a read replica, it only receives reporting queries while its
+// last health check passed.
+type replica struct {
+	index   int
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+type replicaPool struct {
+	replicas []*replica
+	maxLag   time.Duration
+	next     atomic.Uint64
+}
+
+// NewWithReplicas returns a Store that sends reporting queries to the given
+// replicas and everything else to the primary db. Replicas start out unhealthy
+// and only receive queries once MonitorReplicas saw them replicating with at
+// most maxLag of lag.
+func NewWithReplicas(db *sql.DB, replicas []*sql.DB, maxLag time.Duration) *Store {
+	s := New(db)
+
+	pool := &replicaPool{maxLag: maxLag}
+	for i, replicaDB := range replicas {
+		if replicaDB == nil {
+			panic("replica db is nil")
+		}
+		pool.replicas = append(pool.replicas, &replica{index: i, db: replicaDB})
+	}
+	s.replicas = pool
+
+	return s
+}
+
+// MonitorReplicas checks the replication lag of every replica once per
+// interval until ctx is done.
+func (s *Store) MonitorReplicas(ctx context.Context, interval time.Duration) {
+	if s.replicas == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.replicas.check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// queryReport runs a reporting query on a healthy replica, falling back to the
+// primary when there is none or the replica fails.
+func (s *Store) queryReport(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if r := s.replicas.pick(); r != nil {
+		rows, err := r.db.QueryContext(ctx, query, args...)
+		if err == nil || ctx.Err() != nil {
+			return rows, err
+		}
+		// The next health check puts the replica back in rotation.
+		r.setHealthy(false, "query failed", err)
+	}
+
+	return s.db.QueryContext(ctx, query, args...)
+}
+
+// pick returns the next healthy replica in round robin order, nil when there
+// is none.
+func (p *replicaPool) pick() *replica {
+	if p == nil || len(p.replicas) == 0 {
+		return nil
+	}
+
+	start := p.next.Add(1)
+	for i := range p.replicas {
+		r := p.replicas[(start+uint64(i))%uint64(len(p.replicas))]
+		if r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+func (p *replicaPool) check(ctx context.Context) {
+	for _, r := range p.replicas {
+		lag, err := replicationLag(ctx, r.db)
+		switch {
+		case err != nil:
+			r.setHealthy(false, "health check failed", err)
+		case lag > p.maxLag:
+			r.setHealthy(false, "replica lags behind", fmt.Errorf("lag of %s exceeds %s", lag, p.maxLag))
+		default:
+			r.setHealthy(true, "replica is healthy", nil)
+		}
+	}
+}
+
+// setHealthy records the health of the replica, logging when it changes.
+func (r *replica) setHealthy(healthy bool, reason string, err error) {
+	if r.healthy.Swap(healthy) == healthy {
+		return
+	}
+
+	if healthy {
+		slog.Info(reason, "replica", r.index)
+	} else {
+		slog.Warn(reason+", reading from the primary", "replica", r.index, "error", err)
+	}
+}
+
+var errNotReplicating = errors.New("replication is not running")
+
+// replicationLag returns how far the replica is behind its source as reported
+// by SHOW REPLICA STATUS.
+func replicationLag(ctx context.Context, db *sql.DB) (time.Duration, error) {
+	rows, err := db.QueryContext(ctx, "SHOW REPLICA STATUS")
+	if err != nil {
+		return 0, fmt.Errorf("failed to get replica status: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get replica status columns: %w", err)
+	}
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return 0, fmt.Errorf("error iterating rows: %w", err)
+		}
+		return 0, errNotReplicating
+	}
+
+	values := make([]sql.NullString, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return 0, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	for i, column := range columns {
+		if column != "Seconds_Behind_Source" {
+			continue
+		}
+		// NULL means the replication threads are not running.
+		if !values[i].Valid {
+			return 0, errNotReplicating
+		}
+		seconds, err := time.ParseDuration(values[i].String + "s")
+		if err != nil {
+			return 0, fmt.Errorf("invalid Seconds_Behind_Source %q: %w", values[i].String, err)
+		}
+		return seconds, nil
+	}
+
+	return 0, errors.New("replica status has no Seconds_Behind_Source")
+}
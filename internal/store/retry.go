@@ -0,0 +1,126 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+)
+
+// QueryPolicy bounds how long calls of the store take and how often they are
+// tried.
+type QueryPolicy struct {
+	// Timeout bounds every attempt of a call: a query and reading its rows,
+	// or a whole transaction. Zero leaves calls unbounded.
+	Timeout time.Duration
+	// MaxAttempts is how often reads and idempotent writes are tried when
+	// they fail with transient errors, 1 disables retries. Other writes are
+	// tried once, a lost connection leaves open whether they were committed.
+	MaxAttempts int
+}
+
+// DefaultQueryPolicy is the policy of stores until SetQueryPolicy is called.
+var DefaultQueryPolicy = QueryPolicy{
+	Timeout:     30 * time.Second,
+	MaxAttempts: 3,
+}
+
+const (
+	// minRetryBackoff is the wait before the second attempt, it doubles with
+	// every further attempt up to maxRetryBackoff.
+	minRetryBackoff = 50 * time.Millisecond
+	maxRetryBackoff = time.Second
+)
+
+// SetQueryPolicy replaces the query policy of the store, it must be called
+// before the store is used.
+func (s *Store) SetQueryPolicy(policy QueryPolicy) {
+	s.policy = policy
+}
+
+// withTimeout runs fn with the query timeout of the store.
+func (s *Store) withTimeout(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.policy.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.policy.Timeout)
+		defer cancel()
+	}
+	return fn(ctx)
+}
+
+// withRetry is withTimeout for reads and idempotent writes, fn is run again
+// after a backoff when it fails with a transient error. fn must not keep state
+// from a failed attempt.
+func (s *Store) withRetry(ctx context.Context, fn func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		var timedOut bool
+		err := s.withTimeout(ctx, func(ctx context.Context) error {
+			err := fn(ctx)
+			timedOut = ctx.Err() != nil
+			return err
+		})
+		// A call that ran out of time would run out of time again, and the
+		// driver may report the connection it closed as lost.
+		if err == nil || timedOut || attempt >= s.policy.MaxAttempts || !s.dialect.IsTransient(err) {
+			return err
+		}
+
+		backoff := retryBackoff(attempt)
+		slog.Warn("retrying query after transient error", "attempt", attempt, "backoff", backoff, "error", err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// retryBackoff returns the wait after the given failed attempt, with jitter so
+// calls that failed together, like both sides of a deadlock, do not collide
+// again.
+func retryBackoff(attempt int) time.Duration {
+	backoff := maxRetryBackoff
+	if shift := attempt - 1; shift < 16 {
+		backoff = min(minRetryBackoff<<shift, maxRetryBackoff)
+	}
+	return backoff/2 + rand.N(backoff/2+1)
+}
+
+// withTx runs fn in a transaction that is committed when fn succeeds. The
+// transaction is not retried, writes that can safely run twice use
+// withRetryTx.
+func (s *Store) withTx(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	return s.withTimeout(ctx, func(ctx context.Context) error {
+		return s.runTx(ctx, fn)
+	})
+}
+
+// withRetryTx is withTx for idempotent writes, the whole transaction is run
+// again when it fails with a transient error.
+func (s *Store) withRetryTx(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	return s.withRetry(ctx, func(ctx context.Context) error {
+		return s.runTx(ctx, fn)
+	})
+}
+
+func (s *Store) runTx(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(ctx, tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
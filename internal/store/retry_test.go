@@ -0,0 +1,121 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+var errDeadlock = &mysql.MySQLError{Number: mysqlErrDeadlock, Message: "Deadlock found when trying to get lock"}
+
+func TestIsMySQLTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"deadlock", errDeadlock, true},
+		{"lock wait timeout", &mysql.MySQLError{Number: mysqlErrLockWaitTimeout}, true},
+		{"wrapped deadlock", fmt.Errorf("failed to insert bet: %w", errDeadlock), true},
+		{"bad connection", driver.ErrBadConn, true},
+		{"invalid connection", mysql.ErrInvalidConn, true},
+		{"unexpected EOF", io.ErrUnexpectedEOF, true},
+		{"connection reset", &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, true},
+		{"broken pipe", &net.OpError{Op: "write", Net: "tcp", Err: os.NewSyscallError("write", syscall.EPIPE)}, true},
+		{"duplicate entry", &mysql.MySQLError{Number: mysqlErrDuplicateEntry}, false},
+		{"no rows", sql.ErrNoRows, false},
+		{"deadline exceeded", context.DeadlineExceeded, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isMySQLTransient(tt.err))
+		})
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	s := &Store{dialect: MySQL, policy: QueryPolicy{Timeout: time.Second, MaxAttempts: 3}}
+
+	t.Run("RetriesTransientErrors", func(t *testing.T) {
+		calls := 0
+		err := s.withRetry(context.Background(), func(ctx context.Context) error {
+			calls++
+			if _, ok := ctx.Deadline(); !ok {
+				t.Error("attempt has no deadline")
+			}
+			if calls < 3 {
+				return errDeadlock
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("GivesUpAfterMaxAttempts", func(t *testing.T) {
+		calls := 0
+		err := s.withRetry(context.Background(), func(ctx context.Context) error {
+			calls++
+			return driver.ErrBadConn
+		})
+		assert.ErrorIs(t, err, driver.ErrBadConn)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("DoesNotRetryOtherErrors", func(t *testing.T) {
+		calls := 0
+		err := s.withRetry(context.Background(), func(ctx context.Context) error {
+			calls++
+			return sql.ErrNoRows
+		})
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("DoesNotRetryTimedOutAttempts", func(t *testing.T) {
+		s := &Store{dialect: MySQL, policy: QueryPolicy{Timeout: 10 * time.Millisecond, MaxAttempts: 3}}
+
+		calls := 0
+		err := s.withRetry(context.Background(), func(ctx context.Context) error {
+			calls++
+			<-ctx.Done()
+			// The driver closes the connection of a cancelled query.
+			return mysql.ErrInvalidConn
+		})
+		assert.ErrorIs(t, err, mysql.ErrInvalidConn)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("StopsWhenContextIsDone", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		calls := 0
+		err := s.withRetry(ctx, func(context.Context) error {
+			calls++
+			cancel()
+			return errDeadlock
+		})
+		assert.ErrorIs(t, err, errDeadlock)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestRetryBackoff(t *testing.T) {
+	for attempt := 1; attempt <= 100; attempt++ {
+		backoff := retryBackoff(attempt)
+		ceiling := min(minRetryBackoff<<min(attempt-1, 16), maxRetryBackoff)
+		assert.GreaterOrEqual(t, backoff, ceiling/2, "attempt %d", attempt)
+		assert.LessOrEqual(t, backoff, ceiling, "attempt %d", attempt)
+	}
+}
@@ -0,0 +1,324 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+// The country stats rollup holds one row per country, UTC day and bet currency
+// in country_daily_stats and the days players were active in
+// player_activity_days. Every write to bets or players updates it in the same
+// transaction, GetCountryDailyStats and RebuildCountryDailyStats check and
+// repair it.
+
+// ConvertedDailyStats returns a query selecting the rows of
+// country_daily_stats with the amounts converted into the currency the SQL
+// expression currency evaluates to, using the rates effective on the day.
+func ConvertedDailyStats(currency string) string {
+	return convertDailyStats("country_daily_stats", currency)
+}
+
+// ConvertedBetDailyStats is ConvertedDailyStats for the bets inside the window
+// the SQL expressions from and to bound, aggregated per day and currency on
+// the fly. Country stats for windows the rollup can not answer are read from
+// it, so that they are converted and rounded the same way as the rollup.
+func ConvertedBetDailyStats(from, to, currency string) string {
+	return convertDailyStats("("+betDailyStats(from, to)+")", currency)
+}
+
+func convertDailyStats(table, currency string) string {
+	return convertDaily(table,
+		[]string{"country_code", "day", "bet_count", "win_count"},
+		[]string{"total_amount", "settled_stakes", "payouts"},
+		currency)
+}
+
+// betDailyStats aggregates the bets the same way the rollup does, the SQL
+// expressions from and to bound the window and are each used twice. Amounts
+// are rounded to the cent, SQLite sums them as floats.
+func betDailyStats(from, to string) string {
+	return `
+		SELECT
+			p.country_code,
+			DATE(b.created_at) AS day,
+			b.currency,
+			COUNT(*) AS bet_count,
+			ROUND(SUM(b.amount), 2) AS total_amount,
+			ROUND(SUM(CASE WHEN b.status IN ('won', 'lost') THEN b.amount ELSE 0 END), 2) AS settled_stakes,
+			ROUND(SUM(CASE WHEN b.status IN ('won', 'lost') THEN b.payout ELSE 0 END), 2) AS payouts,
+			SUM(CASE WHEN b.status = 'won' THEN 1 ELSE 0 END) AS win_count
+		FROM bets b
+		JOIN players p ON p.id = b.player_id
+		WHERE (` + from + ` IS NULL OR b.created_at >= ` + from + `)
+			AND (` + to + ` IS NULL OR b.created_at < ` + to + `)
+		GROUP BY p.country_code, DATE(b.created_at), b.currency`
+}
+
+// addToRollup adds the bets matching condition to country_daily_stats under
+// the current country of their players. A sign of -1 takes them out again,
+// which leaves rows without bets behind for removeEmptyRollupRows.
+func (s *Store) addToRollup(ctx context.Context, tx *sql.Tx, sign int, condition string, args ...any) error {
+	// The WHERE keeps SQLite from reading the ON of the upsert as a join
+	// constraint.
+	query := fmt.Sprintf(`
+		INSERT INTO country_daily_stats (country_code, day, currency, bet_count, total_amount, settled_stakes, payouts, win_count)
+		SELECT * FROM (
+			SELECT
+				p.country_code,
+				DATE(b.created_at) AS day,
+				b.currency,
+				%[1]d * COUNT(*) AS bet_count,
+				%[1]d * ROUND(SUM(b.amount), 2) AS total_amount,
+				%[1]d * ROUND(SUM(CASE WHEN b.status IN ('won', 'lost') THEN b.amount ELSE 0 END), 2) AS settled_stakes,
+				%[1]d * ROUND(SUM(CASE WHEN b.status IN ('won', 'lost') THEN b.payout ELSE 0 END), 2) AS payouts,
+				%[1]d * SUM(CASE WHEN b.status = 'won' THEN 1 ELSE 0 END) AS win_count
+			FROM bets b
+			JOIN players p ON p.id = b.player_id
+			WHERE %[2]s
+			GROUP BY p.country_code, DATE(b.created_at), b.currency
+		) delta
+		WHERE TRUE`, sign, condition) + s.dialect.UpsertDailyStats
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to update country stats rollup: %w", err)
+	}
+	return nil
+}
+
+func removeEmptyRollupRows(ctx context.Context, tx *sql.Tx, countryCode string) error {
+	query := "DELETE FROM country_daily_stats WHERE country_code = ? AND bet_count = 0"
+
+	if _, err := tx.ExecContext(ctx, query, countryCode); err != nil {
+		return fmt.Errorf("failed to update country stats rollup: %w", err)
+	}
+	return nil
+}
+
+// addBetToRollup adds a newly placed bet to both rollup tables.
+func (s *Store) addBetToRollup(ctx context.Context, tx *sql.Tx, betID int64) error {
+	if err := s.addToRollup(ctx, tx, 1, "b.id = ?", betID); err != nil {
+		return err
+	}
+
+	query := s.dialect.InsertIgnore + " INTO player_activity_days (player_id, day) SELECT player_id, DATE(created_at) FROM bets WHERE id = ?"
+	if _, err := tx.ExecContext(ctx, query, betID); err != nil {
+		return fmt.Errorf("failed to update player activity: %w", err)
+	}
+	return nil
+}
+
+// rollupWindow reports whether the rollup can answer a window, it only can
+// when both bounds are whole UTC days.
+func rollupWindow(from, to time.Time) bool {
+	return isUTCMidnight(from) && isUTCMidnight(to)
+}
+
+func isUTCMidnight(t time.Time) bool {
+	return t.IsZero() || t.Equal(t.UTC().Truncate(24*time.Hour))
+}
+
+func nullDate(t time.Time) sql.NullString {
+	if t.IsZero() {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: t.UTC().Format(time.DateOnly), Valid: true}
+}
+
+// getCountryStatsFromRollup is GetCountryStats for windows rollupWindow
+// accepts.
+func (s *Store) getCountryStatsFromRollup(ctx context.Context, q domain.GetCountryStatsQuery) (*domain.GetCountryStatsResult, error) {
+	from, to := nullDate(q.From), nullDate(q.To)
+
+	codes := countryCodeArgs(q.CountryCodes)
+
+	// Without a window every registered player is counted, with one only the
+	// players that were active in it.
+	playerCounts := registeredPlayerCounts(len(codes))
+	playerArgs := codes
+	if from.Valid || to.Valid {
+		playerCounts = `
+			SELECT players.country_code, COUNT(DISTINCT activity.player_id) AS player_count
+			FROM player_activity_days activity
+			JOIN players ON players.id = activity.player_id
+			WHERE (? IS NULL OR activity.day >= ?)
+				AND (? IS NULL OR activity.day < ?)
+				AND players.country_code IN (` + placeholders(len(codes)) + `)
+			GROUP BY players.country_code`
+		playerArgs = append([]any{from, from, to, to}, codes...)
+	}
+
+	dailyStats := `
+		SELECT * FROM (` + ConvertedDailyStats("?") + `) converted
+		WHERE (? IS NULL OR day >= ?)
+			AND (? IS NULL OR day < ?)`
+
+	args := append([]any{}, playerArgs...)
+	args = append(args, q.Currency, from, from, to, to)
+	args = append(args, codes...)
+
+	return s.queryCountryStats(ctx, countryStatsQuery(playerCounts, dailyStats, len(codes)), args...)
+}
+
+func (s *Store) GetCountryDailyStats(ctx context.Context, q domain.GetCountryDailyStatsQuery) (*domain.GetCountryDailyStatsResult, error) {
+	if !rollupWindow(q.From, q.To) {
+		return nil, fmt.Errorf("rollup window must start and end at midnight UTC: %w", domain.ErrInvalidArgument)
+	}
+
+	from, to := nullDate(q.From), nullDate(q.To)
+	result := &domain.GetCountryDailyStatsResult{}
+
+	err := s.withRetry(ctx, func(ctx context.Context) error {
+		return getCountryDailyStats(ctx, s.db, from, to, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func getCountryDailyStats(ctx context.Context, db *sql.DB, from, to sql.NullString, result *domain.GetCountryDailyStatsResult) error {
+	// Both sides are read in one transaction so bets placed in between can not
+	// show up as differences.
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT country_code, day, currency, bet_count, total_amount, settled_stakes, payouts, win_count
+		FROM country_daily_stats
+		WHERE (? IS NULL OR day >= ?)
+			AND (? IS NULL OR day < ?)
+		ORDER BY country_code, day, currency`
+	if result.RollupStats, err = queryDailyStats(ctx, tx, query, from, from, to, to); err != nil {
+		return err
+	}
+
+	query = betDailyStats("?", "?") + " ORDER BY 1, 2, 3"
+	if result.BetStats, err = queryDailyStats(ctx, tx, query, from, from, to, to); err != nil {
+		return err
+	}
+
+	query = `
+		SELECT players.country_code, activity.day, COUNT(*)
+		FROM player_activity_days activity
+		JOIN players ON players.id = activity.player_id
+		WHERE (? IS NULL OR activity.day >= ?)
+			AND (? IS NULL OR activity.day < ?)
+		GROUP BY players.country_code, activity.day
+		ORDER BY 1, 2`
+	if result.RollupActivity, err = queryDailyActivity(ctx, tx, query, from, from, to, to); err != nil {
+		return err
+	}
+
+	query = `
+		SELECT p.country_code, DATE(b.created_at), COUNT(DISTINCT b.player_id)
+		FROM bets b
+		JOIN players p ON p.id = b.player_id
+		WHERE (? IS NULL OR b.created_at >= ?)
+			AND (? IS NULL OR b.created_at < ?)
+		GROUP BY p.country_code, DATE(b.created_at)
+		ORDER BY 1, 2`
+	if result.BetActivity, err = queryDailyActivity(ctx, tx, query, from, from, to, to); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) RebuildCountryDailyStats(ctx context.Context, q domain.RebuildCountryDailyStatsQuery) error {
+	if !rollupWindow(q.From, q.To) {
+		return fmt.Errorf("rollup window must start and end at midnight UTC: %w", domain.ErrInvalidArgument)
+	}
+
+	from, to := nullDate(q.From), nullDate(q.To)
+
+	statements := []string{
+		"DELETE FROM country_daily_stats WHERE (? IS NULL OR day >= ?) AND (? IS NULL OR day < ?)",
+		"INSERT INTO country_daily_stats (country_code, day, currency, bet_count, total_amount, settled_stakes, payouts, win_count)" + betDailyStats("?", "?"),
+		"DELETE FROM player_activity_days WHERE (? IS NULL OR day >= ?) AND (? IS NULL OR day < ?)",
+		`INSERT INTO player_activity_days (player_id, day)
+			SELECT DISTINCT player_id, DATE(created_at) FROM bets
+			WHERE (? IS NULL OR created_at >= ?) AND (? IS NULL OR created_at < ?)`,
+	}
+
+	// The window is replaced as a whole, so the rebuild can be retried.
+	return s.withRetryTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		for _, statement := range statements {
+			if _, err := tx.ExecContext(ctx, statement, from, from, to, to); err != nil {
+				return fmt.Errorf("failed to rebuild country stats rollup: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+func queryDailyStats(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]domain.CountryDailyStats, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query country daily stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []domain.CountryDailyStats{}
+	for rows.Next() {
+		var (
+			stat domain.CountryDailyStats
+			day  nullTimestamp
+		)
+		err := rows.Scan(
+			&stat.CountryCode,
+			&day,
+			&stat.Currency,
+			&stat.BetCount,
+			&stat.TotalAmount,
+			&stat.SettledStakes,
+			&stat.Payouts,
+			&stat.WinCount,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		stat.Day = day.Time
+		stats = append(stats, stat)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return stats, nil
+}
+
+func queryDailyActivity(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]domain.CountryDailyActivity, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query country daily activity: %w", err)
+	}
+	defer rows.Close()
+
+	activity := []domain.CountryDailyActivity{}
+	for rows.Next() {
+		var (
+			a   domain.CountryDailyActivity
+			day nullTimestamp
+		)
+		if err := rows.Scan(&a.CountryCode, &day, &a.ActivePlayers); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		a.Day = day.Time
+		activity = append(activity, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return activity, nil
+}
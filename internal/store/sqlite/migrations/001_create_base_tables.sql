@@ -0,0 +1,24 @@
+-- Amounts and rates are REAL since SQLite has no decimal type, the store rounds
+-- them back to the cent. Timestamps are UTC text in the format of
+-- CURRENT_TIMESTAMP.
+CREATE TABLE players (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    country_code TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_players_country_code ON players(country_code);
+CREATE INDEX idx_players_email ON players(email);
+
+CREATE TABLE bets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    player_id INTEGER NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    amount REAL NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_bets_player_id ON bets(player_id);
+CREATE INDEX idx_bets_created_at ON bets(created_at);
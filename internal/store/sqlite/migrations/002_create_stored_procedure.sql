@@ -0,0 +1,2 @@
+-- SQLite has no stored procedures, the top countries query is part of the
+-- dialect of package sqlite.
@@ -0,0 +1,3 @@
+ALTER TABLE bets ADD COLUMN idempotency_key TEXT NULL;
+
+CREATE UNIQUE INDEX idx_bets_idempotency_key ON bets(idempotency_key);
@@ -0,0 +1,28 @@
+ALTER TABLE bets ADD COLUMN currency TEXT NOT NULL DEFAULT 'EUR';
+
+-- rate is the amount of currency one EUR buys, so EUR itself is always 1. A rate
+-- applies from effective_from until the next rate of the same currency.
+CREATE TABLE exchange_rates (
+    currency TEXT NOT NULL,
+    effective_from DATE NOT NULL,
+    rate REAL NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (currency, effective_from)
+);
+
+-- Every exchange rate as a [valid_from, valid_to) period, NULL leaves that side
+-- open. The first rate of a currency also covers the time before it, so every
+-- bet in a currency with a rate can be converted.
+CREATE VIEW exchange_rate_periods AS
+SELECT
+    currency,
+    rate,
+    CASE WHEN ROW_NUMBER() OVER w = 1 THEN NULL ELSE effective_from END AS valid_from,
+    LEAD(effective_from) OVER w AS valid_to
+FROM exchange_rates
+WINDOW w AS (PARTITION BY currency ORDER BY effective_from);
+
+INSERT INTO exchange_rates (currency, effective_from, rate) VALUES
+('EUR', '2000-01-01', 1),
+('RSD', '2000-01-01', 117.17),
+('BRL', '2000-01-01', 6.32);
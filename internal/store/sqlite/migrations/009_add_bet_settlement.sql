@@ -0,0 +1,5 @@
+-- payout is what a won bet paid out, in the currency of the bet. It stays 0 for
+-- open, lost and void bets.
+ALTER TABLE bets ADD COLUMN status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'won', 'lost', 'void'));
+ALTER TABLE bets ADD COLUMN payout REAL NOT NULL DEFAULT 0;
+ALTER TABLE bets ADD COLUMN settled_at TIMESTAMP NULL;
@@ -0,0 +1,40 @@
+-- Per country, day and bet currency aggregates of bets, see the MySQL
+-- migration.
+CREATE TABLE country_daily_stats (
+    country_code TEXT NOT NULL,
+    day DATE NOT NULL,
+    currency TEXT NOT NULL,
+    bet_count INTEGER NOT NULL,
+    total_amount REAL NOT NULL,
+    settled_stakes REAL NOT NULL,
+    payouts REAL NOT NULL,
+    win_count INTEGER NOT NULL,
+    PRIMARY KEY (country_code, day, currency)
+);
+
+CREATE INDEX idx_country_daily_stats_day ON country_daily_stats(day);
+
+CREATE TABLE player_activity_days (
+    player_id INTEGER NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    PRIMARY KEY (player_id, day)
+);
+
+CREATE INDEX idx_player_activity_days_day ON player_activity_days(day);
+
+INSERT INTO country_daily_stats (country_code, day, currency, bet_count, total_amount, settled_stakes, payouts, win_count)
+SELECT
+    p.country_code,
+    DATE(b.created_at),
+    b.currency,
+    COUNT(*),
+    ROUND(SUM(b.amount), 2),
+    ROUND(SUM(CASE WHEN b.status IN ('won', 'lost') THEN b.amount ELSE 0 END), 2),
+    ROUND(SUM(CASE WHEN b.status IN ('won', 'lost') THEN b.payout ELSE 0 END), 2),
+    SUM(CASE WHEN b.status = 'won' THEN 1 ELSE 0 END)
+FROM bets b
+JOIN players p ON p.id = b.player_id
+GROUP BY p.country_code, DATE(b.created_at), b.currency;
+
+INSERT INTO player_activity_days (player_id, day)
+SELECT DISTINCT player_id, DATE(created_at) FROM bets;
@@ -0,0 +1,20 @@
+-- erased_at is set once a player's personal data was anonymized on request.
+ALTER TABLE players ADD COLUMN erased_at TIMESTAMP NULL;
+
+-- Log of erasure requests, without a foreign key so it outlives the players it
+-- lists.
+CREATE TABLE player_erasures (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    player_id INTEGER NOT NULL,
+    requested_by TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    erased_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_player_erasures_player_id ON player_erasures(player_id);
+
+CREATE TRIGGER player_erasures_no_update BEFORE UPDATE ON player_erasures
+BEGIN SELECT RAISE(ABORT, 'player_erasures is append-only'); END;
+
+CREATE TRIGGER player_erasures_no_delete BEFORE DELETE ON player_erasures
+BEGIN SELECT RAISE(ABORT, 'player_erasures is append-only'); END;
@@ -0,0 +1,28 @@
+-- Append-only log of every change of a player or bet, see the MySQL migration.
+-- The values are JSON text.
+CREATE TABLE audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    entity_type TEXT NOT NULL,
+    entity_id INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    before_values TEXT NULL,
+    after_values TEXT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    redacted_at TIMESTAMP NULL
+);
+
+CREATE INDEX idx_audit_log_entity ON audit_log(entity_type, entity_id);
+CREATE INDEX idx_audit_log_actor ON audit_log(actor);
+CREATE INDEX idx_audit_log_created_at ON audit_log(created_at);
+
+-- Entries can not be deleted, and the only update is redacting the values of
+-- an entry once.
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+WHEN OLD.redacted_at IS NOT NULL OR NEW.redacted_at IS NULL
+    OR NEW.id IS NOT OLD.id OR NEW.entity_type IS NOT OLD.entity_type OR NEW.entity_id IS NOT OLD.entity_id
+    OR NEW.action IS NOT OLD.action OR NEW.actor IS NOT OLD.actor OR NEW.created_at IS NOT OLD.created_at
+BEGIN SELECT RAISE(ABORT, 'audit_log is append-only'); END;
+
+CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log
+BEGIN SELECT RAISE(ABORT, 'audit_log is append-only'); END;
@@ -0,0 +1,16 @@
+-- UK players move to GB, see the MySQL migration. The WHERE keeps SQLite from
+-- reading the ON of the upsert as a join constraint.
+INSERT INTO country_daily_stats (country_code, day, currency, bet_count, total_amount, settled_stakes, payouts, win_count)
+SELECT 'GB', day, currency, bet_count, total_amount, settled_stakes, payouts, win_count
+FROM country_daily_stats
+WHERE country_code = 'UK'
+ON CONFLICT (country_code, day, currency) DO UPDATE SET
+    bet_count = bet_count + excluded.bet_count,
+    total_amount = ROUND(total_amount + excluded.total_amount, 2),
+    settled_stakes = ROUND(settled_stakes + excluded.settled_stakes, 2),
+    payouts = ROUND(payouts + excluded.payouts, 2),
+    win_count = win_count + excluded.win_count;
+
+DELETE FROM country_daily_stats WHERE country_code = 'UK';
+
+UPDATE players SET country_code = 'GB' WHERE country_code = 'UK';
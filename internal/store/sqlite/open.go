@@ -0,0 +1,125 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// migrations are the SQLite twins of the MySQL migrations, one per migration
+// and named after it. PRAGMA user_version holds the number of the last one
+// applied.
+//
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Open opens the SQLite database at path and applies the migrations it is
+// missing, a new database gets the schema and seed data of the MySQL
+// migrations. ":memory:" opens a database that lives as long as the returned
+// *sql.DB.
+func Open(ctx context.Context, path string) (*sql.DB, error) {
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	// Transactions read before they write, taking the write lock up front
+	// makes concurrent writers wait instead of failing.
+	params.Set("_txlock", "immediate")
+
+	memory := path == ":memory:"
+	if !memory {
+		params.Add("_pragma", "journal_mode(WAL)")
+	}
+
+	db, err := sql.Open("sqlite", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if memory {
+		// Every connection to :memory: gets a database of its own.
+		db.SetMaxOpenConns(1)
+	}
+
+	if err := migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func migrate(ctx context.Context, db *sql.DB) error {
+	// Glob returns the migrations in the order of their names.
+	files, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	// The transaction takes the write lock before the version is read, so only
+	// one of several processes opening a new database applies the migrations.
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var version int
+	if err := tx.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	last, err := migrationNumber(files[len(files)-1])
+	if err != nil {
+		return err
+	}
+	if version > last {
+		return fmt.Errorf("unsupported schema version %d", version)
+	}
+	if version == last {
+		return nil
+	}
+
+	for _, file := range files {
+		number, err := migrationNumber(file)
+		if err != nil {
+			return err
+		}
+		if number <= version {
+			continue
+		}
+
+		migration, err := fs.ReadFile(migrations, file)
+		if err != nil {
+			return fmt.Errorf("failed to read migration %s: %w", file, err)
+		}
+		if _, err := tx.ExecContext(ctx, string(migration)); err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", file, err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", last)); err != nil {
+		return fmt.Errorf("failed to set schema version: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// migrationNumber returns the number the name of a migration starts with.
+func migrationNumber(file string) (int, error) {
+	prefix, _, _ := strings.Cut(path.Base(file), "_")
+	number, err := strconv.Atoi(prefix)
+	if err != nil {
+		return 0, fmt.Errorf("invalid migration name %s", file)
+	}
+	return number, nil
+}
@@ -0,0 +1,104 @@
+package sqlite
+
+import (
+	"context"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mysqlmigrations "github.com/Nikola-Milovic/vyking-interview/migrations"
+)
+
+var (
+	createTable  = regexp.MustCompile(`(?s)CREATE TABLE (?:IF NOT EXISTS )?(\w+) \((.*?)\n\);`)
+	alterTable   = regexp.MustCompile(`(?s)ALTER TABLE (\w+)(.*?);`)
+	addColumn    = regexp.MustCompile(`ADD COLUMN (\w+)`)
+	columnName   = regexp.MustCompile(`^(\w+)\s`)
+	createObject = regexp.MustCompile(`CREATE (?:UNIQUE )?(INDEX|VIEW|TRIGGER) (\w+)`)
+)
+
+// notColumns start the lines of a CREATE TABLE that define constraints.
+var notColumns = map[string]bool{
+	"PRIMARY": true, "FOREIGN": true, "UNIQUE": true, "KEY": true, "INDEX": true, "CONSTRAINT": true,
+}
+
+// TestMigrationsMatchMySQL checks that the SQLite migrations twin the MySQL
+// ones and build the tables, columns, indexes, views and triggers they do.
+func TestMigrationsMatchMySQL(t *testing.T) {
+	upMigrations, err := fs.Glob(mysqlmigrations.FS, "*.up.sql")
+	require.NoError(t, err)
+	twins, err := fs.Glob(migrations, "migrations/*.sql")
+	require.NoError(t, err)
+
+	var want, got []string
+	for _, name := range upMigrations {
+		want = append(want, strings.TrimSuffix(name, ".up.sql"))
+	}
+	for _, name := range twins {
+		got = append(got, strings.TrimSuffix(path.Base(name), ".sql"))
+	}
+	require.Equal(t, want, got, "every MySQL migration needs an SQLite twin of the same name")
+
+	columns := map[string][]string{}
+	objects := map[string]string{}
+	for _, name := range upMigrations {
+		migration, err := fs.ReadFile(mysqlmigrations.FS, name)
+		require.NoError(t, err)
+		sql := string(migration)
+
+		for _, m := range createTable.FindAllStringSubmatch(sql, -1) {
+			for _, line := range strings.Split(m[2], "\n") {
+				c := columnName.FindStringSubmatch(strings.TrimSpace(line) + " ")
+				if c != nil && !notColumns[strings.ToUpper(c[1])] {
+					columns[m[1]] = append(columns[m[1]], c[1])
+				}
+			}
+		}
+		for _, m := range alterTable.FindAllStringSubmatch(sql, -1) {
+			for _, c := range addColumn.FindAllStringSubmatch(m[2], -1) {
+				columns[m[1]] = append(columns[m[1]], c[1])
+			}
+		}
+		for _, m := range createObject.FindAllStringSubmatch(sql, -1) {
+			objects[m[2]] = strings.ToLower(m[1])
+		}
+	}
+
+	db, err := Open(context.Background(), filepath.Join(t.TempDir(), "schema_test.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	gotColumns := map[string][]string{}
+	for table := range columns {
+		rows, err := db.Query("SELECT name FROM pragma_table_info(?) ORDER BY cid", table)
+		require.NoError(t, err)
+		for rows.Next() {
+			var name string
+			require.NoError(t, rows.Scan(&name))
+			gotColumns[table] = append(gotColumns[table], name)
+		}
+		require.NoError(t, rows.Err())
+		rows.Close()
+	}
+	assert.Equal(t, columns, gotColumns)
+
+	gotObjects := map[string]string{}
+	rows, err := db.Query(`
+		SELECT type, name FROM sqlite_master
+		WHERE type IN ('index', 'view', 'trigger') AND name NOT LIKE 'sqlite_autoindex_%'`)
+	require.NoError(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var typ, name string
+		require.NoError(t, rows.Scan(&typ, &name))
+		gotObjects[name] = typ
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, objects, gotObjects)
+}
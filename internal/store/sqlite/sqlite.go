@@ -0,0 +1,188 @@
+// Package sqlite runs the store on an embedded SQLite database, so the service
+// can run without MySQL. The queries are the ones of package store, this
+// package only provides the SQL that has no form both databases understand and
+// the schema of the MySQL migrations.
+package sqlite
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/store"
+)
+
+// Dialect is the SQLite store.Dialect. Its top countries queries number their
+// placeholders, as SQLite has no stored procedures to bind each of them once.
+var Dialect = store.Dialect{
+	// SQLite locks the whole database for the transaction, Open begins every
+	// transaction with the write lock.
+	LockRow:      "",
+	InsertIgnore: "INSERT OR IGNORE",
+	// The amounts are REAL, rounding keeps them at the cent like MySQL's
+	// DECIMAL columns.
+	UpsertDailyStats: `
+		ON CONFLICT (country_code, day, currency) DO UPDATE SET
+			bet_count = bet_count + excluded.bet_count,
+			total_amount = ROUND(total_amount + excluded.total_amount, 2),
+			settled_stakes = ROUND(settled_stakes + excluded.settled_stakes, 2),
+			payouts = ROUND(payouts + excluded.payouts, 2),
+			win_count = win_count + excluded.win_count`,
+	JSONTable:              jsonTable,
+	TopCountries:           topCountries(betCountryTotals),
+	TopCountriesFromRollup: topCountries(rollupCountryTotals),
+	IsDuplicateEntry:       isDuplicateEntry,
+	IsForeignKeyViolation:  isForeignKeyViolation,
+	// The busy timeout Open sets makes writers wait for the lock instead of
+	// failing, there is nothing left to retry.
+	IsTransient: func(error) bool { return false },
+}
+
+// New returns a store for a database opened with Open.
+func New(db *sql.DB) *store.Store {
+	return store.NewWithDialect(db, Dialect)
+}
+
+func jsonTable(columns ...store.JSONColumn) string {
+	selected := []string{"key + 1 AS idx"}
+	for _, c := range columns {
+		selected = append(selected, "json_extract(value, '"+c.Path+"') AS "+c.Name)
+	}
+	return "(SELECT " + strings.Join(selected, ", ") + " FROM json_each(?))"
+}
+
+// betCountryTotals sums up the bets of every country the way the
+// GetTopCountriesByPlayerActivity procedure does, per day and currency like
+// the rollup. Without a window every registered player is counted, with one
+// only players that placed a bet inside the window are.
+var betCountryTotals = `
+	SELECT
+		p.country_code,
+		p.player_count,
+		d.total,
+		d.bet_count,
+		d.settled_stakes,
+		d.payouts,
+		d.win_count
+	FROM (
+		SELECT country_code, COUNT(*) AS player_count
+		FROM players
+		WHERE ?2 IS NULL AND ?3 IS NULL
+		GROUP BY country_code
+		UNION ALL
+		SELECT players.country_code, COUNT(DISTINCT bets.player_id)
+		FROM bets
+		JOIN players ON players.id = bets.player_id
+		WHERE (?2 IS NOT NULL OR ?3 IS NOT NULL)
+			AND (?2 IS NULL OR bets.created_at >= ?2)
+			AND (?3 IS NULL OR bets.created_at < ?3)
+		GROUP BY players.country_code
+	) p
+	LEFT JOIN (
+		SELECT
+			country_code,
+			SUM(total_amount) AS total,
+			SUM(bet_count) AS bet_count,
+			SUM(settled_stakes) AS settled_stakes,
+			SUM(payouts) AS payouts,
+			SUM(win_count) AS win_count
+		FROM (` + store.ConvertedBetDailyStats("?2", "?3", "?9") + `) converted
+		GROUP BY country_code
+	) d ON d.country_code = p.country_code`
+
+// rollupCountryTotals is betCountryTotals read from the rollup, the same as
+// the GetTopCountriesByPlayerActivityFromRollup procedure. The window is whole
+// days, so its bounds are compared as dates.
+var rollupCountryTotals = `
+	SELECT
+		p.country_code,
+		p.player_count,
+		d.total,
+		d.bet_count,
+		d.settled_stakes,
+		d.payouts,
+		d.win_count
+	FROM (
+		SELECT country_code, COUNT(*) AS player_count
+		FROM players
+		WHERE ?2 IS NULL AND ?3 IS NULL
+		GROUP BY country_code
+		UNION ALL
+		SELECT players.country_code, COUNT(DISTINCT activity.player_id)
+		FROM player_activity_days activity
+		JOIN players ON players.id = activity.player_id
+		WHERE (?2 IS NOT NULL OR ?3 IS NOT NULL)
+			AND (?2 IS NULL OR activity.day >= DATE(?2))
+			AND (?3 IS NULL OR activity.day < DATE(?3))
+		GROUP BY players.country_code
+	) p
+	LEFT JOIN (
+		SELECT
+			country_code,
+			SUM(total_amount) AS total,
+			SUM(bet_count) AS bet_count,
+			SUM(settled_stakes) AS settled_stakes,
+			SUM(payouts) AS payouts,
+			SUM(win_count) AS win_count
+		FROM (` + store.ConvertedDailyStats("?9") + `) converted
+		WHERE (?2 IS NULL OR day >= DATE(?2))
+			AND (?3 IS NULL OR day < DATE(?3))
+		GROUP BY country_code
+	) d ON d.country_code = p.country_code`
+
+// topCountries ranks the totals of one of the queries above like the
+// procedures do. Sums of REAL amounts are rounded to the cent and the average
+// to six places like MySQL divides decimals, so that values read back from
+// cursors compare equal. The after placeholders hold the ranking key of the
+// last row of the previous page, only rows ranked strictly after it are
+// returned.
+func topCountries(totals string) string {
+	return `
+		SELECT
+			s.country_code,
+			s.player_count,
+			s.total_bets,
+			s.avg_bet_per_player,
+			s.bet_count,
+			s.settled_stakes,
+			s.ggr,
+			s.win_count
+		FROM (
+			SELECT
+				a.*,
+				CASE ?4
+					WHEN 'total_bets' THEN a.total_bets
+					WHEN 'avg_bet_per_player' THEN a.avg_bet_per_player
+					WHEN 'bet_count' THEN a.bet_count
+					ELSE a.player_count
+				END AS sort_value
+			FROM (
+				SELECT
+					country_code,
+					player_count,
+					ROUND(COALESCE(total, 0), 2) AS total_bets,
+					ROUND(COALESCE(total / player_count, 0), 6) AS avg_bet_per_player,
+					COALESCE(bet_count, 0) AS bet_count,
+					ROUND(COALESCE(settled_stakes, 0), 2) AS settled_stakes,
+					ROUND(COALESCE(settled_stakes - payouts, 0), 2) AS ggr,
+					COALESCE(win_count, 0) AS win_count
+				FROM (` + totals + `) totals
+			) a
+		) s
+		WHERE
+			?8 IS NULL
+			OR (?5 = 'asc' AND s.sort_value > CAST(?6 AS REAL))
+			OR (?5 = 'desc' AND s.sort_value < CAST(?6 AS REAL))
+			OR (
+				s.sort_value = CAST(?6 AS REAL)
+				AND (
+					s.total_bets < CAST(?7 AS REAL)
+					OR (s.total_bets = CAST(?7 AS REAL) AND s.country_code > ?8)
+				)
+			)
+		ORDER BY
+			CASE WHEN ?5 = 'asc' THEN s.sort_value END ASC,
+			CASE WHEN ?5 = 'desc' THEN s.sort_value END DESC,
+			s.total_bets DESC,
+			s.country_code ASC
+		LIMIT ?1`
+}
@@ -0,0 +1,16 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/store/sqlite"
+	"github.com/Nikola-Milovic/vyking-interview/internal/store/storetest"
+	"github.com/Nikola-Milovic/vyking-interview/internal/testutil"
+)
+
+func TestStore(t *testing.T) {
+	db, cleanup := testutil.SetupSQLite(t)
+	defer cleanup()
+
+	storetest.Run(t, sqlite.New(db))
+}
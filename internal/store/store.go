@@ -4,51 +4,358 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
 )
 
 type Store struct {
-	db *sql.DB
+	db      *sql.DB
+	dialect Dialect
+	// replicas serve reporting queries, nil when there are none.
+	replicas *replicaPool
+	policy   QueryPolicy
 }
 
+// New returns a Store for a MySQL database.
 func New(db *sql.DB) *Store {
+	return NewWithDialect(db, MySQL)
+}
+
+// NewWithDialect returns a Store for a database of another dialect.
+func NewWithDialect(db *sql.DB, dialect Dialect) *Store {
 	if db == nil {
 		panic("db is nil")
 	}
 
-	return &Store{db: db}
+	return &Store{db: db, dialect: dialect, policy: DefaultQueryPolicy}
 }
 
 func (s *Store) GetTopCountriesByPlayerActivity(ctx context.Context, q domain.GetTopCountriesByPlayerActivityQuery) (*domain.GetTopCountriesByPlayerActivityResult, error) {
-	query := "CALL GetTopCountriesByPlayerActivity(?)"
+	// The rollup only has whole days, any other window is aggregated from the
+	// bets.
+	query := s.dialect.TopCountries
+	if rollupWindow(q.From, q.To) {
+		query = s.dialect.TopCountriesFromRollup
+	}
 
-	rows, err := s.db.QueryContext(ctx, query, q.Limit)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute stored procedure: %w", err)
-	}
-	defer rows.Close()
-
-	var stats []domain.CountryPlayerStats
-	for rows.Next() {
-		var stat domain.CountryPlayerStats
-		err := rows.Scan(
-			&stat.CountryCode,
-			&stat.PlayerCount,
-			&stat.TotalBets,
-			&stat.AvgBetPerPlayer,
+	var afterSortValue, afterTotalBets, afterCountryCode sql.NullString
+	if q.After != nil {
+		afterSortValue = sql.NullString{String: q.After.SortValue, Valid: true}
+		afterTotalBets = sql.NullString{String: q.After.TotalBets, Valid: true}
+		afterCountryCode = sql.NullString{String: q.After.CountryCode, Valid: true}
+	}
+
+	var (
+		stats []domain.CountryPlayerStats
+		keys  []countryStatsKey
+	)
+	err := s.withRetry(ctx, func(ctx context.Context) error {
+		// One extra row is fetched to find out whether there is a next page.
+		rows, err := s.queryReport(ctx, query,
+			q.Limit+1,
+			nullTime(q.From),
+			nullTime(q.To),
+			string(q.SortBy),
+			string(q.Order),
+			afterSortValue,
+			afterTotalBets,
+			afterCountryCode,
+			q.Currency,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
+			return fmt.Errorf("failed to query top countries: %w", err)
 		}
-		stats = append(stats, stat)
-	}
+		defer rows.Close()
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating rows: %w", err)
+		stats, keys = nil, nil
+		for rows.Next() {
+			stat, key, err := scanCountryStats(rows)
+			if err != nil {
+				return err
+			}
+			stats = append(stats, stat)
+			keys = append(keys, key)
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating rows: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return &domain.GetTopCountriesByPlayerActivityResult{
+	result := &domain.GetTopCountriesByPlayerActivityResult{
 		Stats: stats,
-	}, nil
+	}
+
+	if len(stats) > q.Limit {
+		result.Stats = stats[:q.Limit]
+		last := keys[q.Limit-1]
+		result.Next = &domain.CountryStatsCursor{
+			SortBy:      q.SortBy,
+			Order:       q.Order,
+			SortValue:   last.sortValue(q.SortBy),
+			TotalBets:   last.totalBets,
+			CountryCode: stats[q.Limit-1].CountryCode,
+			Currency:    q.Currency,
+		}
+	}
+
+	return result, nil
+}
+
+// GetCountryStats aggregates the given countries the same way the
+// GetTopCountriesByPlayerActivity procedure does, reading the rollup for
+// windows of whole days.
+func (s *Store) GetCountryStats(ctx context.Context, q domain.GetCountryStatsQuery) (*domain.GetCountryStatsResult, error) {
+	if len(q.CountryCodes) == 0 {
+		return &domain.GetCountryStatsResult{
+			Stats: []domain.CountryPlayerStats{},
+		}, nil
+	}
+	if rollupWindow(q.From, q.To) {
+		return s.getCountryStatsFromRollup(ctx, q)
+	}
+
+	from, to := nullTime(q.From), nullTime(q.To)
+	codes := countryCodeArgs(q.CountryCodes)
+
+	// The bets are summed up per day and currency and converted like the
+	// rollup, so that a window gets the same totals whichever path reads it.
+	playerCounts := registeredPlayerCounts(len(codes))
+	playerArgs := codes
+	if from.Valid || to.Valid {
+		playerCounts = `
+			SELECT players.country_code, COUNT(DISTINCT bets.player_id) AS player_count
+			FROM bets
+			JOIN players ON players.id = bets.player_id
+			WHERE (? IS NULL OR bets.created_at >= ?)
+				AND (? IS NULL OR bets.created_at < ?)
+				AND players.country_code IN (` + placeholders(len(codes)) + `)
+			GROUP BY players.country_code`
+		playerArgs = append([]any{from, from, to, to}, codes...)
+	}
+
+	args := append([]any{}, playerArgs...)
+	args = append(args, from, from, to, to, q.Currency)
+	args = append(args, codes...)
+
+	return s.queryCountryStats(ctx, countryStatsQuery(playerCounts, ConvertedBetDailyStats("?", "?", "?"), len(codes)), args...)
+}
+
+// countryStatsQuery returns the query of GetCountryStats for the player counts
+// of the countries and their daily stats inside the window, converted into
+// the requested currency. The placeholders of the country codes follow those
+// of both queries.
+func countryStatsQuery(playerCounts, dailyStats string, codeCount int) string {
+	return `
+		SELECT
+			p.country_code,
+			p.player_count,
+			COALESCE(d.total, 0),
+			COALESCE(d.total / p.player_count, 0),
+			COALESCE(d.bet_count, 0),
+			COALESCE(d.settled_stakes, 0),
+			COALESCE(d.settled_stakes - d.payouts, 0),
+			COALESCE(d.win_count, 0)
+		FROM (` + playerCounts + `) p
+		LEFT JOIN (
+			SELECT
+				country_code,
+				SUM(total_amount) AS total,
+				SUM(bet_count) AS bet_count,
+				SUM(settled_stakes) AS settled_stakes,
+				SUM(payouts) AS payouts,
+				SUM(win_count) AS win_count
+			FROM (` + dailyStats + `) daily
+			WHERE country_code IN (` + placeholders(codeCount) + `)
+			GROUP BY country_code
+		) d ON d.country_code = p.country_code
+		ORDER BY p.country_code`
+}
+
+// registeredPlayerCounts counts every registered player of the countries the
+// placeholders hold.
+func registeredPlayerCounts(codeCount int) string {
+	return `
+		SELECT country_code, COUNT(*) AS player_count
+		FROM players
+		WHERE country_code IN (` + placeholders(codeCount) + `)
+		GROUP BY country_code`
+}
+
+func countryCodeArgs(countryCodes []string) []any {
+	codes := make([]any, 0, len(countryCodes))
+	for _, code := range countryCodes {
+		codes = append(codes, code)
+	}
+	return codes
+}
+
+func (s *Store) queryCountryStats(ctx context.Context, query string, args ...any) (*domain.GetCountryStatsResult, error) {
+	result := &domain.GetCountryStatsResult{}
+
+	err := s.withRetry(ctx, func(ctx context.Context) error {
+		rows, err := s.queryReport(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to query country stats: %w", err)
+		}
+		defer rows.Close()
+
+		result.Stats = []domain.CountryPlayerStats{}
+		for rows.Next() {
+			stat, _, err := scanCountryStats(rows)
+			if err != nil {
+				return err
+			}
+			result.Stats = append(result.Stats, stat)
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating rows: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// scanCountryStats scans a row of the columns GetTopCountriesByPlayerActivity
+// returns.
+func scanCountryStats(row rowScanner) (domain.CountryPlayerStats, countryStatsKey, error) {
+	var (
+		stat domain.CountryPlayerStats
+		key  countryStatsKey
+	)
+	err := row.Scan(
+		&stat.CountryCode,
+		decimalText{&key.playerCount, 0},
+		decimalText{&key.totalBets, 2},
+		decimalText{&key.avgBetPerPlayer, 6},
+		decimalText{&key.betCount, 0},
+		&stat.SettledStakes,
+		&stat.GGR,
+		&stat.WinCount,
+	)
+	if err != nil {
+		return stat, key, fmt.Errorf("failed to scan row: %w", err)
+	}
+	if err := key.fill(&stat); err != nil {
+		return stat, key, fmt.Errorf("failed to parse row: %w", err)
+	}
+	return stat, key, nil
+}
+
+// countryStatsKey holds the aggregated columns as decimal text so cursors can
+// be built from exact values.
+type countryStatsKey struct {
+	playerCount     string
+	totalBets       string
+	avgBetPerPlayer string
+	betCount        string
+}
+
+func (k countryStatsKey) fill(stat *domain.CountryPlayerStats) error {
+	var err error
+	if stat.PlayerCount, err = strconv.Atoi(k.playerCount); err != nil {
+		return err
+	}
+	if stat.TotalBets, err = domain.ParseMoney(k.totalBets); err != nil {
+		return err
+	}
+	if stat.BetCount, err = strconv.Atoi(k.betCount); err != nil {
+		return err
+	}
+	// The average the database computed is only used for ranking, the
+	// reported one is rounded from the exact total.
+	stat.AvgBetPerPlayer = stat.TotalBets.DivRound(stat.PlayerCount)
+	return nil
+}
+
+func (k countryStatsKey) sortValue(field domain.CountryStatsSortField) string {
+	switch field {
+	case domain.SortByTotalBets:
+		return k.totalBets
+	case domain.SortByAvgBetPerPlayer:
+		return k.avgBetPerPlayer
+	case domain.SortByBetCount:
+		return k.betCount
+	default:
+		return k.playerCount
+	}
+}
+
+// decimalText scans a number as decimal text. MySQL returns DECIMAL columns as
+// exact text, SQLite has no decimal type and returns floats, which are
+// formatted with scale decimal places.
+type decimalText struct {
+	dest  *string
+	scale int
+}
+
+func (d decimalText) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		*d.dest = string(v)
+	case string:
+		*d.dest = v
+	case int64:
+		*d.dest = strconv.FormatInt(v, 10)
+	case float64:
+		*d.dest = strconv.FormatFloat(v, 'f', d.scale, 64)
+	default:
+		return fmt.Errorf("can not scan %T into a decimal", src)
+	}
+	return nil
+}
+
+// timestampLayout is the layout of CURRENT_TIMESTAMP with optional fractional
+// seconds. Bounds are bound as UTC text in this layout, which MySQL converts
+// like the driver formats a time.Time and SQLite compares with its timestamps
+// as text.
+const timestampLayout = "2006-01-02 15:04:05.999999"
+
+func nullTime(t time.Time) sql.NullString {
+	if t.IsZero() {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: t.UTC().Format(timestampLayout), Valid: true}
+}
+
+// nullTimestamp scans timestamps and dates computed by a query. SQLite only
+// parses columns declared as DATE, DATETIME or TIMESTAMP and returns text for
+// everything else.
+type nullTimestamp struct {
+	Time  time.Time
+	Valid bool
+}
+
+func (t *nullTimestamp) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*t = nullTimestamp{}
+		return nil
+	case time.Time:
+		*t = nullTimestamp{Time: v, Valid: true}
+		return nil
+	case []byte:
+		return t.Scan(string(v))
+	case string:
+		for _, layout := range []string{timestampLayout, time.DateOnly} {
+			if parsed, err := time.Parse(layout, v); err == nil {
+				*t = nullTimestamp{Time: parsed, Valid: true}
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid timestamp %q", v)
+	default:
+		return fmt.Errorf("can not scan %T into a timestamp", src)
+	}
 }
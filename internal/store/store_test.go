@@ -0,0 +1,20 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/store"
+	"github.com/Nikola-Milovic/vyking-interview/internal/store/storetest"
+	"github.com/Nikola-Milovic/vyking-interview/internal/testutil"
+)
+
+func TestStore(t *testing.T) {
+	if testutil.UseSQLite() {
+		t.Skip("the MySQL store is not tested with DB_DRIVER=sqlite")
+	}
+
+	db, cleanup := testutil.SetupMySQL(t)
+	defer cleanup()
+
+	storetest.Run(t, store.New(db))
+}
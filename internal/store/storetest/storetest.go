@@ -0,0 +1,881 @@
+// Package storetest is a conformance suite for implementations of
+// domain.Store. Every implementation runs it against a freshly migrated
+// database, so the stores stay interchangeable.
+package storetest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+// Run runs the suite against store, which must be backed by a database set up
+// the way the migrations do. The suite only adds players to countries without
+// seed data, so the seeded random bets do not affect its results.
+func Run(t *testing.T, store domain.Store) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, store domain.Store)
+	}{
+		{"Players", testPlayers},
+		{"Bets", testBets},
+		{"SettleBet", testSettleBet},
+		{"ExchangeRates", testExchangeRates},
+		{"CountryStats", testCountryStats},
+		{"TopCountriesPagination", testTopCountriesPagination},
+		{"Rollup", testRollup},
+		{"RollupConversion", testRollupConversion},
+		{"Leaderboard", testLeaderboard},
+		{"Distribution", testDistribution},
+		{"ActivityByBucket", testActivityByBucket},
+		{"Import", testImport},
+		{"Erasure", testErasure},
+		{"Audit", testAudit},
+		{"PlayersByCountry", testPlayersByCountry},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, store)
+		})
+	}
+}
+
+// today is the window of whole UTC days around now, which stores answer from
+// the rollup.
+func today() (time.Time, time.Time) {
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	return day.AddDate(0, 0, -1), day.AddDate(0, 0, 2)
+}
+
+func createPlayer(t *testing.T, store domain.Store, email, countryCode string) domain.Player {
+	t.Helper()
+
+	res, err := store.CreatePlayer(context.Background(), domain.CreatePlayerQuery{
+		Name:        "Player " + email,
+		Email:       email,
+		CountryCode: countryCode,
+	})
+	require.NoError(t, err)
+	return res.Player
+}
+
+func createBet(t *testing.T, store domain.Store, playerID int, amount domain.Money, currency string) domain.Bet {
+	t.Helper()
+
+	res, err := store.CreateBet(context.Background(), domain.CreateBetQuery{
+		PlayerID: playerID,
+		Amount:   amount,
+		Currency: currency,
+	})
+	require.NoError(t, err)
+	return res.Bet
+}
+
+func testPlayers(t *testing.T, store domain.Store) {
+	ctx := context.Background()
+
+	player := createPlayer(t, store, "players@example.com", "AD")
+	assert.NotZero(t, player.ID)
+	assert.Equal(t, "AD", player.CountryCode)
+	assert.False(t, player.CreatedAt.IsZero())
+
+	_, err := store.CreatePlayer(ctx, domain.CreatePlayerQuery{Name: "Copy", Email: "players@example.com", CountryCode: "AD"})
+	assert.ErrorIs(t, err, domain.ErrConflict)
+
+	got, err := store.GetPlayer(ctx, domain.GetPlayerQuery{ID: player.ID})
+	require.NoError(t, err)
+	assert.Equal(t, player, got.Player)
+
+	list, err := store.ListPlayers(ctx, domain.ListPlayersQuery{Limit: 1000})
+	require.NoError(t, err)
+	assert.Contains(t, list.Players, player)
+
+	updated, err := store.UpdatePlayer(ctx, domain.UpdatePlayerQuery{
+		ID:          player.ID,
+		Name:        "Renamed",
+		Email:       "renamed@example.com",
+		CountryCode: "SM",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", updated.Player.Name)
+	assert.Equal(t, "renamed@example.com", updated.Player.Email)
+	assert.Equal(t, "SM", updated.Player.CountryCode)
+
+	other := createPlayer(t, store, "players.other@example.com", "AD")
+	_, err = store.UpdatePlayer(ctx, domain.UpdatePlayerQuery{ID: other.ID, Name: "Other", Email: "renamed@example.com", CountryCode: "AD"})
+	assert.ErrorIs(t, err, domain.ErrConflict)
+
+	require.NoError(t, store.DeletePlayer(ctx, domain.DeletePlayerQuery{ID: player.ID}))
+
+	_, err = store.GetPlayer(ctx, domain.GetPlayerQuery{ID: player.ID})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = store.UpdatePlayer(ctx, domain.UpdatePlayerQuery{ID: player.ID, Name: "Gone", Email: "gone@example.com", CountryCode: "AD"})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.ErrorIs(t, store.DeletePlayer(ctx, domain.DeletePlayerQuery{ID: player.ID}), domain.ErrNotFound)
+	_, err = store.GetPlayerStats(ctx, domain.GetPlayerStatsQuery{PlayerID: player.ID, Currency: "EUR"})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func testBets(t *testing.T, store domain.Store) {
+	ctx := context.Background()
+
+	player := createPlayer(t, store, "bets@example.com", "LI")
+
+	query := domain.CreateBetQuery{
+		PlayerID:       player.ID,
+		Amount:         domain.MoneyFromCents(1050),
+		Currency:       "EUR",
+		IdempotencyKey: "storetest-bets",
+	}
+	created, err := store.CreateBet(ctx, query)
+	require.NoError(t, err)
+	assert.False(t, created.Replayed)
+	assert.Equal(t, player.ID, created.Bet.PlayerID)
+	assert.Equal(t, domain.MoneyFromCents(1050), created.Bet.Amount)
+	assert.Equal(t, domain.BetStatusOpen, created.Bet.Status)
+
+	replayed, err := store.CreateBet(ctx, query)
+	require.NoError(t, err)
+	assert.True(t, replayed.Replayed)
+	assert.Equal(t, created.Bet, replayed.Bet)
+
+	query.Amount = domain.MoneyFromCents(1051)
+	_, err = store.CreateBet(ctx, query)
+	assert.ErrorIs(t, err, domain.ErrConflict)
+
+	_, err = store.CreateBet(ctx, domain.CreateBetQuery{PlayerID: player.ID + 1000000, Amount: 100, Currency: "EUR"})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	createBet(t, store, player.ID, domain.MoneyFromCents(2575), "EUR")
+	createBet(t, store, player.ID, domain.MoneyFromCents(1), "EUR")
+
+	stats, err := store.GetPlayerStats(ctx, domain.GetPlayerStatsQuery{PlayerID: player.ID, Currency: "EUR"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.Stats.BetCount)
+	assert.Equal(t, domain.MoneyFromCents(3626), stats.Stats.TotalWagered)
+	assert.Equal(t, domain.MoneyFromCents(1209), stats.Stats.AvgBet)
+	assert.Equal(t, domain.MoneyFromCents(1), stats.Stats.MinBet)
+	assert.Equal(t, domain.MoneyFromCents(2575), stats.Stats.MaxBet)
+	assert.WithinDuration(t, time.Now(), stats.Stats.FirstBetAt, time.Hour)
+	assert.False(t, stats.Stats.LastBetAt.Before(stats.Stats.FirstBetAt))
+
+	// Amounts are converted into the currency of the query.
+	foreign := createPlayer(t, store, "bets.rsd@example.com", "LI")
+	createBet(t, store, foreign.ID, domain.MoneyFromCents(11717), "RSD")
+	createBet(t, store, foreign.ID, domain.MoneyFromCents(200), "EUR")
+	stats, err = store.GetPlayerStats(ctx, domain.GetPlayerStatsQuery{PlayerID: foreign.ID, Currency: "EUR"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Stats.BetCount)
+	assert.Equal(t, domain.MoneyFromCents(300), stats.Stats.TotalWagered)
+	assert.Equal(t, domain.MoneyFromCents(100), stats.Stats.MinBet)
+	assert.Equal(t, domain.MoneyFromCents(200), stats.Stats.MaxBet)
+
+	idle := createPlayer(t, store, "bets.idle@example.com", "LI")
+	stats, err = store.GetPlayerStats(ctx, domain.GetPlayerStatsQuery{PlayerID: idle.ID, Currency: "EUR"})
+	require.NoError(t, err)
+	assert.Zero(t, stats.Stats.BetCount)
+	assert.Zero(t, stats.Stats.TotalWagered)
+	assert.True(t, stats.Stats.FirstBetAt.IsZero())
+}
+
+func testSettleBet(t *testing.T, store domain.Store) {
+	ctx := context.Background()
+
+	player := createPlayer(t, store, "settle@example.com", "MC")
+	bet := createBet(t, store, player.ID, domain.MoneyFromCents(1000), "EUR")
+
+	settled, err := store.SettleBet(ctx, domain.SettleBetQuery{ID: bet.ID, Status: domain.BetStatusWon, Payout: domain.MoneyFromCents(2500)})
+	require.NoError(t, err)
+	assert.False(t, settled.Replayed)
+	assert.Equal(t, domain.BetStatusWon, settled.Bet.Status)
+	assert.Equal(t, domain.MoneyFromCents(2500), settled.Bet.Payout)
+	assert.False(t, settled.Bet.SettledAt.IsZero())
+
+	replayed, err := store.SettleBet(ctx, domain.SettleBetQuery{ID: bet.ID, Status: domain.BetStatusWon, Payout: domain.MoneyFromCents(2500)})
+	require.NoError(t, err)
+	assert.True(t, replayed.Replayed)
+
+	_, err = store.SettleBet(ctx, domain.SettleBetQuery{ID: bet.ID, Status: domain.BetStatusLost})
+	assert.ErrorIs(t, err, domain.ErrConflict)
+
+	_, err = store.SettleBet(ctx, domain.SettleBetQuery{ID: bet.ID + 1000000, Status: domain.BetStatusLost})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func testExchangeRates(t *testing.T, store domain.Store) {
+	ctx := context.Background()
+
+	rate := domain.ExchangeRate{
+		Currency:      "CHF",
+		EffectiveFrom: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Rate:          0.95,
+	}
+	created, err := store.CreateExchangeRate(ctx, domain.CreateExchangeRateQuery{Rate: rate})
+	require.NoError(t, err)
+	assert.Equal(t, rate.Currency, created.Rate.Currency)
+	assert.True(t, rate.EffectiveFrom.Equal(created.Rate.EffectiveFrom))
+	assert.InDelta(t, rate.Rate, created.Rate.Rate, 1e-9)
+
+	_, err = store.CreateExchangeRate(ctx, domain.CreateExchangeRateQuery{Rate: rate})
+	assert.ErrorIs(t, err, domain.ErrConflict)
+
+	rate.EffectiveFrom = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, err = store.CreateExchangeRate(ctx, domain.CreateExchangeRateQuery{Rate: rate})
+	require.NoError(t, err)
+
+	list, err := store.ListExchangeRates(ctx, domain.ListExchangeRatesQuery{Currency: "CHF"})
+	require.NoError(t, err)
+	require.Len(t, list.Rates, 2)
+	assert.True(t, list.Rates[0].EffectiveFrom.Before(list.Rates[1].EffectiveFrom))
+
+	all, err := store.ListExchangeRates(ctx, domain.ListExchangeRatesQuery{})
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, len(all.Rates), 5)
+}
+
+func testCountryStats(t *testing.T, store domain.Store) {
+	ctx := context.Background()
+
+	winner := createPlayer(t, store, "stats.winner@example.com", "VA")
+	loser := createPlayer(t, store, "stats.loser@example.com", "VA")
+	createPlayer(t, store, "stats.idle@example.com", "VA")
+
+	won := createBet(t, store, winner.ID, domain.MoneyFromCents(10000), "EUR")
+	lost := createBet(t, store, loser.ID, domain.MoneyFromCents(11717), "RSD")
+	createBet(t, store, loser.ID, domain.MoneyFromCents(632), "BRL")
+
+	_, err := store.SettleBet(ctx, domain.SettleBetQuery{ID: won.ID, Status: domain.BetStatusWon, Payout: domain.MoneyFromCents(15000)})
+	require.NoError(t, err)
+	_, err = store.SettleBet(ctx, domain.SettleBetQuery{ID: lost.ID, Status: domain.BetStatusLost})
+	require.NoError(t, err)
+
+	from, to := today()
+	now := time.Now()
+	windows := []struct {
+		name     string
+		from, to time.Time
+		players  int
+	}{
+		{"without window", time.Time{}, time.Time{}, 3},
+		{"whole days", from, to, 2},
+		{"partial days", now.Add(-time.Hour), now.Add(time.Hour), 2},
+	}
+
+	for _, w := range windows {
+		t.Run(w.name, func(t *testing.T) {
+			res, err := store.GetCountryStats(ctx, domain.GetCountryStatsQuery{
+				CountryCodes: []string{"VA", "XX"},
+				From:         w.from,
+				To:           w.to,
+				Currency:     "EUR",
+			})
+			require.NoError(t, err)
+			require.Len(t, res.Stats, 1)
+
+			stat := res.Stats[0]
+			assert.Equal(t, "VA", stat.CountryCode)
+			assert.Equal(t, w.players, stat.PlayerCount)
+			assert.Equal(t, 3, stat.BetCount)
+			assert.Equal(t, domain.MoneyFromCents(10200), stat.TotalBets)
+			assert.Equal(t, domain.MoneyFromCents(10200).DivRound(w.players), stat.AvgBetPerPlayer)
+			assert.Equal(t, domain.MoneyFromCents(10100), stat.SettledStakes)
+			assert.Equal(t, domain.MoneyFromCents(-4900), stat.GGR)
+			assert.Equal(t, 1, stat.WinCount)
+
+			top, err := store.GetTopCountriesByPlayerActivity(ctx, domain.GetTopCountriesByPlayerActivityQuery{
+				Limit:    100,
+				From:     w.from,
+				To:       w.to,
+				SortBy:   domain.SortByPlayerCount,
+				Order:    domain.SortOrderDesc,
+				Currency: "EUR",
+			})
+			require.NoError(t, err)
+			assert.Contains(t, top.Stats, stat)
+		})
+	}
+
+	res, err := store.GetCountryStats(ctx, domain.GetCountryStatsQuery{CountryCodes: []string{"VA"}, Currency: "RSD"})
+	require.NoError(t, err)
+	require.Len(t, res.Stats, 1)
+	assert.Equal(t, domain.MoneyFromCents(1195134), res.Stats[0].TotalBets)
+
+	res, err = store.GetCountryStats(ctx, domain.GetCountryStatsQuery{Currency: "EUR"})
+	require.NoError(t, err)
+	assert.Empty(t, res.Stats)
+}
+
+func testTopCountriesPagination(t *testing.T, store domain.Store) {
+	ctx := context.Background()
+
+	for i, amount := range []int64{500, 500, 700, 100} {
+		player := createPlayer(t, store, fmt.Sprintf("pagination.%d@example.com", i), []string{"BT", "NP", "MN", "KG"}[i])
+		createBet(t, store, player.ID, domain.MoneyFromCents(amount), "EUR")
+	}
+
+	from, to := today()
+	fields := []domain.CountryStatsSortField{
+		domain.SortByPlayerCount,
+		domain.SortByTotalBets,
+		domain.SortByAvgBetPerPlayer,
+		domain.SortByBetCount,
+	}
+
+	for _, window := range [][2]time.Time{{}, {from, to}} {
+		for _, field := range fields {
+			for _, order := range []domain.SortOrder{domain.SortOrderAsc, domain.SortOrderDesc} {
+				query := domain.GetTopCountriesByPlayerActivityQuery{
+					Limit:    1000,
+					From:     window[0],
+					To:       window[1],
+					SortBy:   field,
+					Order:    order,
+					Currency: "EUR",
+				}
+
+				all, err := store.GetTopCountriesByPlayerActivity(ctx, query)
+				require.NoError(t, err)
+				assert.Nil(t, all.Next)
+
+				var paged []domain.CountryPlayerStats
+				query.Limit = 2
+				for {
+					page, err := store.GetTopCountriesByPlayerActivity(ctx, query)
+					require.NoError(t, err)
+					paged = append(paged, page.Stats...)
+					if page.Next == nil {
+						break
+					}
+					require.Len(t, page.Stats, 2)
+					query.After = page.Next
+				}
+
+				assert.Equal(t, all.Stats, paged, "sorted by %s %s from %s", field, order, window[0])
+			}
+		}
+	}
+}
+
+func testRollup(t *testing.T, store domain.Store) {
+	ctx := context.Background()
+
+	assertConsistent := func(t *testing.T) {
+		t.Helper()
+
+		res, err := store.GetCountryDailyStats(ctx, domain.GetCountryDailyStatsQuery{})
+		require.NoError(t, err)
+		assert.Equal(t, res.BetStats, res.RollupStats)
+		assert.Equal(t, res.BetActivity, res.RollupActivity)
+	}
+
+	player := createPlayer(t, store, "rollup@example.com", "FO")
+	bet := createBet(t, store, player.ID, domain.MoneyFromCents(1999), "BRL")
+	createBet(t, store, player.ID, domain.MoneyFromCents(1), "EUR")
+	assertConsistent(t)
+
+	_, err := store.SettleBet(ctx, domain.SettleBetQuery{ID: bet.ID, Status: domain.BetStatusWon, Payout: domain.MoneyFromCents(3998)})
+	require.NoError(t, err)
+	assertConsistent(t)
+
+	_, err = store.UpdatePlayer(ctx, domain.UpdatePlayerQuery{ID: player.ID, Name: player.Name, Email: player.Email, CountryCode: "GL"})
+	require.NoError(t, err)
+	assertConsistent(t)
+
+	from, to := today()
+	res, err := store.GetCountryDailyStats(ctx, domain.GetCountryDailyStatsQuery{From: from, To: to})
+	require.NoError(t, err)
+	var moved []domain.CountryDailyStats
+	for _, stat := range res.RollupStats {
+		assert.NotEqual(t, "FO", stat.CountryCode)
+		if stat.CountryCode == "GL" {
+			moved = append(moved, stat)
+		}
+	}
+	require.Len(t, moved, 2)
+	assert.Equal(t, "BRL", moved[0].Currency)
+	assert.Equal(t, domain.MoneyFromCents(1999), moved[0].TotalAmount)
+	assert.Equal(t, domain.MoneyFromCents(3998), moved[0].Payouts)
+	assert.Equal(t, 1, moved[0].WinCount)
+
+	require.NoError(t, store.DeletePlayer(ctx, domain.DeletePlayerQuery{ID: player.ID}))
+	assertConsistent(t)
+
+	require.NoError(t, store.RebuildCountryDailyStats(ctx, domain.RebuildCountryDailyStatsQuery{From: from, To: to}))
+	assertConsistent(t)
+
+	_, err = store.GetCountryDailyStats(ctx, domain.GetCountryDailyStatsQuery{From: time.Now()})
+	assert.ErrorIs(t, err, domain.ErrInvalidArgument)
+	err = store.RebuildCountryDailyStats(ctx, domain.RebuildCountryDailyStatsQuery{To: time.Now()})
+	assert.ErrorIs(t, err, domain.ErrInvalidArgument)
+}
+
+// testRollupConversion checks that windows read from the bets convert the
+// amounts the same way as windows read from the rollup, and that the reports
+// of players and time series convert them the same way as well. Converted one
+// by one, each of the bets would round to a cent, converted per day they do
+// not add up to one cent each.
+func testRollupConversion(t *testing.T, store domain.Store) {
+	ctx := context.Background()
+
+	player := createPlayer(t, store, "conversion@example.com", "SM")
+	for i := 0; i < 10; i++ {
+		bet := createBet(t, store, player.ID, domain.MoneyFromCents(100), "RSD")
+		if i%2 == 0 {
+			_, err := store.SettleBet(ctx, domain.SettleBetQuery{ID: bet.ID, Status: domain.BetStatusWon, Payout: domain.MoneyFromCents(150)})
+			require.NoError(t, err)
+		}
+	}
+
+	from, to := today()
+	countryStats := func(from, to time.Time) domain.CountryPlayerStats {
+		t.Helper()
+
+		res, err := store.GetCountryStats(ctx, domain.GetCountryStatsQuery{
+			CountryCodes: []string{"SM"},
+			From:         from,
+			To:           to,
+			Currency:     "EUR",
+		})
+		require.NoError(t, err)
+		require.Len(t, res.Stats, 1)
+
+		top, err := store.GetTopCountriesByPlayerActivity(ctx, domain.GetTopCountriesByPlayerActivityQuery{
+			Limit:    1000,
+			From:     from,
+			To:       to,
+			SortBy:   domain.SortByPlayerCount,
+			Order:    domain.SortOrderDesc,
+			Currency: "EUR",
+		})
+		require.NoError(t, err)
+		assert.Contains(t, top.Stats, res.Stats[0])
+
+		return res.Stats[0]
+	}
+
+	rollup := countryStats(from, to)
+	assert.Equal(t, 10, rollup.BetCount)
+	assert.Equal(t, domain.MoneyFromCents(9), rollup.TotalBets)
+	assert.Equal(t, countryStats(from, to.Add(time.Nanosecond)), rollup)
+
+	leaderboard, err := store.GetTopPlayersByTotalWagered(ctx, domain.GetTopPlayersByTotalWageredQuery{MaxRank: 1, MaxEntries: 1, CountryCode: "SM", Currency: "EUR"})
+	require.NoError(t, err)
+	require.Len(t, leaderboard.Entries, 1)
+	assert.Equal(t, rollup.TotalBets, leaderboard.Entries[0].TotalWagered)
+	assert.Equal(t, rollup.BetCount, leaderboard.Entries[0].BetCount)
+
+	stats, err := store.GetPlayerStats(ctx, domain.GetPlayerStatsQuery{PlayerID: player.ID, Currency: "EUR"})
+	require.NoError(t, err)
+	assert.Equal(t, rollup.TotalBets, stats.Stats.TotalWagered)
+	assert.Equal(t, rollup.BetCount, stats.Stats.BetCount)
+	// Single bets are still converted one by one.
+	assert.Equal(t, domain.MoneyFromCents(1), stats.Stats.MinBet)
+	assert.Equal(t, domain.MoneyFromCents(1), stats.Stats.MaxBet)
+
+	activity, err := store.GetCountryActivityByBucket(ctx, domain.GetCountryActivityByBucketQuery{
+		Buckets:     []domain.TimeRange{{Start: from, End: to}},
+		CountryCode: "SM",
+		Currency:    "EUR",
+	})
+	require.NoError(t, err)
+	require.Len(t, activity.Rows, 1)
+	assert.Equal(t, rollup.TotalBets, activity.Rows[0].TotalBets)
+	assert.Equal(t, rollup.BetCount, activity.Rows[0].BetCount)
+	assert.Equal(t, 1, activity.Rows[0].ActivePlayers)
+}
+
+func testLeaderboard(t *testing.T, store domain.Store) {
+	ctx := context.Background()
+
+	var players []domain.Player
+	for i, amounts := range [][]int64{{300, 200}, {500}, {100}} {
+		player := createPlayer(t, store, fmt.Sprintf("leaderboard.%d@example.com", i), "IS")
+		for _, amount := range amounts {
+			createBet(t, store, player.ID, domain.MoneyFromCents(amount), "EUR")
+		}
+		players = append(players, player)
+	}
+
+	res, err := store.GetTopPlayersByTotalWagered(ctx, domain.GetTopPlayersByTotalWageredQuery{MaxRank: 1, MaxEntries: 10, CountryCode: "IS", Currency: "EUR"})
+	require.NoError(t, err)
+	require.Len(t, res.Entries, 2)
+	for i, entry := range res.Entries {
+		assert.Equal(t, 1, entry.Rank)
+		assert.Equal(t, players[i].ID, entry.PlayerID)
+		assert.Equal(t, "IS", entry.CountryCode)
+		assert.Equal(t, domain.MoneyFromCents(500), entry.TotalWagered)
+	}
+	assert.Equal(t, 2, res.Entries[0].BetCount)
+
+	// Ties can not grow the result beyond MaxEntries.
+	res, err = store.GetTopPlayersByTotalWagered(ctx, domain.GetTopPlayersByTotalWageredQuery{MaxRank: 1, MaxEntries: 1, CountryCode: "IS", Currency: "EUR"})
+	require.NoError(t, err)
+	require.Len(t, res.Entries, 1)
+	assert.Equal(t, players[0].ID, res.Entries[0].PlayerID)
+
+	res, err = store.GetTopPlayersByTotalWagered(ctx, domain.GetTopPlayersByTotalWageredQuery{
+		MaxRank:     10,
+		MaxEntries:  10,
+		CountryCode: "IS",
+		From:        time.Now().Add(time.Hour),
+		Currency:    "EUR",
+	})
+	require.NoError(t, err)
+	assert.Empty(t, res.Entries)
+
+	// Bets are converted into the currency of the leaderboard.
+	foreign := createPlayer(t, store, "leaderboard.rsd@example.com", "FO")
+	createBet(t, store, foreign.ID, domain.MoneyFromCents(23434), "RSD")
+	createBet(t, store, foreign.ID, domain.MoneyFromCents(100), "EUR")
+	res, err = store.GetTopPlayersByTotalWagered(ctx, domain.GetTopPlayersByTotalWageredQuery{MaxRank: 1, MaxEntries: 10, CountryCode: "FO", Currency: "EUR"})
+	require.NoError(t, err)
+	require.Len(t, res.Entries, 1)
+	assert.Equal(t, domain.MoneyFromCents(300), res.Entries[0].TotalWagered)
+}
+
+func testDistribution(t *testing.T, store domain.Store) {
+	ctx := context.Background()
+
+	player := createPlayer(t, store, "distribution@example.com", "MT")
+	for _, amount := range []int64{1000, 2000, 3000, 4000, 10000} {
+		createBet(t, store, player.ID, domain.MoneyFromCents(amount), "EUR")
+	}
+
+	res, err := store.GetBetDistributionByCountry(ctx, domain.GetBetDistributionByCountryQuery{
+		CountryCodes:   []string{"MT", "XX"},
+		HistogramEdges: []float64{0, 25, 50},
+		Currency:       "EUR",
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Distributions, 1)
+
+	distribution := res.Distributions["MT"]
+	assert.Equal(t, 5, distribution.Count)
+	assert.InDelta(t, 10, distribution.Min, 1e-9)
+	assert.InDelta(t, 100, distribution.Max, 1e-9)
+	assert.InDelta(t, 30, distribution.Median, 1e-9)
+	assert.InDelta(t, 76, distribution.P90, 1e-9)
+	assert.InDelta(t, 97.6, distribution.P99, 1e-9)
+	assert.InDelta(t, 31.62277660168, distribution.StdDev, 1e-6)
+
+	require.Len(t, distribution.Histogram, 3)
+	assert.Equal(t, 2, distribution.Histogram[0].Count)
+	assert.Equal(t, 2, distribution.Histogram[1].Count)
+	assert.Equal(t, 1, distribution.Histogram[2].Count)
+}
+
+func testActivityByBucket(t *testing.T, store domain.Store) {
+	ctx := context.Background()
+
+	first := createPlayer(t, store, "buckets.first@example.com", "LU")
+	second := createPlayer(t, store, "buckets.second@example.com", "LU")
+	createBet(t, store, first.ID, domain.MoneyFromCents(1000), "EUR")
+	createBet(t, store, first.ID, domain.MoneyFromCents(1500), "EUR")
+	createBet(t, store, second.ID, domain.MoneyFromCents(250), "EUR")
+	createBet(t, store, second.ID, domain.MoneyFromCents(23434), "RSD")
+
+	now := time.Now()
+	res, err := store.GetCountryActivityByBucket(ctx, domain.GetCountryActivityByBucketQuery{
+		Buckets: []domain.TimeRange{
+			{Start: now.Add(-48 * time.Hour), End: now.Add(-24 * time.Hour)},
+			{Start: now.Add(-time.Hour), End: now.Add(time.Hour)},
+		},
+		CountryCode: "LU",
+		Currency:    "EUR",
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Rows, 1)
+
+	// The RSD bet is converted into 2.00 EUR.
+	row := res.Rows[0]
+	assert.Equal(t, "LU", row.CountryCode)
+	assert.Equal(t, 1, row.Bucket)
+	assert.Equal(t, domain.MoneyFromCents(2950), row.TotalBets)
+	assert.Equal(t, 4, row.BetCount)
+	assert.Equal(t, 2, row.ActivePlayers)
+}
+
+func testImport(t *testing.T, store domain.Store) {
+	ctx := context.Background()
+
+	placed := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	players := []domain.ImportedPlayer{
+		{ExternalID: "ext-player-1", Name: "Imported One", Email: "import.one@example.com", CountryCode: "SC", CreatedAt: placed},
+		{ExternalID: "ext-player-2", Name: "Imported Two", Email: "import.two@example.com", CountryCode: "SC"},
+	}
+
+	dryRun, err := store.ImportPlayers(ctx, domain.ImportPlayersQuery{Players: players, DryRun: true})
+	require.NoError(t, err)
+	require.Len(t, dryRun.Rows, 2)
+	for _, row := range dryRun.Rows {
+		assert.NoError(t, row.Err)
+		assert.False(t, row.Skipped)
+	}
+	imported, err := store.ImportPlayers(ctx, domain.ImportPlayersQuery{Players: append(players, domain.ImportedPlayer{
+		ExternalID: "ext-player-3", Name: "Same Email", Email: "import.one@example.com", CountryCode: "SC",
+	})})
+	require.NoError(t, err)
+	require.Len(t, imported.Rows, 3)
+	require.NoError(t, imported.Rows[0].Err)
+	require.NoError(t, imported.Rows[1].Err)
+	// Nothing was written by the dry run, so nothing is skipped.
+	assert.False(t, imported.Rows[0].Skipped)
+	assert.ErrorIs(t, imported.Rows[2].Err, domain.ErrConflict)
+
+	player, err := store.GetPlayer(ctx, domain.GetPlayerQuery{ID: imported.Rows[0].ID})
+	require.NoError(t, err)
+	assert.Equal(t, "import.one@example.com", player.Player.Email)
+	assert.True(t, placed.Equal(player.Player.CreatedAt))
+
+	again, err := store.ImportPlayers(ctx, domain.ImportPlayersQuery{Players: players[:1]})
+	require.NoError(t, err)
+	assert.True(t, again.Rows[0].Skipped)
+	assert.Equal(t, imported.Rows[0].ID, again.Rows[0].ID)
+
+	bets := []domain.ImportedBet{
+		{ExternalID: "ext-bet-1", PlayerExternalID: "ext-player-1", Amount: domain.MoneyFromCents(1000), Currency: "EUR", Status: domain.BetStatusOpen, CreatedAt: placed},
+		{ExternalID: "ext-bet-2", PlayerExternalID: "ext-player-1", Amount: domain.MoneyFromCents(500), Currency: "EUR", Status: domain.BetStatusWon, Payout: domain.MoneyFromCents(1500), CreatedAt: placed, SettledAt: placed.Add(time.Minute)},
+		{ExternalID: "ext-bet-3", PlayerExternalID: "ext-player-2", Amount: domain.MoneyFromCents(250), Currency: "EUR", Status: domain.BetStatusLost, SettledAt: time.Now()},
+		{ExternalID: "ext-bet-4", PlayerExternalID: "ext-player-missing", Amount: domain.MoneyFromCents(100), Currency: "EUR", Status: domain.BetStatusOpen},
+	}
+
+	dryRunBets, err := store.ImportBets(ctx, domain.ImportBetsQuery{Bets: bets, DryRun: true})
+	require.NoError(t, err)
+	require.Len(t, dryRunBets.Rows, 4)
+	assert.ErrorIs(t, dryRunBets.Rows[3].Err, domain.ErrNotFound)
+
+	importedBets, err := store.ImportBets(ctx, domain.ImportBetsQuery{Bets: bets})
+	require.NoError(t, err)
+	require.Len(t, importedBets.Rows, 4)
+	for _, row := range importedBets.Rows[:3] {
+		require.NoError(t, row.Err)
+		assert.False(t, row.Skipped)
+	}
+	assert.ErrorIs(t, importedBets.Rows[3].Err, domain.ErrNotFound)
+
+	stats, err := store.GetPlayerStats(ctx, domain.GetPlayerStatsQuery{PlayerID: imported.Rows[0].ID, Currency: "EUR"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Stats.BetCount)
+	assert.Equal(t, domain.MoneyFromCents(1500), stats.Stats.TotalWagered)
+
+	againBets, err := store.ImportBets(ctx, domain.ImportBetsQuery{Bets: bets[:1]})
+	require.NoError(t, err)
+	assert.True(t, againBets.Rows[0].Skipped)
+	assert.Equal(t, importedBets.Rows[0].ID, againBets.Rows[0].ID)
+
+	res, err := store.GetCountryDailyStats(ctx, domain.GetCountryDailyStatsQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, res.BetStats, res.RollupStats)
+	assert.Equal(t, res.BetActivity, res.RollupActivity)
+}
+
+func testErasure(t *testing.T, store domain.Store) {
+	ctx := context.Background()
+
+	player := createPlayer(t, store, "erasure@example.com", "AX")
+	createBet(t, store, player.ID, domain.MoneyFromCents(500), "EUR")
+
+	statsBefore, err := store.GetCountryDailyStats(ctx, domain.GetCountryDailyStatsQuery{})
+	require.NoError(t, err)
+
+	erased, err := store.ErasePlayer(ctx, domain.ErasePlayerQuery{
+		ID:          player.ID,
+		Name:        "Erased Player",
+		Email:       "erased@erased.invalid",
+		RequestedBy: "ticket-1",
+		Reason:      "right to be forgotten",
+	})
+	require.NoError(t, err)
+	assert.False(t, erased.Replayed)
+	assert.NotZero(t, erased.Erasure.ID)
+	assert.Equal(t, player.ID, erased.Erasure.PlayerID)
+	assert.Equal(t, "ticket-1", erased.Erasure.RequestedBy)
+	assert.Equal(t, "right to be forgotten", erased.Erasure.Reason)
+	assert.False(t, erased.Erasure.ErasedAt.IsZero())
+
+	got, err := store.GetPlayer(ctx, domain.GetPlayerQuery{ID: player.ID})
+	require.NoError(t, err)
+	assert.Equal(t, "Erased Player", got.Player.Name)
+	assert.Equal(t, "erased@erased.invalid", got.Player.Email)
+	assert.Equal(t, "AX", got.Player.CountryCode)
+	assert.True(t, erased.Erasure.ErasedAt.Equal(got.Player.ErasedAt))
+
+	playerStats, err := store.GetPlayerStats(ctx, domain.GetPlayerStatsQuery{PlayerID: player.ID, Currency: "EUR"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, playerStats.Stats.BetCount)
+	assert.Equal(t, domain.MoneyFromCents(500), playerStats.Stats.TotalWagered)
+
+	statsAfter, err := store.GetCountryDailyStats(ctx, domain.GetCountryDailyStatsQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, statsBefore.RollupStats, statsAfter.RollupStats)
+	assert.Equal(t, statsAfter.BetStats, statsAfter.RollupStats)
+
+	replayed, err := store.ErasePlayer(ctx, domain.ErasePlayerQuery{
+		ID:          player.ID,
+		Name:        "Erased Player",
+		Email:       "erased@erased.invalid",
+		RequestedBy: "ticket-2",
+	})
+	require.NoError(t, err)
+	assert.True(t, replayed.Replayed)
+	assert.Equal(t, erased.Erasure.ID, replayed.Erasure.ID)
+	assert.Equal(t, "ticket-1", replayed.Erasure.RequestedBy)
+
+	other := createPlayer(t, store, "erasure-other@example.com", "AX")
+	_, err = store.ErasePlayer(ctx, domain.ErasePlayerQuery{
+		ID:          other.ID,
+		Name:        "Erased Player",
+		Email:       "erased-other@erased.invalid",
+		RequestedBy: "ticket-3",
+	})
+	require.NoError(t, err)
+
+	list, err := store.ListPlayerErasures(ctx, domain.ListPlayerErasuresQuery{PlayerID: player.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, list.Erasures, 1)
+	assert.Equal(t, erased.Erasure.ID, list.Erasures[0].ID)
+
+	list, err = store.ListPlayerErasures(ctx, domain.ListPlayerErasuresQuery{Limit: 10})
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(list.Erasures), 2)
+	for i := 1; i < len(list.Erasures); i++ {
+		assert.Less(t, list.Erasures[i-1].ID, list.Erasures[i].ID)
+	}
+
+	_, err = store.ErasePlayer(ctx, domain.ErasePlayerQuery{ID: math.MaxInt32, RequestedBy: "ticket-4"})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func testAudit(t *testing.T, store domain.Store) {
+	ctx := domain.WithActor(context.Background(), "storetest")
+
+	entries := func(t *testing.T, entity domain.AuditEntityType, id int) []domain.AuditEntry {
+		t.Helper()
+
+		res, err := store.ListAuditEntries(ctx, domain.ListAuditEntriesQuery{EntityType: entity, EntityID: id, Limit: 100})
+		require.NoError(t, err)
+		return res.Entries
+	}
+	actions := func(entries []domain.AuditEntry) []domain.AuditAction {
+		var actions []domain.AuditAction
+		for _, e := range entries {
+			actions = append(actions, e.Action)
+		}
+		return actions
+	}
+
+	created, err := store.CreatePlayer(ctx, domain.CreatePlayerQuery{Name: "Audited", Email: "audit@example.com", CountryCode: "AD"})
+	require.NoError(t, err)
+	player := created.Player
+
+	update := domain.UpdatePlayerQuery{ID: player.ID, Name: "Audited Again", Email: player.Email, CountryCode: "AD"}
+	updated, err := store.UpdatePlayer(ctx, update)
+	require.NoError(t, err)
+	// Putting the same values again, in a later second of updated_at, changes
+	// nothing and is not recorded.
+	time.Sleep(time.Second)
+	again, err := store.UpdatePlayer(ctx, update)
+	require.NoError(t, err)
+	assert.Equal(t, updated.Player.UpdatedAt, again.Player.UpdatedAt)
+
+	bet, err := store.CreateBet(ctx, domain.CreateBetQuery{PlayerID: player.ID, Amount: domain.MoneyFromCents(250), Currency: "EUR"})
+	require.NoError(t, err)
+	settle := domain.SettleBetQuery{ID: bet.Bet.ID, Status: domain.BetStatusWon, Payout: domain.MoneyFromCents(500)}
+	_, err = store.SettleBet(ctx, settle)
+	require.NoError(t, err)
+	// Settling again changes nothing and is not recorded.
+	_, err = store.SettleBet(ctx, settle)
+	require.NoError(t, err)
+
+	playerEntries := entries(t, domain.AuditEntityPlayer, player.ID)
+	require.Equal(t, []domain.AuditAction{domain.AuditActionCreate, domain.AuditActionUpdate}, actions(playerEntries))
+	assert.Equal(t, "storetest", playerEntries[0].Actor)
+	assert.Nil(t, playerEntries[0].Before)
+	assert.Equal(t, "Audited", playerEntries[0].After["name"])
+	assert.Equal(t, "Audited", playerEntries[1].Before["name"])
+	assert.Equal(t, "Audited Again", playerEntries[1].After["name"])
+	assert.Equal(t, "audit@example.com", playerEntries[1].After["email"])
+	assert.False(t, playerEntries[1].CreatedAt.IsZero())
+	assert.True(t, playerEntries[1].RedactedAt.IsZero())
+
+	betEntries := entries(t, domain.AuditEntityBet, bet.Bet.ID)
+	require.Equal(t, []domain.AuditAction{domain.AuditActionCreate, domain.AuditActionSettle}, actions(betEntries))
+	assert.Equal(t, "open", betEntries[1].Before["status"])
+	assert.Equal(t, "won", betEntries[1].After["status"])
+	assert.Equal(t, "5.00", betEntries[1].After["payout"])
+
+	// Erasing the player redacts the name and email of all its entries.
+	_, err = store.ErasePlayer(ctx, domain.ErasePlayerQuery{
+		ID:          player.ID,
+		Name:        "Erased Player",
+		Email:       "erased-audit@erased.invalid",
+		RequestedBy: "ticket-audit",
+	})
+	require.NoError(t, err)
+
+	playerEntries = entries(t, domain.AuditEntityPlayer, player.ID)
+	require.Equal(t, []domain.AuditAction{domain.AuditActionCreate, domain.AuditActionUpdate, domain.AuditActionErase}, actions(playerEntries))
+	for _, e := range playerEntries {
+		assert.False(t, e.RedactedAt.IsZero())
+		for _, values := range []map[string]any{e.Before, e.After} {
+			if values == nil {
+				continue
+			}
+			assert.Equal(t, "Erased Player", values["name"])
+			assert.Equal(t, "erased-audit@erased.invalid", values["email"])
+			assert.Equal(t, "AD", values["country_code"])
+		}
+	}
+
+	// Deleting the player records the bets that go with it as well.
+	require.NoError(t, store.DeletePlayer(ctx, domain.DeletePlayerQuery{ID: player.ID}))
+	assert.Equal(t, domain.AuditActionDelete, entries(t, domain.AuditEntityPlayer, player.ID)[3].Action)
+	betEntries = entries(t, domain.AuditEntityBet, bet.Bet.ID)
+	require.Len(t, betEntries, 3)
+	assert.Equal(t, domain.AuditActionDelete, betEntries[2].Action)
+	assert.Equal(t, "won", betEntries[2].Before["status"])
+	assert.Nil(t, betEntries[2].After)
+
+	res, err := store.ListAuditEntries(ctx, domain.ListAuditEntriesQuery{Actor: "storetest", Limit: 100})
+	require.NoError(t, err)
+	assert.Len(t, res.Entries, 7)
+	res, err = store.ListAuditEntries(ctx, domain.ListAuditEntriesQuery{Actor: "someone else", Limit: 100})
+	require.NoError(t, err)
+	assert.Empty(t, res.Entries)
+
+	from, to := today()
+	res, err = store.ListAuditEntries(ctx, domain.ListAuditEntriesQuery{Actor: "storetest", From: from, To: to, Limit: 100})
+	require.NoError(t, err)
+	assert.Len(t, res.Entries, 7)
+	res, err = store.ListAuditEntries(ctx, domain.ListAuditEntriesQuery{Actor: "storetest", From: to, Limit: 100})
+	require.NoError(t, err)
+	assert.Empty(t, res.Entries)
+}
+
+func testPlayersByCountry(t *testing.T, store domain.Store) {
+	ctx := context.Background()
+
+	first := createPlayer(t, store, "country-1@example.com", "PW")
+	second := createPlayer(t, store, "country-2@example.com", "PW")
+
+	counts, err := store.CountPlayersByCountry(ctx, domain.CountPlayersByCountryQuery{})
+	require.NoError(t, err)
+	assert.Contains(t, counts.Counts, domain.CountryPlayerCount{CountryCode: "PW", PlayerCount: 2})
+	for i := 1; i < len(counts.Counts); i++ {
+		assert.Less(t, counts.Counts[i-1].CountryCode, counts.Counts[i].CountryCode)
+	}
+
+	listed, err := store.ListPlayers(ctx, domain.ListPlayersQuery{CountryCode: "PW", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, listed.Players, 2)
+	assert.Equal(t, first.ID, listed.Players[0].ID)
+	assert.Equal(t, second.ID, listed.Players[1].ID)
+}
@@ -0,0 +1,111 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+type bucketBounds struct {
+	Start string `json:"s"`
+	End   string `json:"e"`
+}
+
+// bucketColumns read bucketBounds from a JSON table.
+var bucketColumns = []JSONColumn{
+	{Name: "bucket_start", Type: "DATETIME(6)", Path: "$.s"},
+	{Name: "bucket_end", Type: "DATETIME(6)", Path: "$.e"},
+}
+
+func (s *Store) GetCountryActivityByBucket(ctx context.Context, q domain.GetCountryActivityByBucketQuery) (*domain.GetCountryActivityByBucketResult, error) {
+	// Bucket boundaries are computed by the caller so that calendar and time
+	// zone rules stay out of SQL, they are joined against bets as a JSON table.
+	// Bets are summed up per day and currency inside a bucket and converted
+	// like country stats, so daily buckets add up to the same totals. Active
+	// players can not be summed up over days and are counted on their own.
+	query := `
+		WITH bucket_bets AS (
+			SELECT p.country_code, bk.idx, b.player_id, DATE(b.created_at) AS day, b.currency, b.amount
+			FROM ` + s.dialect.JSONTable(bucketColumns...) + ` bk
+			JOIN bets b ON b.created_at >= bk.bucket_start AND b.created_at < bk.bucket_end
+			JOIN players p ON p.id = b.player_id
+			WHERE ? IS NULL OR p.country_code = ?
+		)
+		SELECT
+			d.country_code,
+			d.idx,
+			ROUND(SUM(d.total_amount), 2),
+			SUM(d.bet_count),
+			a.active_players
+		FROM (` + convertDaily(`(
+			SELECT country_code, idx, day, currency, COUNT(*) AS bet_count, ROUND(SUM(amount), 2) AS total_amount
+			FROM bucket_bets
+			GROUP BY country_code, idx, day, currency
+		)`, []string{"country_code", "idx", "bet_count"}, []string{"total_amount"}, "?") + `) d
+		JOIN (
+			SELECT country_code, idx, COUNT(DISTINCT player_id) AS active_players
+			FROM bucket_bets
+			GROUP BY country_code, idx
+		) a ON a.country_code = d.country_code AND a.idx = d.idx
+		GROUP BY d.country_code, d.idx, a.active_players
+		ORDER BY d.country_code, d.idx`
+
+	bounds := make([]bucketBounds, 0, len(q.Buckets))
+	for _, bucket := range q.Buckets {
+		bounds = append(bounds, bucketBounds{
+			Start: bucket.Start.UTC().Format(timestampLayout),
+			End:   bucket.End.UTC().Format(timestampLayout),
+		})
+	}
+	buckets, err := json.Marshal(bounds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode buckets: %w", err)
+	}
+
+	var countryCode sql.NullString
+	if q.CountryCode != "" {
+		countryCode = sql.NullString{String: q.CountryCode, Valid: true}
+	}
+
+	var activity []domain.CountryBucketActivity
+	err = s.withRetry(ctx, func(ctx context.Context) error {
+		rows, err := s.queryReport(ctx, query, string(buckets), countryCode, countryCode, q.Currency)
+		if err != nil {
+			return fmt.Errorf("failed to query country activity: %w", err)
+		}
+		defer rows.Close()
+
+		activity = []domain.CountryBucketActivity{}
+		for rows.Next() {
+			var row domain.CountryBucketActivity
+			err := rows.Scan(
+				&row.CountryCode,
+				&row.Bucket,
+				&row.TotalBets,
+				&row.BetCount,
+				&row.ActivePlayers,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to scan row: %w", err)
+			}
+			// JSONTable counts from 1.
+			row.Bucket--
+			activity = append(activity, row)
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating rows: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.GetCountryActivityByBucketResult{
+		Rows: activity,
+	}, nil
+}
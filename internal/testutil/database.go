@@ -0,0 +1,85 @@
+package testutil
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/mysql"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/store/sqlite"
+)
+
+// UseSQLite reports whether tests run against SQLite instead of MySQL, which
+// DB_DRIVER=sqlite selects the same way it does for the server.
+func UseSQLite() bool {
+	return os.Getenv("DB_DRIVER") == "sqlite"
+}
+
+// SetupDB returns a migrated and seeded database for the driver UseSQLite
+// selects, along with a function that releases it.
+func SetupDB(t *testing.T) (*sql.DB, func()) {
+	if UseSQLite() {
+		return SetupSQLite(t)
+	}
+	return SetupMySQL(t)
+}
+
+// SetupMySQL starts a MySQL container and runs the migrations in it.
+func SetupMySQL(t *testing.T) (*sql.DB, func()) {
+	ctx := context.Background()
+
+	mysqlContainer, err := mysql.Run(ctx,
+		"mysql:lts",
+		mysql.WithDatabase("player_activity_test"),
+		mysql.WithUsername("test_user"),
+		mysql.WithPassword("test_pass"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("port: 3306  MySQL Community Server").
+				WithOccurrence(1).
+				WithStartupTimeout(30*time.Second),
+		),
+	)
+	require.NoError(t, err)
+
+	host, err := mysqlContainer.Host(ctx)
+	require.NoError(t, err)
+
+	port, err := mysqlContainer.MappedPort(ctx, "3306")
+	require.NoError(t, err)
+
+	dsn := "test_user:test_pass@tcp(" + host + ":" + port.Port() + ")/player_activity_test?parseTime=true&loc=UTC&time_zone=%27%2B00%3A00%27&multiStatements=true"
+	db, err := sql.Open("mysql", dsn)
+	require.NoError(t, err)
+
+	err = RunMigrations(db)
+	require.NoError(t, err)
+
+	cleanup := func() {
+		db.Close()
+		if err := testcontainers.TerminateContainer(mysqlContainer); err != nil {
+			t.Logf("failed to terminate container: %s", err)
+		}
+	}
+
+	return db, cleanup
+}
+
+// SetupSQLite creates a SQLite database in a temporary directory.
+func SetupSQLite(t *testing.T) (*sql.DB, func()) {
+	db, err := sqlite.Open(context.Background(), filepath.Join(t.TempDir(), "player_activity_test.db"))
+	require.NoError(t, err)
+
+	cleanup := func() {
+		db.Close()
+	}
+
+	return db, cleanup
+}
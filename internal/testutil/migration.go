@@ -2,53 +2,28 @@ package testutil
 
 import (
 	"database/sql"
-	"fmt"
 
-	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/mysql"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/Nikola-Milovic/vyking-interview/internal/store"
 )
 
-func RunMigrations(db *sql.DB, migrationsPath string) error {
-	driver, err := mysql.WithInstance(db, &mysql.Config{})
+// RunMigrations applies the migrations embedded in the binary, db must allow
+// multiple statements per query.
+func RunMigrations(db *sql.DB) error {
+	m, err := store.NewMigrator(db)
 	if err != nil {
-		return fmt.Errorf("failed to create migration driver: %w", err)
+		return err
 	}
+	defer m.Close()
 
-	m, err := migrate.NewWithDatabaseInstance(
-		fmt.Sprintf("file://%s", migrationsPath),
-		"mysql",
-		driver,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to create migration instance: %w", err)
-	}
-
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		return fmt.Errorf("failed to run migrations: %w", err)
-	}
-
-	return nil
+	return m.Up(0)
 }
 
-func RunMigrationsDown(db *sql.DB, migrationsPath string) error {
-	driver, err := mysql.WithInstance(db, &mysql.Config{})
+func RunMigrationsDown(db *sql.DB) error {
+	m, err := store.NewMigrator(db)
 	if err != nil {
-		return fmt.Errorf("failed to create migration driver: %w", err)
+		return err
 	}
+	defer m.Close()
 
-	m, err := migrate.NewWithDatabaseInstance(
-		fmt.Sprintf("file://%s", migrationsPath),
-		"mysql",
-		driver,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to create migration instance: %w", err)
-	}
-
-	if err := m.Down(); err != nil && err != migrate.ErrNoChange {
-		return fmt.Errorf("failed to rollback migrations: %w", err)
-	}
-
-	return nil
-}
\ No newline at end of file
+	return m.Down(0)
+}
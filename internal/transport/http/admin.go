@@ -0,0 +1,148 @@
+package http
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+	"github.com/swaggest/rest"
+	"github.com/swaggest/usecase"
+	"github.com/swaggest/usecase/status"
+)
+
+// requireAdmin only lets requests carrying the admin token as bearer token
+// through, and attributes their changes to the admin. Without a configured
+// token the admin endpoints are disabled.
+func (h *Handler) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.adminToken == "" {
+			writeStatusError(w, status.Wrap(errors.New("admin endpoints are disabled, ADMIN_TOKEN is not set"), status.PermissionDenied))
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeStatusError(w, status.Wrap(errors.New("missing or invalid admin token"), status.Unauthenticated))
+			return
+		}
+
+		actor, err := adminRequestActor(r)
+		if err != nil {
+			writeStatusError(w, status.Wrap(err, status.InvalidArgument))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(domain.WithActor(r.Context(), actor)))
+	})
+}
+
+// writeStatusError answers a request that did not reach a use case the way
+// use cases answer errors.
+func writeStatusError(w http.ResponseWriter, err error) {
+	code, resp := rest.Err(err)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+type erasePlayerInput struct {
+	ID          int    `path:"id" minimum:"1" description:"Player ID"`
+	RequestedBy string `json:"requested_by" required:"true" minLength:"1" maxLength:"255" description:"Who asked for the erasure, e.g. the support ticket or the operator"`
+	Reason      string `json:"reason" maxLength:"1000" description:"Why the player was erased"`
+}
+
+type erasePlayerOutput struct {
+	Replayed bool `header:"Idempotent-Replayed" json:"-" description:"Set when the player was erased before, the first erasure is returned"`
+	PlayerErasureResponse
+}
+
+func (h *Handler) erasePlayer() usecase.Interactor {
+	u := usecase.NewInteractor(func(ctx context.Context, input erasePlayerInput, output *erasePlayerOutput) error {
+		resp, err := h.service.ErasePlayer(ctx, domain.ErasePlayerRequest{
+			ID:          input.ID,
+			RequestedBy: input.RequestedBy,
+			Reason:      input.Reason,
+		})
+		if err != nil {
+			return toStatusError(err)
+		}
+
+		output.Replayed = resp.Replayed
+		output.PlayerErasureResponse = toPlayerErasureResponse(resp.Erasure)
+
+		return nil
+	})
+
+	u.SetTitle("Erase Player")
+	u.SetDescription("Honors a right to be forgotten request. The name and email of the player are replaced and the erasure is logged. " +
+		"The player keeps their country and bets, so statistics do not change. Erasing a player again returns the first erasure.")
+	u.SetTags("Admin")
+
+	u.SetExpectedErrors(
+		status.InvalidArgument,
+		status.Unauthenticated,
+		status.PermissionDenied,
+		status.NotFound,
+		status.Internal,
+	)
+
+	return u
+}
+
+type listPlayerErasuresInput struct {
+	PlayerID int `query:"player_id" minimum:"1" description:"Only list the erasures of this player"`
+	Limit    int `query:"limit" default:"50" minimum:"1" maximum:"500" description:"Maximum number of erasures to return"`
+	Offset   int `query:"offset" default:"0" minimum:"0" description:"Number of erasures to skip"`
+}
+
+type listPlayerErasuresOutput struct {
+	Erasures []PlayerErasureResponse `json:"erasures"`
+}
+
+func (h *Handler) listPlayerErasures() usecase.Interactor {
+	u := usecase.NewInteractor(func(ctx context.Context, input listPlayerErasuresInput, output *listPlayerErasuresOutput) error {
+		resp, err := h.service.ListPlayerErasures(ctx, domain.ListPlayerErasuresRequest{
+			PlayerID: input.PlayerID,
+			Limit:    input.Limit,
+			Offset:   input.Offset,
+		})
+		if err != nil {
+			return toStatusError(err)
+		}
+
+		output.Erasures = make([]PlayerErasureResponse, 0, len(resp.Erasures))
+		for _, erasure := range resp.Erasures {
+			output.Erasures = append(output.Erasures, toPlayerErasureResponse(erasure))
+		}
+
+		return nil
+	})
+
+	u.SetTitle("List Player Erasures")
+	u.SetDescription("Returns the log of erasures in the order they were made")
+	u.SetTags("Admin")
+
+	u.SetExpectedErrors(
+		status.InvalidArgument,
+		status.Unauthenticated,
+		status.PermissionDenied,
+		status.Internal,
+	)
+
+	return u
+}
+
+func toPlayerErasureResponse(e domain.PlayerErasure) PlayerErasureResponse {
+	return PlayerErasureResponse{
+		ID:          e.ID,
+		PlayerID:    e.PlayerID,
+		RequestedBy: e.RequestedBy,
+		Reason:      e.Reason,
+		ErasedAt:    e.ErasedAt,
+	}
+}
@@ -0,0 +1,119 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+	"github.com/swaggest/usecase"
+	"github.com/swaggest/usecase/status"
+)
+
+const (
+	// actorHeader tells admins apart, the admin token is shared. Only admin
+	// requests are authenticated, so the header is ignored on every other
+	// route. Admin actors are prefixed and have to fit the 255 characters the
+	// audit log keeps.
+	actorHeader    = "X-Actor"
+	maxActorLength = 255 - len(adminActor+":")
+
+	// apiActor is the actor of requests without the admin token, admin
+	// requests are attributed to adminActor.
+	apiActor   = "api"
+	adminActor = "admin"
+)
+
+// withActor attributes the changes of every request to apiActor, until
+// requireAdmin authenticates it as an admin. Nothing else the request carries
+// can be trusted to tell who made it.
+func withActor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(domain.WithActor(r.Context(), apiActor)))
+	})
+}
+
+// adminRequestActor is the actor of an admin request, the admin token does not
+// tell admins apart so actorHeader is kept next to it.
+func adminRequestActor(r *http.Request) (string, error) {
+	actor := strings.TrimSpace(r.Header.Get(actorHeader))
+	if len(actor) > maxActorLength {
+		return "", fmt.Errorf("%s must be at most %d characters", actorHeader, maxActorLength)
+	}
+	if actor != "" {
+		return adminActor + ":" + actor, nil
+	}
+	return adminActor, nil
+}
+
+type listAuditEntriesInput struct {
+	EntityType string    `query:"entity_type" enum:"player,bet" description:"Only list changes of this kind of entity"`
+	EntityID   int       `query:"entity_id" minimum:"1" description:"Only list changes of the entity with this ID, needs entity_type"`
+	Actor      string    `query:"actor" maxLength:"255" description:"Only list changes made by this actor"`
+	From       time.Time `query:"from" description:"Only list changes made at or after this time (RFC 3339)"`
+	To         time.Time `query:"to" description:"Only list changes made before this time (RFC 3339)"`
+	Limit      int       `query:"limit" default:"50" minimum:"1" maximum:"500" description:"Maximum number of entries to return"`
+	Offset     int       `query:"offset" default:"0" minimum:"0" description:"Number of entries to skip"`
+}
+
+type listAuditEntriesOutput struct {
+	Entries []AuditEntryResponse `json:"entries"`
+}
+
+func (h *Handler) listAuditEntries() usecase.Interactor {
+	u := usecase.NewInteractor(func(ctx context.Context, input listAuditEntriesInput, output *listAuditEntriesOutput) error {
+		resp, err := h.service.ListAuditEntries(ctx, domain.ListAuditEntriesRequest{
+			EntityType: domain.AuditEntityType(input.EntityType),
+			EntityID:   input.EntityID,
+			Actor:      input.Actor,
+			From:       input.From,
+			To:         input.To,
+			Limit:      input.Limit,
+			Offset:     input.Offset,
+		})
+		if err != nil {
+			return toStatusError(err)
+		}
+
+		output.Entries = make([]AuditEntryResponse, 0, len(resp.Entries))
+		for _, entry := range resp.Entries {
+			output.Entries = append(output.Entries, toAuditEntryResponse(entry))
+		}
+
+		return nil
+	})
+
+	u.SetTitle("List Audit Entries")
+	u.SetDescription("Returns the changes of players and bets in the order they were made, with the values before and after each change. " +
+		"Changes made by requests without the admin token are attributed to " + apiActor + ", their " + actorHeader + " header is not authenticated and ignored. " +
+		"Admin requests are attributed to " + adminActor + ", or to " + adminActor + ":<" + actorHeader + "> when they carry the header.")
+	u.SetTags("Admin")
+
+	u.SetExpectedErrors(
+		status.InvalidArgument,
+		status.Unauthenticated,
+		status.PermissionDenied,
+		status.Internal,
+	)
+
+	return u
+}
+
+func toAuditEntryResponse(e domain.AuditEntry) AuditEntryResponse {
+	response := AuditEntryResponse{
+		ID:         e.ID,
+		EntityType: string(e.EntityType),
+		EntityID:   e.EntityID,
+		Action:     string(e.Action),
+		Actor:      e.Actor,
+		Before:     e.Before,
+		After:      e.After,
+		CreatedAt:  e.CreatedAt,
+	}
+	if !e.RedactedAt.IsZero() {
+		response.RedactedAt = &e.RedactedAt
+	}
+	return response
+}
@@ -0,0 +1,105 @@
+package http
+
+import (
+	"context"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+	"github.com/swaggest/usecase"
+	"github.com/swaggest/usecase/status"
+)
+
+type placeBetInput struct {
+	IdempotencyKey string `header:"Idempotency-Key" maxLength:"255" description:"Client generated key, retries with the same key never record the wager twice"`
+	PlayerID       int    `json:"player_id" required:"true" minimum:"1" description:"ID of the player placing the bet"`
+	Amount         Money  `json:"amount" required:"true" description:"Wagered amount between 0.01 and 99999999.99 with at most two decimal places"`
+	Currency       string `json:"currency" default:"EUR" pattern:"^[A-Za-z]{3}$" description:"ISO 4217 currency of the amount, an exchange rate must exist for it"`
+}
+
+type placeBetOutput struct {
+	Replayed bool `header:"Idempotent-Replayed" json:"-" description:"Set when the bet was already recorded under the given idempotency key"`
+	BetResponse
+}
+
+func (h *Handler) placeBet() usecase.Interactor {
+	u := usecase.NewInteractor(func(ctx context.Context, input placeBetInput, output *placeBetOutput) error {
+		resp, err := h.service.PlaceBet(ctx, domain.PlaceBetRequest{
+			PlayerID:       input.PlayerID,
+			Amount:         domain.Money(input.Amount),
+			Currency:       input.Currency,
+			IdempotencyKey: input.IdempotencyKey,
+		})
+		if err != nil {
+			return toStatusError(err)
+		}
+
+		output.Replayed = resp.Replayed
+		output.BetResponse = toBetResponse(resp.Bet)
+
+		return nil
+	})
+
+	u.SetTitle("Place Bet")
+	u.SetDescription("Records a bet for an existing player. Requests carrying an already used Idempotency-Key return the original bet.")
+	u.SetTags("Bets")
+
+	u.SetExpectedErrors(
+		status.InvalidArgument,
+		status.NotFound,
+		status.AlreadyExists,
+		status.Internal,
+	)
+
+	return u
+}
+
+type settleBetInput struct {
+	ID     int    `path:"id" minimum:"1" description:"ID of the bet"`
+	Status string `json:"status" required:"true" enum:"won,lost,void" description:"Outcome of the bet"`
+	Payout Money  `json:"payout" description:"Amount paid out in the bet currency, required for won bets and 0 otherwise"`
+}
+
+func (h *Handler) settleBet() usecase.Interactor {
+	u := usecase.NewInteractor(func(ctx context.Context, input settleBetInput, output *BetResponse) error {
+		resp, err := h.service.SettleBet(ctx, domain.SettleBetRequest{
+			ID:     input.ID,
+			Status: domain.BetStatus(input.Status),
+			Payout: domain.Money(input.Payout),
+		})
+		if err != nil {
+			return toStatusError(err)
+		}
+
+		*output = toBetResponse(resp.Bet)
+
+		return nil
+	})
+
+	u.SetTitle("Settle Bet")
+	u.SetDescription("Settles an open bet as won, lost or void. Repeating a settlement with the same outcome returns the bet unchanged, a different outcome conflicts.")
+	u.SetTags("Bets")
+
+	u.SetExpectedErrors(
+		status.InvalidArgument,
+		status.NotFound,
+		status.AlreadyExists,
+		status.Internal,
+	)
+
+	return u
+}
+
+func toBetResponse(b domain.Bet) BetResponse {
+	response := BetResponse{
+		ID:        b.ID,
+		PlayerID:  b.PlayerID,
+		Amount:    Money(b.Amount),
+		Currency:  b.Currency,
+		Status:    string(b.Status),
+		Payout:    Money(b.Payout),
+		CreatedAt: b.CreatedAt,
+	}
+	if !b.SettledAt.IsZero() {
+		response.SettledAt = &b.SettledAt
+	}
+	return response
+}
@@ -1,11 +1,49 @@
 package http
 
+import "time"
+
 type CountryPlayerStatsResponse struct {
-	CountryCode     string       `json:"country_code" description:"ISO 3166-1 alpha-2 country code"`
-	PlayerCount     int          `json:"player_count" description:"Number of active players in this country"`
-	TotalBets       float64      `json:"total_bets" description:"Total amount of bets placed by players from this country"`
-	AvgBetPerPlayer float64      `json:"avg_bet_per_player" description:"Average bet amount per player in this country"`
-	CountryInfo     *CountryInfo `json:"country_info" description:"Additional information about the country"`
+	CountryCode     string           `json:"country_code" description:"ISO 3166-1 alpha-2 country code"`
+	PlayerCount     int              `json:"player_count" description:"Number of active players in this country"`
+	TotalBets       Money            `json:"total_bets" description:"Total amount of bets placed by players from this country"`
+	AvgBetPerPlayer Money            `json:"avg_bet_per_player" description:"Average bet amount per player in this country"`
+	BetCount        int              `json:"bet_count" description:"Number of bets placed by players from this country"`
+	SettledStakes   Money            `json:"settled_stakes" description:"Stakes of won and lost bets"`
+	GGR             Money            `json:"ggr" description:"Gross gaming revenue, settled stakes minus payouts"`
+	HoldPercentage  float64          `json:"hold_percentage" description:"GGR as a percentage of settled stakes, 0 without settled bets"`
+	WinCount        int              `json:"win_count" description:"Number of won bets"`
+	CountryInfo     *CountryInfo     `json:"country_info" description:"Additional information about the country"`
+	Distribution    *BetDistribution `json:"distribution,omitempty" description:"Distribution of individual bet amounts, only present with include=distribution"`
+}
+
+type BetDistribution struct {
+	Count     int               `json:"count" description:"Number of bets the distribution is based on"`
+	Min       float64           `json:"min" description:"Smallest bet amount"`
+	Max       float64           `json:"max" description:"Largest bet amount"`
+	Median    float64           `json:"median" description:"Median bet amount"`
+	P90       float64           `json:"p90" description:"90th percentile of bet amounts"`
+	P99       float64           `json:"p99" description:"99th percentile of bet amounts"`
+	StdDev    float64           `json:"std_dev" description:"Population standard deviation of bet amounts"`
+	Histogram []HistogramBucket `json:"histogram" description:"Number of bets per amount range"`
+}
+
+type HistogramBucket struct {
+	LowerBound float64  `json:"lower_bound" description:"Inclusive lower bound of the bucket"`
+	UpperBound *float64 `json:"upper_bound" description:"Exclusive upper bound of the bucket, null for the last bucket"`
+	Count      int      `json:"count" description:"Number of bets in the bucket"`
+}
+
+type RegionPlayerStatsResponse struct {
+	Region          string   `json:"region" description:"Region name as reported by the country API, unknown when it could not be resolved"`
+	CountryCodes    []string `json:"country_codes" description:"ISO 3166-1 alpha-2 codes of the countries rolled up into this region"`
+	PlayerCount     int      `json:"player_count" description:"Number of active players in this region"`
+	TotalBets       Money    `json:"total_bets" description:"Total amount of bets placed by players from this region"`
+	AvgBetPerPlayer Money    `json:"avg_bet_per_player" description:"Average bet amount per player in this region, weighted by player count"`
+	BetCount        int      `json:"bet_count" description:"Number of bets placed by players from this region"`
+	SettledStakes   Money    `json:"settled_stakes" description:"Stakes of won and lost bets"`
+	GGR             Money    `json:"ggr" description:"Gross gaming revenue, settled stakes minus payouts"`
+	HoldPercentage  float64  `json:"hold_percentage" description:"GGR as a percentage of settled stakes, 0 without settled bets"`
+	WinCount        int      `json:"win_count" description:"Number of won bets"`
 }
 
 type CountryInfo struct {
@@ -17,3 +55,90 @@ type CountryInfo struct {
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
+
+type PlayerResponse struct {
+	ID          int        `json:"id" description:"Unique identifier of the player"`
+	Name        string     `json:"name" description:"Full name of the player"`
+	Email       string     `json:"email" description:"Email address of the player"`
+	CountryCode string     `json:"country_code" description:"ISO 3166-1 alpha-2 country code"`
+	CreatedAt   time.Time  `json:"created_at" description:"Time the player was created"`
+	UpdatedAt   time.Time  `json:"updated_at" description:"Time the player was last updated"`
+	ErasedAt    *time.Time `json:"erased_at,omitempty" description:"Time the personal data of the player was erased, only present for erased players"`
+}
+
+type AuditEntryResponse struct {
+	ID         int            `json:"id" description:"Unique identifier of the entry"`
+	EntityType string         `json:"entity_type" enum:"player,bet" description:"Kind of the changed entity"`
+	EntityID   int            `json:"entity_id" description:"ID of the changed entity"`
+	Action     string         `json:"action" enum:"create,update,delete,settle,erase,import" description:"What was done to the entity"`
+	Actor      string         `json:"actor" description:"Who made the change"`
+	Before     map[string]any `json:"before" description:"Values of the entity before the change, null when it was created"`
+	After      map[string]any `json:"after" description:"Values of the entity after the change, null when it was deleted"`
+	CreatedAt  time.Time      `json:"created_at" description:"Time of the change"`
+	RedactedAt *time.Time     `json:"redacted_at,omitempty" description:"Time the personal data in the values was replaced because the player was erased"`
+}
+
+type PlayerErasureResponse struct {
+	ID          int       `json:"id" description:"Unique identifier of the erasure"`
+	PlayerID    int       `json:"player_id" description:"ID of the erased player"`
+	RequestedBy string    `json:"requested_by" description:"Who asked for the erasure"`
+	Reason      string    `json:"reason" description:"Why the player was erased"`
+	ErasedAt    time.Time `json:"erased_at" description:"Time the player was erased"`
+}
+
+type BetResponse struct {
+	ID        int        `json:"id" description:"Unique identifier of the bet"`
+	PlayerID  int        `json:"player_id" description:"ID of the player who placed the bet"`
+	Amount    Money      `json:"amount" description:"Wagered amount"`
+	Currency  string     `json:"currency" description:"ISO 4217 currency of the amount"`
+	Status    string     `json:"status" enum:"open,won,lost,void" description:"Settlement status of the bet"`
+	Payout    Money      `json:"payout" description:"Amount paid out for a won bet in the bet currency, 0 otherwise"`
+	SettledAt *time.Time `json:"settled_at" description:"Time the bet was settled, null while it is open"`
+	CreatedAt time.Time  `json:"created_at" description:"Time the bet was placed"`
+}
+
+type ExchangeRateResponse struct {
+	Currency      string  `json:"currency" description:"ISO 4217 currency code"`
+	EffectiveFrom string  `json:"effective_from" format:"date" description:"Date from which the rate applies until the next rate of the currency"`
+	Rate          float64 `json:"rate" description:"Amount of the currency one EUR buys"`
+}
+
+type PlayerStatsResponse struct {
+	Currency     string         `json:"currency" description:"Currency of all amounts"`
+	Player       PlayerResponse `json:"player" description:"The player the statistics belong to"`
+	BetCount     int            `json:"bet_count" description:"Number of bets placed by the player"`
+	TotalWagered Money          `json:"total_wagered" description:"Total amount wagered by the player"`
+	AvgBet       Money          `json:"avg_bet" description:"Average bet amount, 0 when the player has no bets"`
+	MinBet       Money          `json:"min_bet" description:"Smallest bet amount, 0 when the player has no bets"`
+	MaxBet       Money          `json:"max_bet" description:"Largest bet amount, 0 when the player has no bets"`
+	FirstBetAt   *time.Time     `json:"first_bet_at" description:"Time of the first bet, null when the player has no bets"`
+	LastBetAt    *time.Time     `json:"last_bet_at" description:"Time of the most recent bet, null when the player has no bets"`
+	CountryInfo  *CountryInfo   `json:"country_info" description:"Additional information about the player's country"`
+}
+
+type LeaderboardEntryResponse struct {
+	Rank         int    `json:"rank" description:"Dense rank of the player, players with equal totals share a rank"`
+	PlayerID     int    `json:"player_id" description:"ID of the player"`
+	Name         string `json:"name" description:"Full name of the player"`
+	CountryCode  string `json:"country_code" description:"ISO 3166-1 alpha-2 country code"`
+	TotalWagered Money  `json:"total_wagered" description:"Total amount wagered by the player"`
+	BetCount     int    `json:"bet_count" description:"Number of bets placed by the player"`
+}
+
+type CountryActivitySeriesResponse struct {
+	CountryCode string                          `json:"country_code" description:"ISO 3166-1 alpha-2 country code"`
+	Buckets     []CountryActivityBucketResponse `json:"buckets" description:"Consecutive buckets covering the requested range, buckets without bets are zero filled"`
+}
+
+type CountryActivityBucketResponse struct {
+	Start         time.Time `json:"start" description:"Calendar start of the bucket in the requested time zone"`
+	TotalBets     Money     `json:"total_bets" description:"Total amount of bets placed in the bucket"`
+	BetCount      int       `json:"bet_count" description:"Number of bets placed in the bucket"`
+	ActivePlayers int       `json:"active_players" description:"Number of distinct players that placed a bet in the bucket"`
+}
+
+type ImportRowError struct {
+	Row        int    `json:"row" description:"1-based number of the row, the CSV header is not counted"`
+	ExternalID string `json:"external_id,omitempty" description:"external_id of the row, when it could be read"`
+	Message    string `json:"message" description:"Why the row was rejected"`
+}
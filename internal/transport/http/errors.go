@@ -0,0 +1,23 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+	"github.com/swaggest/usecase/status"
+)
+
+func toStatusError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidArgument):
+		return status.Wrap(err, status.InvalidArgument)
+	case errors.Is(err, domain.ErrNotFound):
+		return status.Wrap(err, status.NotFound)
+	case errors.Is(err, domain.ErrConflict):
+		return status.Wrap(err, status.AlreadyExists)
+	case errors.Is(err, domain.ErrUnavailable):
+		return status.Wrap(err, status.Unavailable)
+	default:
+		return status.Wrap(err, status.Internal)
+	}
+}
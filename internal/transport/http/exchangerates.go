@@ -0,0 +1,103 @@
+package http
+
+import (
+	"context"
+	"time"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+	"github.com/swaggest/usecase"
+	"github.com/swaggest/usecase/status"
+)
+
+const dateLayout = "2006-01-02"
+
+type createExchangeRateInput struct {
+	Currency      string  `json:"currency" required:"true" pattern:"^[A-Za-z]{3}$" description:"ISO 4217 currency code"`
+	EffectiveFrom string  `json:"effective_from" required:"true" format:"date" description:"Date from which the rate applies, earlier bets keep using the previous rate"`
+	Rate          float64 `json:"rate" required:"true" exclusiveMinimum:"0" description:"Amount of the currency one EUR buys, rounded to 8 decimal places"`
+}
+
+type createExchangeRateOutput struct {
+	ExchangeRateResponse
+}
+
+func (h *Handler) createExchangeRate() usecase.Interactor {
+	u := usecase.NewInteractor(func(ctx context.Context, input createExchangeRateInput, output *createExchangeRateOutput) error {
+		effectiveFrom, err := time.Parse(dateLayout, input.EffectiveFrom)
+		if err != nil {
+			return status.Wrap(err, status.InvalidArgument)
+		}
+
+		resp, err := h.service.CreateExchangeRate(ctx, domain.CreateExchangeRateRequest{
+			Currency:      input.Currency,
+			EffectiveFrom: effectiveFrom,
+			Rate:          input.Rate,
+		})
+		if err != nil {
+			return toStatusError(err)
+		}
+
+		output.ExchangeRateResponse = toExchangeRateResponse(resp.Rate)
+
+		return nil
+	})
+
+	u.SetTitle("Create Exchange Rate")
+	u.SetDescription("Adds an exchange rate against EUR that applies from the given date on. Rates change every converted amount of the reports, so adding one needs the admin token.")
+	u.SetTags("Exchange Rates")
+
+	u.SetExpectedErrors(
+		status.InvalidArgument,
+		status.Unauthenticated,
+		status.PermissionDenied,
+		status.AlreadyExists,
+		status.Internal,
+	)
+
+	return u
+}
+
+type listExchangeRatesInput struct {
+	Currency string `query:"currency" pattern:"^[A-Za-z]{3}$" description:"Only list the rates of this currency"`
+}
+
+type listExchangeRatesOutput struct {
+	Rates []ExchangeRateResponse `json:"rates"`
+}
+
+func (h *Handler) listExchangeRates() usecase.Interactor {
+	u := usecase.NewInteractor(func(ctx context.Context, input listExchangeRatesInput, output *listExchangeRatesOutput) error {
+		resp, err := h.service.ListExchangeRates(ctx, domain.ListExchangeRatesRequest{
+			Currency: input.Currency,
+		})
+		if err != nil {
+			return toStatusError(err)
+		}
+
+		output.Rates = make([]ExchangeRateResponse, 0, len(resp.Rates))
+		for _, rate := range resp.Rates {
+			output.Rates = append(output.Rates, toExchangeRateResponse(rate))
+		}
+
+		return nil
+	})
+
+	u.SetTitle("List Exchange Rates")
+	u.SetDescription("Lists exchange rates by currency and effective date")
+	u.SetTags("Exchange Rates")
+
+	u.SetExpectedErrors(
+		status.InvalidArgument,
+		status.Internal,
+	)
+
+	return u
+}
+
+func toExchangeRateResponse(r domain.ExchangeRate) ExchangeRateResponse {
+	return ExchangeRateResponse{
+		Currency:      r.Currency,
+		EffectiveFrom: r.EffectiveFrom.Format(dateLayout),
+		Rate:          r.Rate,
+	}
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+// exchangeRateService records the rates it is asked to create.
+type exchangeRateService struct {
+	domain.Service
+
+	created []domain.CreateExchangeRateRequest
+}
+
+func (s *exchangeRateService) CreateExchangeRate(_ context.Context, req domain.CreateExchangeRateRequest) (domain.CreateExchangeRateResponse, error) {
+	s.created = append(s.created, req)
+	return domain.CreateExchangeRateResponse{Rate: domain.ExchangeRate{Currency: req.Currency, EffectiveFrom: req.EffectiveFrom}}, nil
+}
+
+func (s *exchangeRateService) ListExchangeRates(context.Context, domain.ListExchangeRatesRequest) (domain.ListExchangeRatesResponse, error) {
+	return domain.ListExchangeRatesResponse{}, nil
+}
+
+func TestCreateExchangeRate(t *testing.T) {
+	svc := &exchangeRateService{}
+	mux := http.NewServeMux()
+	NewHandler(svc, "secret").RegisterRoutes(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	post := func(token string) int {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/exchange-rates", strings.NewReader(`{"currency":"RSD","effective_from":"2025-01-01","rate":117.2}`))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, post(""))
+	assert.Empty(t, svc.created)
+
+	assert.Equal(t, http.StatusCreated, post("secret"))
+	require.Len(t, svc.created, 1)
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), svc.created[0].EffectiveFrom)
+
+	// Listing the rates stays public.
+	resp, err := http.Get(server.URL + "/exchange-rates")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,428 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+	"github.com/swaggest/openapi-go"
+	"github.com/swaggest/rest/jsonschema"
+	"github.com/swaggest/rest/nethttp"
+	"github.com/swaggest/rest/request"
+	"github.com/swaggest/rest/response"
+	"github.com/swaggest/rest/response/gzip"
+	"github.com/swaggest/rest/web"
+	"github.com/swaggest/usecase"
+	"github.com/swaggest/usecase/status"
+)
+
+const (
+	exportFormatCSV    = "csv"
+	exportFormatNDJSON = "ndjson"
+
+	contentTypeCSV    = "text/csv"
+	contentTypeNDJSON = "application/x-ndjson"
+
+	// exportPageSize is the number of countries an export reads at a time.
+	exportPageSize = 100
+	// exportPageTimeout is how long reading and sending a page may take. It
+	// replaces the server write timeout, which an export of every country
+	// would run out of.
+	exportPageTimeout = time.Minute
+)
+
+// exportFormat picks the export format of a request from the format parameter
+// or else the Accept header. It is empty for JSON responses.
+func exportFormat(format, accept string) string {
+	if format != "" {
+		if format == "json" {
+			return ""
+		}
+		return format
+	}
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case contentTypeCSV:
+			return exportFormatCSV
+		case contentTypeNDJSON:
+			return exportFormatNDJSON
+		case "application/json":
+			return ""
+		}
+	}
+	return ""
+}
+
+// withExport serves the requests that ask for an export with export instead.
+func withExport(export http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if exportFormat(r.URL.Query().Get("format"), r.Header.Get("Accept")) != "" {
+				export.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// compressUnlessExport compresses the responses of requests that do not ask for
+// an export. The gzip writer leaves CSV and NDJSON uncompressed, but then
+// ignores flushes, which would hold back the pages of an export.
+func compressUnlessExport(next http.Handler) http.Handler {
+	compressed := gzip.Middleware(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if exportFormat(r.URL.Query().Get("format"), r.Header.Get("Accept")) != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		compressed.ServeHTTP(w, r)
+	})
+}
+
+// exportHandler serves an export interactor. It is not routed on its own, so
+// it is set up with the decoding and encoding the service sets up routes with.
+func exportHandler(s *web.Service, u usecase.Interactor) http.Handler {
+	return nethttp.WrapHandler(nethttp.NewHandler(u),
+		request.DecoderMiddleware(s.DecoderFactory),
+		request.ValidatorMiddleware(jsonschema.NewFactory(s.OpenAPICollector, s.OpenAPICollector)),
+		response.EncoderMiddleware,
+	)
+}
+
+// exportContent documents the export content types of an operation.
+func exportContent() func(h *nethttp.Handler) {
+	return nethttp.AnnotateOpenAPIOperation(func(oc openapi.OperationContext) error {
+		for _, contentType := range []string{contentTypeCSV, contentTypeNDJSON} {
+			oc.AddRespStructure(nil, openapi.WithContentType(contentType), openapi.WithHTTPStatus(http.StatusOK))
+		}
+		return nil
+	})
+}
+
+type exportCountryPlayerStatsInput struct {
+	getCountryPlayerStatsInput
+	Accept string `header:"Accept"`
+}
+
+type exportOutput struct {
+	usecase.OutputWithEmbeddedWriter
+	response.EmbeddedSetter
+}
+
+// exportCountryPlayerStats streams the country stats page by page, so an
+// export of every country never holds more than a page in memory.
+func (h *Handler) exportCountryPlayerStats() usecase.Interactor {
+	u := usecase.NewInteractor(func(ctx context.Context, input exportCountryPlayerStatsInput, output *exportOutput) error {
+		rows := newExportRowWriter(exportFormat(input.Format, input.Accept), output.ResponseWriter(), "country-player-stats")
+
+		if input.GroupBy == "region" {
+			if err := rows.extendDeadline(); err != nil {
+				return status.Wrap(err, status.Internal)
+			}
+
+			req, err := input.regionRequest()
+			if err != nil {
+				return err
+			}
+
+			resp, err := h.service.GetRegionPlayerStats(ctx, req)
+			if err != nil {
+				return toStatusError(err)
+			}
+
+			if err := rows.writeHeader(columnNames(regionStatsColumns)); err != nil {
+				return rows.abort(ctx, err)
+			}
+			for _, region := range resp.Regions {
+				if err := rows.writeRow(columnValues(regionStatsColumns, resp.Currency, toRegionPlayerStatsResponse(region))); err != nil {
+					return rows.abort(ctx, err)
+				}
+			}
+			if err := rows.flush(); err != nil {
+				return rows.abort(ctx, err)
+			}
+			return nil
+		}
+
+		req := input.countryRequest()
+		req.Limit = exportPageSize
+
+		columns := countryStatsColumns
+		if req.IncludeDistribution {
+			columns = append(columns[:len(columns):len(columns)], distributionColumns...)
+		}
+
+		for page := 0; ; page++ {
+			if err := rows.extendDeadline(); err != nil {
+				if page == 0 {
+					return status.Wrap(err, status.Internal)
+				}
+				return rows.abort(ctx, err)
+			}
+
+			resp, err := h.service.GetCountryPlayerStats(ctx, req)
+			if err != nil {
+				if page == 0 {
+					return toStatusError(err)
+				}
+				return rows.abort(ctx, err)
+			}
+
+			if page == 0 {
+				if err := rows.writeHeader(columnNames(columns)); err != nil {
+					return rows.abort(ctx, err)
+				}
+			}
+			for _, stat := range resp.Stats {
+				if err := rows.writeRow(columnValues(columns, resp.Currency, toCountryPlayerStatsResponse(stat))); err != nil {
+					return rows.abort(ctx, err)
+				}
+			}
+			if err := rows.flush(); err != nil {
+				return rows.abort(ctx, err)
+			}
+
+			if resp.NextCursor == "" {
+				return nil
+			}
+			req.Cursor = resp.NextCursor
+		}
+	})
+
+	u.SetExpectedErrors(
+		status.InvalidArgument,
+		status.Internal,
+		status.Unavailable,
+	)
+
+	return u
+}
+
+// exportColumn is a column of an export. Its name is the JSON name of the
+// field, prefixed with the name of the object it is nested in.
+type exportColumn[T any] struct {
+	name  string
+	value func(currency string, row T) any
+}
+
+var countryStatsColumns = []exportColumn[CountryPlayerStatsResponse]{
+	{"country_code", func(_ string, s CountryPlayerStatsResponse) any { return s.CountryCode }},
+	{"currency", func(currency string, _ CountryPlayerStatsResponse) any { return currency }},
+	{"player_count", func(_ string, s CountryPlayerStatsResponse) any { return s.PlayerCount }},
+	{"total_bets", func(_ string, s CountryPlayerStatsResponse) any { return s.TotalBets }},
+	{"avg_bet_per_player", func(_ string, s CountryPlayerStatsResponse) any { return s.AvgBetPerPlayer }},
+	{"bet_count", func(_ string, s CountryPlayerStatsResponse) any { return s.BetCount }},
+	{"settled_stakes", func(_ string, s CountryPlayerStatsResponse) any { return s.SettledStakes }},
+	{"ggr", func(_ string, s CountryPlayerStatsResponse) any { return s.GGR }},
+	{"hold_percentage", func(_ string, s CountryPlayerStatsResponse) any { return s.HoldPercentage }},
+	{"win_count", func(_ string, s CountryPlayerStatsResponse) any { return s.WinCount }},
+	{"country_info_name", func(_ string, s CountryPlayerStatsResponse) any {
+		if s.CountryInfo == nil {
+			return nil
+		}
+		return s.CountryInfo.Name
+	}},
+	{"country_info_region", func(_ string, s CountryPlayerStatsResponse) any {
+		if s.CountryInfo == nil {
+			return nil
+		}
+		return s.CountryInfo.Region
+	}},
+	{"country_info_borders", func(_ string, s CountryPlayerStatsResponse) any {
+		if s.CountryInfo == nil {
+			return nil
+		}
+		return nonNil(s.CountryInfo.Borders)
+	}},
+}
+
+// distributionColumns flatten the distribution statistics, the histogram is
+// only part of JSON responses.
+var distributionColumns = []exportColumn[CountryPlayerStatsResponse]{
+	distributionColumn("count", func(d BetDistribution) any { return d.Count }),
+	distributionColumn("min", func(d BetDistribution) any { return d.Min }),
+	distributionColumn("max", func(d BetDistribution) any { return d.Max }),
+	distributionColumn("median", func(d BetDistribution) any { return d.Median }),
+	distributionColumn("p90", func(d BetDistribution) any { return d.P90 }),
+	distributionColumn("p99", func(d BetDistribution) any { return d.P99 }),
+	distributionColumn("std_dev", func(d BetDistribution) any { return d.StdDev }),
+}
+
+func distributionColumn(name string, value func(d BetDistribution) any) exportColumn[CountryPlayerStatsResponse] {
+	return exportColumn[CountryPlayerStatsResponse]{"distribution_" + name, func(_ string, s CountryPlayerStatsResponse) any {
+		if s.Distribution == nil {
+			return nil
+		}
+		return value(*s.Distribution)
+	}}
+}
+
+var regionStatsColumns = []exportColumn[RegionPlayerStatsResponse]{
+	{"region", func(_ string, s RegionPlayerStatsResponse) any { return s.Region }},
+	{"currency", func(currency string, _ RegionPlayerStatsResponse) any { return currency }},
+	{"country_codes", func(_ string, s RegionPlayerStatsResponse) any { return nonNil(s.CountryCodes) }},
+	{"player_count", func(_ string, s RegionPlayerStatsResponse) any { return s.PlayerCount }},
+	{"total_bets", func(_ string, s RegionPlayerStatsResponse) any { return s.TotalBets }},
+	{"avg_bet_per_player", func(_ string, s RegionPlayerStatsResponse) any { return s.AvgBetPerPlayer }},
+	{"bet_count", func(_ string, s RegionPlayerStatsResponse) any { return s.BetCount }},
+	{"settled_stakes", func(_ string, s RegionPlayerStatsResponse) any { return s.SettledStakes }},
+	{"ggr", func(_ string, s RegionPlayerStatsResponse) any { return s.GGR }},
+	{"hold_percentage", func(_ string, s RegionPlayerStatsResponse) any { return s.HoldPercentage }},
+	{"win_count", func(_ string, s RegionPlayerStatsResponse) any { return s.WinCount }},
+}
+
+func columnNames[T any](columns []exportColumn[T]) []string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.name
+	}
+	return names
+}
+
+func columnValues[T any](columns []exportColumn[T], currency string, row T) []any {
+	values := make([]any, len(columns))
+	for i, c := range columns {
+		values[i] = c.value(currency, row)
+	}
+	return values
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// exportRowWriter writes the rows of an export to the response. The headers
+// are sent with the column names, errors before that are answered as usual.
+type exportRowWriter struct {
+	w       http.ResponseWriter
+	format  string
+	columns []string
+
+	csv  *csv.Writer
+	line bytes.Buffer
+}
+
+func newExportRowWriter(format string, w http.ResponseWriter, name string) *exportRowWriter {
+	rows := &exportRowWriter{w: w, format: format}
+
+	switch format {
+	case exportFormatCSV:
+		w.Header().Set("Content-Type", contentTypeCSV+"; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".csv"))
+		rows.csv = csv.NewWriter(w)
+	default:
+		w.Header().Set("Content-Type", contentTypeNDJSON)
+	}
+
+	return rows
+}
+
+func (rw *exportRowWriter) writeHeader(columns []string) error {
+	rw.columns = columns
+	if rw.csv != nil {
+		return rw.csv.Write(columns)
+	}
+	// NDJSON has no header row, the headers are sent with the first flush.
+	return nil
+}
+
+func (rw *exportRowWriter) writeRow(values []any) error {
+	if rw.csv != nil {
+		record := make([]string, len(values))
+		for i, v := range values {
+			record[i] = csvValue(v)
+		}
+		return rw.csv.Write(record)
+	}
+
+	rw.line.Reset()
+	rw.line.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			rw.line.WriteByte(',')
+		}
+		name, _ := json.Marshal(rw.columns[i])
+		value, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		rw.line.Write(name)
+		rw.line.WriteByte(':')
+		rw.line.Write(value)
+	}
+	rw.line.WriteString("}\n")
+
+	_, err := rw.w.Write(rw.line.Bytes())
+	return err
+}
+
+// extendDeadline gives the next page exportPageTimeout to be read and sent.
+func (rw *exportRowWriter) extendDeadline() error {
+	err := http.NewResponseController(rw.w).SetWriteDeadline(time.Now().Add(exportPageTimeout))
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// flush sends the rows written so far to the client.
+func (rw *exportRowWriter) flush() error {
+	if rw.csv != nil {
+		rw.csv.Flush()
+		if err := rw.csv.Error(); err != nil {
+			return err
+		}
+	}
+
+	err := http.NewResponseController(rw.w).Flush()
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// abort ends an export that failed after rows were sent. The status can not
+// change anymore, so the connection is dropped to keep the client from taking
+// a partial export for a complete one.
+func (rw *exportRowWriter) abort(ctx context.Context, err error) error {
+	slog.ErrorContext(ctx, "export failed", "error", err)
+	panic(http.ErrAbortHandler)
+}
+
+func csvValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case Money:
+		return domain.Money(v).String()
+	case []string:
+		return strings.Join(v, " ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,278 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+func TestExportFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		accept string
+		want   string
+	}{
+		{"json by default", "", "", ""},
+		{"any media type", "", "*/*", ""},
+		{"csv accepted", "", "text/csv", exportFormatCSV},
+		{"ndjson accepted with parameters", "", "application/x-ndjson; q=0.9", exportFormatNDJSON},
+		{"first known media type wins", "", "text/html, application/json, text/csv", ""},
+		{"unknown media types are skipped", "", "text/html, text/csv; charset=utf-8", exportFormatCSV},
+		{"invalid media types are skipped", "", "text/, application/x-ndjson", exportFormatNDJSON},
+		{"format overrides accept", exportFormatNDJSON, "text/csv", exportFormatNDJSON},
+		{"json format overrides accept", "json", "text/csv", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, exportFormat(tt.format, tt.accept))
+		})
+	}
+}
+
+// exportService serves pages of country stats, its cursors are page numbers.
+type exportService struct {
+	domain.Service
+
+	pages [][]domain.CountryPlayerStatsWithInfo
+	// failAt is the page that fails, -1 when none does.
+	failAt int
+	// delay is how long reading a page takes.
+	delay    time.Duration
+	requests []domain.GetCountryPlayerStatsRequest
+}
+
+func (s *exportService) GetCountryPlayerStats(_ context.Context, req domain.GetCountryPlayerStatsRequest) (domain.GetCountryPlayerStatsResponse, error) {
+	s.requests = append(s.requests, req)
+	time.Sleep(s.delay)
+
+	page := 0
+	if req.Cursor != "" {
+		page, _ = strconv.Atoi(req.Cursor)
+	}
+	if page == s.failAt {
+		return domain.GetCountryPlayerStatsResponse{}, errors.New("database is gone")
+	}
+
+	resp := domain.GetCountryPlayerStatsResponse{Currency: req.Currency, Stats: s.pages[page]}
+	if page+1 < len(s.pages) {
+		resp.NextCursor = strconv.Itoa(page + 1)
+	}
+	return resp, nil
+}
+
+func newExportServer(t *testing.T, failAt int) (*httptest.Server, *exportService) {
+	t.Helper()
+
+	svc := newExportService(failAt)
+	return startExportServer(t, svc, 0), svc
+}
+
+func newExportService(failAt int) *exportService {
+
+	stat := func(code string) domain.CountryPlayerStatsWithInfo {
+		return domain.CountryPlayerStatsWithInfo{
+			CountryPlayerStats: domain.CountryPlayerStats{
+				CountryCode:     code,
+				PlayerCount:     2,
+				TotalBets:       domain.MoneyFromCents(1050),
+				AvgBetPerPlayer: domain.MoneyFromCents(525),
+				BetCount:        3,
+			},
+			CountryInfo: domain.CountryInfo{Code: code, Name: "Country " + code, Region: "Europe", Borders: []string{"AUT"}},
+			Distribution: &domain.BetDistribution{
+				Count:     3,
+				Min:       1,
+				Max:       8.5,
+				Histogram: []domain.HistogramBucket{{LowerBound: 0, UpperBound: 10, Count: 3}},
+			},
+		}
+	}
+
+	return &exportService{
+		pages: [][]domain.CountryPlayerStatsWithInfo{
+			{stat("DE"), stat("FR")},
+			{stat("IT")},
+		},
+		failAt: failAt,
+	}
+}
+
+// startExportServer serves svc with the write timeout of the server, none
+// when it is zero.
+func startExportServer(t *testing.T, svc domain.Service, writeTimeout time.Duration) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	NewHandler(svc, "").RegisterRoutes(mux)
+	server := httptest.NewUnstartedServer(mux)
+	server.Config.WriteTimeout = writeTimeout
+	server.Start()
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func getExport(t *testing.T, url, accept string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// flattenedKeys are the keys of the JSON object of v, with the keys of nested
+// objects prefixed with the key of the object the way export columns are.
+func flattenedKeys(t *testing.T, v any) []string {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	var object map[string]any
+	require.NoError(t, json.Unmarshal(data, &object))
+
+	var keys []string
+	for key, value := range object {
+		nested, ok := value.(map[string]any)
+		if !ok {
+			keys = append(keys, key)
+			continue
+		}
+		for nestedKey := range nested {
+			keys = append(keys, key+"_"+nestedKey)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func TestExportCountryPlayerStats_NDJSON(t *testing.T) {
+	server, svc := newExportServer(t, -1)
+
+	resp := getExport(t, server.URL+"/country-player-stats?format=ndjson&include=distribution&currency=RSD", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, contentTypeNDJSON, resp.Header.Get("Content-Type"))
+
+	var lines []map[string]any
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var line map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	require.NoError(t, scanner.Err())
+
+	// Both pages were streamed, the second one from the cursor of the first.
+	require.Len(t, lines, 3)
+	var codes []any
+	for _, line := range lines {
+		codes = append(codes, line["country_code"])
+	}
+	assert.Equal(t, []any{"DE", "FR", "IT"}, codes)
+	require.Len(t, svc.requests, 2)
+	assert.Equal(t, exportPageSize, svc.requests[0].Limit)
+	assert.Equal(t, "1", svc.requests[1].Cursor)
+
+	// The lines have the fields of the JSON response, flattened, and the
+	// currency of the response next to them. The histogram is left out.
+	want := flattenedKeys(t, toCountryPlayerStatsResponse(svc.pages[0][0]))
+	want = slices.DeleteFunc(want, func(key string) bool { return key == "distribution_histogram" })
+	want = append(want, "currency")
+	slices.Sort(want)
+
+	var got []string
+	for key := range lines[0] {
+		got = append(got, key)
+	}
+	slices.Sort(got)
+	assert.Equal(t, want, got)
+	assert.Equal(t, "RSD", lines[0]["currency"])
+	assert.Equal(t, "Country DE", lines[0]["country_info_name"])
+}
+
+func TestExportCountryPlayerStats_CSV(t *testing.T) {
+	server, svc := newExportServer(t, -1)
+
+	resp := getExport(t, server.URL+"/country-player-stats", "text/csv")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, contentTypeCSV+"; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="country-player-stats.csv"`, resp.Header.Get("Content-Disposition"))
+
+	records, err := csv.NewReader(resp.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+
+	// Without the distribution its columns are left out as well.
+	stat := toCountryPlayerStatsResponse(svc.pages[0][0])
+	stat.Distribution = nil
+	want := append(flattenedKeys(t, stat), "currency")
+	slices.Sort(want)
+	header := slices.Clone(records[0])
+	slices.Sort(header)
+	assert.Equal(t, want, header)
+
+	assert.Equal(t, columnNames(countryStatsColumns), records[0])
+	assert.Equal(t, []string{"DE", "EUR", "2", "10.50", "5.25", "3", "0.00", "0.00", "0", "0", "Country DE", "Europe", "AUT"}, records[1])
+	assert.Equal(t, "IT", records[3][0])
+}
+
+func TestExportCountryPlayerStats_Abort(t *testing.T) {
+	t.Run("before any row", func(t *testing.T) {
+		server, _ := newExportServer(t, 0)
+
+		resp := getExport(t, server.URL+"/country-player-stats?format=csv", "")
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get("Content-Type"), "application/json")
+	})
+
+	t.Run("after rows were sent", func(t *testing.T) {
+		server, _ := newExportServer(t, 1)
+
+		resp := getExport(t, server.URL+"/country-player-stats?format=ndjson", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		// The connection is dropped, so the partial export does not end like
+		// a complete one.
+		body, err := io.ReadAll(resp.Body)
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+		assert.Contains(t, string(body), `"country_code":"FR"`)
+		assert.NotContains(t, string(body), `"country_code":"IT"`)
+	})
+}
+
+func TestExportCountryPlayerStats_WriteTimeout(t *testing.T) {
+	// Every page is read within the write timeout of the server, the whole
+	// export is not.
+	svc := newExportService(-1)
+	svc.delay = 100 * time.Millisecond
+	server := startExportServer(t, svc, 150*time.Millisecond)
+
+	resp := getExport(t, server.URL+"/country-player-stats?format=csv", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	records, err := csv.NewReader(resp.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	assert.Equal(t, "IT", records[3][0])
+}
@@ -2,11 +2,14 @@ package http
 
 import (
 	"context"
+	"errors"
+	"math"
 	"net/http"
+	"time"
 
 	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
 	"github.com/swaggest/openapi-go/openapi31"
-	"github.com/swaggest/rest/response/gzip"
+	"github.com/swaggest/rest/nethttp"
 	"github.com/swaggest/rest/web"
 	swgui "github.com/swaggest/swgui/v5emb"
 	"github.com/swaggest/usecase"
@@ -15,11 +18,15 @@ import (
 
 type Handler struct {
 	service domain.Service
+	// adminToken is the bearer token of the admin endpoints, they are
+	// disabled when it is empty.
+	adminToken string
 }
 
-func NewHandler(service domain.Service) *Handler {
+func NewHandler(service domain.Service, adminToken string) *Handler {
 	return &Handler{
-		service: service,
+		service:    service,
+		adminToken: adminToken,
 	}
 }
 
@@ -31,10 +38,45 @@ func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 	s.OpenAPISchema().SetVersion("v1.0.0")
 
 	s.Wrap(
-		gzip.Middleware,
+		compressUnlessExport,
 	)
+	// Router middlewares run before the ones of route groups, the admin group
+	// replaces the actor.
+	s.Use(withActor)
+
+	s.Method(http.MethodGet, "/country-player-stats", nethttp.WrapHandler(
+		nethttp.NewHandler(h.getCountryPlayerStats(), exportContent()),
+		withExport(exportHandler(s, h.exportCountryPlayerStats())),
+	))
+	s.Get("/country-activity", h.getCountryActivity())
+	s.Get("/countries/{code}/neighbors-comparison", h.getNeighborComparison())
+	s.Get("/leaderboard", h.getLeaderboard())
+
+	s.Post("/players", h.createPlayer(), nethttp.SuccessStatus(http.StatusCreated))
+	s.Get("/players", h.listPlayers())
+	s.Get("/players/{id}", h.getPlayer())
+	s.Put("/players/{id}", h.updatePlayer())
+	s.Delete("/players/{id}", h.deletePlayer())
+	s.Get("/players/{id}/stats", h.getPlayerStats())
+
+	s.Post("/bets", h.placeBet(), nethttp.SuccessStatus(http.StatusCreated))
+	s.Post("/bets/{id}/settle", h.settleBet())
+
+	s.Get("/exchange-rates", h.listExchangeRates())
+
+	admin := s.With(
+		h.requireAdmin,
+		nethttp.HTTPBearerSecurityMiddleware(s.OpenAPICollector, "admin", "ADMIN_TOKEN of the service", ""),
+	)
+	admin.Method(http.MethodPost, "/admin/players/{id}/erasure", nethttp.NewHandler(h.erasePlayer(), nethttp.SuccessStatus(http.StatusCreated)))
+	admin.Method(http.MethodGet, "/admin/erasures", nethttp.NewHandler(h.listPlayerErasures()))
+	admin.Method(http.MethodGet, "/admin/audit", nethttp.NewHandler(h.listAuditEntries()))
+	admin.Method(http.MethodPost, "/imports", nethttp.WrapHandler(
+		nethttp.NewHandler(h.createImport(), nethttp.RequestBodyContent("text/csv")),
+		withImportLimits,
+	))
+	admin.Method(http.MethodPost, "/exchange-rates", nethttp.NewHandler(h.createExchangeRate(), nethttp.SuccessStatus(http.StatusCreated)))
 
-	s.Get("/country-player-stats", h.getCountryPlayerStats())
 	s.Get("/health", h.health())
 
 	s.Docs("/docs", swgui.New)
@@ -43,49 +85,50 @@ func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 }
 
 type getCountryPlayerStatsInput struct {
-	Limit int `query:"limit" default:"10" minimum:"1" maximum:"100" description:"Maximum number of countries to return"`
+	Limit          int       `query:"limit" default:"10" minimum:"1" maximum:"100" description:"Maximum number of countries to return"`
+	From           time.Time `query:"from" description:"Only count bets placed at or after this time (RFC 3339)"`
+	To             time.Time `query:"to" description:"Only count bets placed before this time (RFC 3339)"`
+	SortBy         string    `query:"sort_by" default:"player_count" enum:"player_count,total_bets,avg_bet_per_player,bet_count" description:"Metric to rank countries by"`
+	Order          string    `query:"order" default:"desc" enum:"asc,desc" description:"Ranking direction"`
+	Cursor         string    `query:"cursor" description:"Opaque next_cursor of the previous page, requires the same sort_by and order"`
+	Include        string    `query:"include" enum:"distribution" description:"Set to distribution to add bet amount distribution statistics to every country"`
+	HistogramEdges []float64 `query:"histogram_edges" description:"Ascending lower bounds of the distribution histogram buckets, repeat the parameter for every edge. The last bucket is open ended."`
+	GroupBy        string    `query:"group_by" default:"country" enum:"country,region" description:"Set to region to roll countries up into their regions, all regions are returned at once and limit, cursor and include do not apply"`
+	Currency       string    `query:"currency" default:"EUR" pattern:"^[A-Za-z]{3}$" description:"ISO 4217 currency the bets are converted into per day and bet currency, using the exchange rates of the day. Requires the same value when paging with a cursor."`
+	Format         string    `query:"format" enum:"json,csv,ndjson" description:"Response format, overrides the Accept header. CSV and NDJSON stream every country from the cursor on with country_info and distribution flattened into country_info_* and distribution_* columns, limit does not apply."`
 }
 
 type getCountryPlayerStatsOutput struct {
-	Stats []CountryPlayerStatsResponse `json:"stats"`
+	Currency   string                       `json:"currency" description:"Currency of all amounts"`
+	Stats      []CountryPlayerStatsResponse `json:"stats"`
+	Regions    []RegionPlayerStatsResponse  `json:"regions,omitempty" description:"Per region statistics, only present with group_by=region"`
+	NextCursor string                       `json:"next_cursor,omitempty" description:"Cursor for the next page, omitted on the last page"`
 }
 
 func (h *Handler) getCountryPlayerStats() usecase.Interactor {
 	u := usecase.NewInteractor(func(ctx context.Context, input getCountryPlayerStatsInput, output *getCountryPlayerStatsOutput) error {
-		req := domain.GetCountryPlayerStatsRequest{
-			Limit: input.Limit,
+		if input.GroupBy == "region" {
+			return h.getRegionPlayerStats(ctx, input, output)
 		}
 
-		resp, err := h.service.GetCountryPlayerStats(ctx, req)
+		resp, err := h.service.GetCountryPlayerStats(ctx, input.countryRequest())
 		if err != nil {
-			return status.Wrap(err, status.Internal)
+			return toStatusError(err)
 		}
 
+		output.Currency = resp.Currency
 		output.Stats = make([]CountryPlayerStatsResponse, 0, len(resp.Stats))
 		for _, stat := range resp.Stats {
-			response := CountryPlayerStatsResponse{
-				CountryCode:     stat.CountryCode,
-				PlayerCount:     stat.PlayerCount,
-				TotalBets:       stat.TotalBets,
-				AvgBetPerPlayer: stat.AvgBetPerPlayer,
-			}
-
-			if !stat.CountryInfo.IsZero() {
-				response.CountryInfo = &CountryInfo{
-					Name:    stat.CountryInfo.Name,
-					Region:  stat.CountryInfo.Region,
-					Borders: stat.CountryInfo.Borders,
-				}
-			}
-
-			output.Stats = append(output.Stats, response)
+			output.Stats = append(output.Stats, toCountryPlayerStatsResponse(stat))
 		}
+		output.NextCursor = resp.NextCursor
 
 		return nil
 	})
 
 	u.SetTitle("Get Country Player Statistics")
-	u.SetDescription("Returns player activity statistics grouped by country with enriched country information")
+	u.SetDescription("Returns player activity statistics grouped by country with enriched country information. When a from/to window is given only players that bet inside it are counted. Amounts are summed up per day and bet currency and converted into the requested currency with the rates of the day. " +
+		"Requests accepting text/csv or application/x-ndjson, or with the matching format, get a streamed export instead.")
 	u.SetTags("Statistics")
 
 	u.SetExpectedErrors(
@@ -97,6 +140,131 @@ func (h *Handler) getCountryPlayerStats() usecase.Interactor {
 	return u
 }
 
+func (h *Handler) getRegionPlayerStats(ctx context.Context, input getCountryPlayerStatsInput, output *getCountryPlayerStatsOutput) error {
+	req, err := input.regionRequest()
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.service.GetRegionPlayerStats(ctx, req)
+	if err != nil {
+		return toStatusError(err)
+	}
+
+	output.Currency = resp.Currency
+	output.Stats = []CountryPlayerStatsResponse{}
+	output.Regions = make([]RegionPlayerStatsResponse, 0, len(resp.Regions))
+	for _, region := range resp.Regions {
+		output.Regions = append(output.Regions, toRegionPlayerStatsResponse(region))
+	}
+
+	return nil
+}
+
+func (input getCountryPlayerStatsInput) countryRequest() domain.GetCountryPlayerStatsRequest {
+	return domain.GetCountryPlayerStatsRequest{
+		Limit:  input.Limit,
+		From:   input.From,
+		To:     input.To,
+		SortBy: domain.CountryStatsSortField(input.SortBy),
+		Order:  domain.SortOrder(input.Order),
+		Cursor: input.Cursor,
+
+		Currency: input.Currency,
+
+		IncludeDistribution: input.Include == "distribution",
+		HistogramEdges:      input.HistogramEdges,
+	}
+}
+
+func (input getCountryPlayerStatsInput) regionRequest() (domain.GetRegionPlayerStatsRequest, error) {
+	if input.Cursor != "" || input.Include != "" {
+		return domain.GetRegionPlayerStatsRequest{}, status.Wrap(errors.New("cursor and include can not be combined with group_by=region"), status.InvalidArgument)
+	}
+
+	return domain.GetRegionPlayerStatsRequest{
+		From:   input.From,
+		To:     input.To,
+		SortBy: domain.CountryStatsSortField(input.SortBy),
+		Order:  domain.SortOrder(input.Order),
+
+		Currency: input.Currency,
+	}, nil
+}
+
+func toRegionPlayerStatsResponse(region domain.RegionPlayerStats) RegionPlayerStatsResponse {
+	return RegionPlayerStatsResponse{
+		Region:          region.Region,
+		CountryCodes:    region.CountryCodes,
+		PlayerCount:     region.PlayerCount,
+		TotalBets:       Money(region.TotalBets),
+		AvgBetPerPlayer: Money(region.AvgBetPerPlayer),
+		BetCount:        region.BetCount,
+		SettledStakes:   Money(region.SettledStakes),
+		GGR:             Money(region.GGR),
+		HoldPercentage:  region.HoldPercentage(),
+		WinCount:        region.WinCount,
+	}
+}
+
+func toCountryPlayerStatsResponse(stat domain.CountryPlayerStatsWithInfo) CountryPlayerStatsResponse {
+	response := CountryPlayerStatsResponse{
+		CountryCode:     stat.CountryCode,
+		PlayerCount:     stat.PlayerCount,
+		TotalBets:       Money(stat.TotalBets),
+		AvgBetPerPlayer: Money(stat.AvgBetPerPlayer),
+		BetCount:        stat.BetCount,
+		SettledStakes:   Money(stat.SettledStakes),
+		GGR:             Money(stat.GGR),
+		HoldPercentage:  stat.HoldPercentage(),
+		WinCount:        stat.WinCount,
+	}
+
+	response.CountryInfo = toCountryInfo(stat.CountryInfo)
+	if stat.Distribution != nil {
+		response.Distribution = toBetDistribution(*stat.Distribution)
+	}
+
+	return response
+}
+
+func toBetDistribution(d domain.BetDistribution) *BetDistribution {
+	histogram := make([]HistogramBucket, 0, len(d.Histogram))
+	for _, bucket := range d.Histogram {
+		b := HistogramBucket{
+			LowerBound: bucket.LowerBound,
+			Count:      bucket.Count,
+		}
+		if !math.IsInf(bucket.UpperBound, 1) {
+			b.UpperBound = &bucket.UpperBound
+		}
+		histogram = append(histogram, b)
+	}
+
+	return &BetDistribution{
+		Count:     d.Count,
+		Min:       d.Min,
+		Max:       d.Max,
+		Median:    d.Median,
+		P90:       d.P90,
+		P99:       d.P99,
+		StdDev:    d.StdDev,
+		Histogram: histogram,
+	}
+}
+
+func toCountryInfo(info domain.CountryInfo) *CountryInfo {
+	if info.IsZero() {
+		return nil
+	}
+
+	return &CountryInfo{
+		Name:    info.Name,
+		Region:  info.Region,
+		Borders: info.Borders,
+	}
+}
+
 type healthOutput struct {
 	Status string `json:"status"`
 }
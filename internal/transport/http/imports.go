@@ -0,0 +1,110 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+	"github.com/swaggest/rest/request"
+	"github.com/swaggest/usecase"
+	"github.com/swaggest/usecase/status"
+)
+
+const (
+	// importTimeout replaces the server read and write timeouts for imports,
+	// which read bodies far larger than other requests.
+	importTimeout = time.Hour
+	// maxImportBodySize is the largest import body read, 1 GiB.
+	maxImportBodySize = 1 << 30
+)
+
+type createImportInput struct {
+	request.EmbeddedSetter
+
+	Type   string `query:"type" required:"true" enum:"players,bets" description:"Kind of records in the body"`
+	Format string `query:"format" default:"csv" enum:"csv,ndjson" description:"Format of the body, CSV with a header row or one JSON object per line"`
+	DryRun bool   `query:"dry_run" description:"Validate every row without writing anything"`
+}
+
+type createImportOutput struct {
+	DryRun        bool             `json:"dry_run" description:"Set when nothing was written"`
+	Rows          int              `json:"rows" description:"Number of rows read, without the CSV header"`
+	Imported      int              `json:"imported" description:"Number of rows written, or that would have been written on a dry run"`
+	Skipped       int              `json:"skipped" description:"Number of rows whose external_id was imported before"`
+	Failed        int              `json:"failed" description:"Number of rejected rows"`
+	Errors        []ImportRowError `json:"errors" description:"Why the first 1000 rejected rows were rejected"`
+	ErrorsDropped int              `json:"errors_dropped" description:"Number of rejected rows after the first 1000, which errors leaves out"`
+}
+
+func (h *Handler) createImport() usecase.Interactor {
+	u := usecase.NewInteractor(func(ctx context.Context, input createImportInput, output *createImportOutput) error {
+		resp, err := h.service.Import(ctx, domain.ImportRequest{
+			Kind:   domain.ImportKind(input.Type),
+			Format: domain.ImportFormat(input.Format),
+			Body:   input.Request().Body,
+			DryRun: input.DryRun,
+		})
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return status.Wrap(fmt.Errorf("import body is larger than %d bytes, split it up", tooLarge.Limit), status.InvalidArgument)
+		}
+		if err != nil {
+			return toStatusError(err)
+		}
+
+		output.DryRun = input.DryRun
+		output.Rows = resp.Rows
+		output.Imported = resp.Imported
+		output.Skipped = resp.Skipped
+		output.Failed = resp.Failed
+		output.Errors = make([]ImportRowError, 0, len(resp.Errors))
+		for _, e := range resp.Errors {
+			output.Errors = append(output.Errors, ImportRowError{
+				Row:        e.Row,
+				ExternalID: e.ExternalID,
+				Message:    e.Message,
+			})
+		}
+		output.ErrorsDropped = resp.ErrorsDropped
+
+		return nil
+	})
+
+	u.SetTitle("Import Players or Bets")
+	u.SetDescription("Streams players or bets exported from another platform into the database. " +
+		"Players columns: external_id, name, email, country_code and optionally created_at. " +
+		"Bets columns: external_id, player_external_id, amount, currency and optionally status, payout, created_at and settled_at, players are referenced by the external_id they were imported with. " +
+		"Rows are written in batches of 500, each in its own transaction. Rejected rows are reported and do not stop the import, rows imported before are skipped. " +
+		"Bodies may be up to 1 GiB and take up to an hour.")
+	u.SetTags("Imports")
+
+	u.SetExpectedErrors(
+		status.InvalidArgument,
+		status.Unauthenticated,
+		status.PermissionDenied,
+		status.Internal,
+		status.Unavailable,
+	)
+
+	return u
+}
+
+// withImportLimits replaces the server read and write timeouts with
+// importTimeout and stops reading the body after maxImportBodySize bytes.
+func withImportLimits(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rc := http.NewResponseController(w)
+		deadline := time.Now().Add(importTimeout)
+		for _, setDeadline := range []func(time.Time) error{rc.SetReadDeadline, rc.SetWriteDeadline} {
+			if err := setDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxImportBodySize)
+		next.ServeHTTP(w, r)
+	})
+}
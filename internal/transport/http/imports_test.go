@@ -0,0 +1,73 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+// importService reads the body of imports and rejects every row.
+type importService struct {
+	domain.Service
+
+	bodies []string
+}
+
+func (s *importService) Import(_ context.Context, req domain.ImportRequest) (domain.ImportResponse, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return domain.ImportResponse{}, err
+	}
+	s.bodies = append(s.bodies, string(body))
+
+	return domain.ImportResponse{
+		Rows:          1001,
+		Failed:        1001,
+		Errors:        []domain.ImportRowError{{Row: 1, ExternalID: "p-1", Message: "email is invalid"}},
+		ErrorsDropped: 1000,
+	}, nil
+}
+
+func TestCreateImport(t *testing.T) {
+	svc := &importService{}
+	mux := http.NewServeMux()
+	NewHandler(svc, "secret").RegisterRoutes(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	post := func(token string) *http.Response {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/imports?type=players", strings.NewReader("external_id\np-1\n"))
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, post("").StatusCode)
+	assert.Equal(t, http.StatusUnauthorized, post("wrong").StatusCode)
+	assert.Empty(t, svc.bodies)
+
+	resp := post("secret")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var output createImportOutput
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&output))
+	assert.Equal(t, 1001, output.Failed)
+	assert.Len(t, output.Errors, 1)
+	assert.Equal(t, 1000, output.ErrorsDropped)
+	assert.Equal(t, []string{"external_id\np-1\n"}, svc.bodies)
+}
@@ -0,0 +1,64 @@
+package http
+
+import (
+	"context"
+	"time"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+	"github.com/swaggest/usecase"
+	"github.com/swaggest/usecase/status"
+)
+
+type getLeaderboardInput struct {
+	Limit       int       `query:"limit" default:"10" minimum:"1" maximum:"100" description:"Rank cutoff, not a row count: players tied on a rank share it, so the list can be longer. At most 1000 entries are returned"`
+	CountryCode string    `query:"country_code" pattern:"^[A-Za-z]{2}$" description:"Restrict the leaderboard to a single country (ISO 3166-1 alpha-2)"`
+	From        time.Time `query:"from" description:"Only count bets placed at or after this time (RFC 3339)"`
+	To          time.Time `query:"to" description:"Only count bets placed before this time (RFC 3339)"`
+	Currency    string    `query:"currency" default:"EUR" pattern:"^[A-Za-z]{3}$" description:"ISO 4217 currency the bets are converted into per day and bet currency, using the exchange rates of the day"`
+}
+
+type getLeaderboardOutput struct {
+	Currency string                     `json:"currency" description:"Currency of all amounts"`
+	Entries  []LeaderboardEntryResponse `json:"entries"`
+}
+
+func (h *Handler) getLeaderboard() usecase.Interactor {
+	u := usecase.NewInteractor(func(ctx context.Context, input getLeaderboardInput, output *getLeaderboardOutput) error {
+		resp, err := h.service.GetLeaderboard(ctx, domain.GetLeaderboardRequest{
+			Limit:       input.Limit,
+			CountryCode: input.CountryCode,
+			From:        input.From,
+			To:          input.To,
+			Currency:    input.Currency,
+		})
+		if err != nil {
+			return toStatusError(err)
+		}
+
+		output.Currency = resp.Currency
+		output.Entries = make([]LeaderboardEntryResponse, 0, len(resp.Entries))
+		for _, entry := range resp.Entries {
+			output.Entries = append(output.Entries, LeaderboardEntryResponse{
+				Rank:         entry.Rank,
+				PlayerID:     entry.PlayerID,
+				Name:         entry.Name,
+				CountryCode:  entry.CountryCode,
+				TotalWagered: Money(entry.TotalWagered),
+				BetCount:     entry.BetCount,
+			})
+		}
+
+		return nil
+	})
+
+	u.SetTitle("Get Player Leaderboard")
+	u.SetDescription("Returns the top players by total amount wagered, globally or within a single country")
+	u.SetTags("Statistics")
+
+	u.SetExpectedErrors(
+		status.InvalidArgument,
+		status.Internal,
+	)
+
+	return u
+}
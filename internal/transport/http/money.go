@@ -0,0 +1,29 @@
+package http
+
+import (
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+)
+
+// Money carries a domain.Money as a JSON number with exactly two decimal
+// places, e.g. 12.50. Decoding rejects amounts with more decimal places
+// instead of rounding them.
+type Money domain.Money
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(domain.Money(m).String()), nil
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	parsed, err := domain.ParseMoney(string(data))
+	if err != nil {
+		return err
+	}
+	*m = Money(parsed)
+	return nil
+}
+
+// JSONSchemaBytes documents Money as a number instead of the integer it is
+// backed by.
+func (Money) JSONSchemaBytes() ([]byte, error) {
+	return []byte(`{"type":"number","multipleOf":0.01}`), nil
+}
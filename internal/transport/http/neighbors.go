@@ -0,0 +1,59 @@
+package http
+
+import (
+	"context"
+	"time"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+	"github.com/swaggest/usecase"
+	"github.com/swaggest/usecase/status"
+)
+
+type getNeighborComparisonInput struct {
+	Code string    `path:"code" pattern:"^[A-Za-z]{2}$" description:"Country to compare (ISO 3166-1 alpha-2)"`
+	From time.Time `query:"from" description:"Only count bets placed at or after this time (RFC 3339)"`
+	To   time.Time `query:"to" description:"Only count bets placed before this time (RFC 3339)"`
+
+	Currency string `query:"currency" default:"EUR" pattern:"^[A-Za-z]{3}$" description:"ISO 4217 currency the bets are converted into per day and bet currency, using the exchange rates of the day"`
+}
+
+type getNeighborComparisonOutput struct {
+	Currency  string                       `json:"currency" description:"Currency of all amounts"`
+	Country   CountryPlayerStatsResponse   `json:"country"`
+	Neighbors []CountryPlayerStatsResponse `json:"neighbors" description:"Bordering countries, countries without players have zero stats"`
+}
+
+func (h *Handler) getNeighborComparison() usecase.Interactor {
+	u := usecase.NewInteractor(func(ctx context.Context, input getNeighborComparisonInput, output *getNeighborComparisonOutput) error {
+		resp, err := h.service.GetNeighborComparison(ctx, domain.GetNeighborComparisonRequest{
+			CountryCode: input.Code,
+			From:        input.From,
+			To:          input.To,
+			Currency:    input.Currency,
+		})
+		if err != nil {
+			return toStatusError(err)
+		}
+
+		output.Currency = resp.Currency
+		output.Country = toCountryPlayerStatsResponse(resp.Country)
+		output.Neighbors = make([]CountryPlayerStatsResponse, 0, len(resp.Neighbors))
+		for _, neighbor := range resp.Neighbors {
+			output.Neighbors = append(output.Neighbors, toCountryPlayerStatsResponse(neighbor))
+		}
+
+		return nil
+	})
+
+	u.SetTitle("Compare Country With Neighbors")
+	u.SetDescription("Returns the player activity of a country next to the activity of every bordering country. When a from/to window is given only players that bet inside it are counted.")
+	u.SetTags("Statistics")
+
+	u.SetExpectedErrors(
+		status.InvalidArgument,
+		status.Internal,
+		status.Unavailable,
+	)
+
+	return u
+}
@@ -0,0 +1,245 @@
+package http
+
+import (
+	"context"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+	"github.com/swaggest/usecase"
+	"github.com/swaggest/usecase/status"
+)
+
+type createPlayerInput struct {
+	Name        string `json:"name" required:"true" minLength:"1" maxLength:"255" description:"Full name of the player"`
+	Email       string `json:"email" required:"true" format:"email" maxLength:"255" description:"Email address of the player, must be unique"`
+	CountryCode string `json:"country_code" required:"true" pattern:"^[A-Za-z]{2}$" description:"ISO 3166-1 alpha-2 country code"`
+}
+
+func (h *Handler) createPlayer() usecase.Interactor {
+	u := usecase.NewInteractor(func(ctx context.Context, input createPlayerInput, output *PlayerResponse) error {
+		resp, err := h.service.CreatePlayer(ctx, domain.CreatePlayerRequest{
+			Name:        input.Name,
+			Email:       input.Email,
+			CountryCode: input.CountryCode,
+		})
+		if err != nil {
+			return toStatusError(err)
+		}
+
+		*output = toPlayerResponse(resp.Player)
+
+		return nil
+	})
+
+	u.SetTitle("Create Player")
+	u.SetDescription("Creates a new player")
+	u.SetTags("Players")
+
+	u.SetExpectedErrors(
+		status.InvalidArgument,
+		status.AlreadyExists,
+		status.Internal,
+	)
+
+	return u
+}
+
+type getPlayerInput struct {
+	ID int `path:"id" minimum:"1" description:"Player ID"`
+}
+
+func (h *Handler) getPlayer() usecase.Interactor {
+	u := usecase.NewInteractor(func(ctx context.Context, input getPlayerInput, output *PlayerResponse) error {
+		resp, err := h.service.GetPlayer(ctx, domain.GetPlayerRequest{
+			ID: input.ID,
+		})
+		if err != nil {
+			return toStatusError(err)
+		}
+
+		*output = toPlayerResponse(resp.Player)
+
+		return nil
+	})
+
+	u.SetTitle("Get Player")
+	u.SetDescription("Returns a single player by ID")
+	u.SetTags("Players")
+
+	u.SetExpectedErrors(
+		status.InvalidArgument,
+		status.NotFound,
+		status.Internal,
+	)
+
+	return u
+}
+
+type listPlayersInput struct {
+	Limit  int `query:"limit" default:"50" minimum:"1" maximum:"500" description:"Maximum number of players to return"`
+	Offset int `query:"offset" default:"0" minimum:"0" description:"Number of players to skip"`
+}
+
+type listPlayersOutput struct {
+	Players []PlayerResponse `json:"players"`
+}
+
+func (h *Handler) listPlayers() usecase.Interactor {
+	u := usecase.NewInteractor(func(ctx context.Context, input listPlayersInput, output *listPlayersOutput) error {
+		resp, err := h.service.ListPlayers(ctx, domain.ListPlayersRequest{
+			Limit:  input.Limit,
+			Offset: input.Offset,
+		})
+		if err != nil {
+			return toStatusError(err)
+		}
+
+		output.Players = make([]PlayerResponse, 0, len(resp.Players))
+		for _, player := range resp.Players {
+			output.Players = append(output.Players, toPlayerResponse(player))
+		}
+
+		return nil
+	})
+
+	u.SetTitle("List Players")
+	u.SetDescription("Returns players ordered by ID")
+	u.SetTags("Players")
+
+	u.SetExpectedErrors(
+		status.InvalidArgument,
+		status.Internal,
+	)
+
+	return u
+}
+
+type updatePlayerInput struct {
+	ID          int    `path:"id" minimum:"1" description:"Player ID"`
+	Name        string `json:"name" required:"true" minLength:"1" maxLength:"255" description:"Full name of the player"`
+	Email       string `json:"email" required:"true" format:"email" maxLength:"255" description:"Email address of the player, must be unique"`
+	CountryCode string `json:"country_code" required:"true" pattern:"^[A-Za-z]{2}$" description:"ISO 3166-1 alpha-2 country code"`
+}
+
+func (h *Handler) updatePlayer() usecase.Interactor {
+	u := usecase.NewInteractor(func(ctx context.Context, input updatePlayerInput, output *PlayerResponse) error {
+		resp, err := h.service.UpdatePlayer(ctx, domain.UpdatePlayerRequest{
+			ID:          input.ID,
+			Name:        input.Name,
+			Email:       input.Email,
+			CountryCode: input.CountryCode,
+		})
+		if err != nil {
+			return toStatusError(err)
+		}
+
+		*output = toPlayerResponse(resp.Player)
+
+		return nil
+	})
+
+	u.SetTitle("Update Player")
+	u.SetDescription("Replaces the name, email and country of an existing player. Updating an erased player conflicts.")
+	u.SetTags("Players")
+
+	u.SetExpectedErrors(
+		status.InvalidArgument,
+		status.NotFound,
+		status.AlreadyExists,
+		status.Internal,
+	)
+
+	return u
+}
+
+type deletePlayerInput struct {
+	ID int `path:"id" minimum:"1" description:"Player ID"`
+}
+
+func (h *Handler) deletePlayer() usecase.Interactor {
+	u := usecase.NewInteractor(func(ctx context.Context, input deletePlayerInput, _ *struct{}) error {
+		err := h.service.DeletePlayer(ctx, domain.DeletePlayerRequest{
+			ID: input.ID,
+		})
+		if err != nil {
+			return toStatusError(err)
+		}
+
+		return nil
+	})
+
+	u.SetTitle("Delete Player")
+	u.SetDescription("Deletes a player together with all of their bets")
+	u.SetTags("Players")
+
+	u.SetExpectedErrors(
+		status.InvalidArgument,
+		status.NotFound,
+		status.Internal,
+	)
+
+	return u
+}
+
+type getPlayerStatsInput struct {
+	ID       int    `path:"id" minimum:"1" description:"Player ID"`
+	Currency string `query:"currency" default:"EUR" pattern:"^[A-Za-z]{3}$" description:"ISO 4217 currency the bets are converted into per day and bet currency, using the exchange rates of the day"`
+}
+
+func (h *Handler) getPlayerStats() usecase.Interactor {
+	u := usecase.NewInteractor(func(ctx context.Context, input getPlayerStatsInput, output *PlayerStatsResponse) error {
+		resp, err := h.service.GetPlayerStats(ctx, domain.GetPlayerStatsRequest{
+			PlayerID: input.ID,
+			Currency: input.Currency,
+		})
+		if err != nil {
+			return toStatusError(err)
+		}
+
+		stats := resp.Stats
+		*output = PlayerStatsResponse{
+			Currency:     resp.Currency,
+			Player:       toPlayerResponse(stats.Player),
+			BetCount:     stats.BetCount,
+			TotalWagered: Money(stats.TotalWagered),
+			AvgBet:       Money(stats.AvgBet),
+			MinBet:       Money(stats.MinBet),
+			MaxBet:       Money(stats.MaxBet),
+			CountryInfo:  toCountryInfo(stats.CountryInfo),
+		}
+		if !stats.FirstBetAt.IsZero() {
+			output.FirstBetAt = &stats.FirstBetAt
+		}
+		if !stats.LastBetAt.IsZero() {
+			output.LastBetAt = &stats.LastBetAt
+		}
+
+		return nil
+	})
+
+	u.SetTitle("Get Player Statistics")
+	u.SetDescription("Returns betting statistics of a single player with enriched country information")
+	u.SetTags("Players", "Statistics")
+
+	u.SetExpectedErrors(
+		status.InvalidArgument,
+		status.NotFound,
+		status.Internal,
+	)
+
+	return u
+}
+
+func toPlayerResponse(p domain.Player) PlayerResponse {
+	response := PlayerResponse{
+		ID:          p.ID,
+		Name:        p.Name,
+		Email:       p.Email,
+		CountryCode: p.CountryCode,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+	}
+	if !p.ErasedAt.IsZero() {
+		response.ErasedAt = &p.ErasedAt
+	}
+	return response
+}
@@ -0,0 +1,78 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Nikola-Milovic/vyking-interview/internal/domain"
+	"github.com/swaggest/usecase"
+	"github.com/swaggest/usecase/status"
+)
+
+type getCountryActivityInput struct {
+	From        time.Time `query:"from" required:"true" description:"Start of the range, inclusive (RFC 3339)"`
+	To          time.Time `query:"to" required:"true" description:"End of the range, exclusive (RFC 3339)"`
+	Interval    string    `query:"interval" default:"day" enum:"day,week,month" description:"Bucket size, weeks start on Monday"`
+	Timezone    string    `query:"timezone" default:"UTC" description:"IANA time zone bucket boundaries are aligned to, e.g. Europe/Belgrade"`
+	CountryCode string    `query:"country_code" pattern:"^[A-Za-z]{2}$" description:"Restrict the series to a single country (ISO 3166-1 alpha-2)"`
+	Currency    string    `query:"currency" default:"EUR" pattern:"^[A-Za-z]{3}$" description:"ISO 4217 currency the bets are converted into per day and bet currency, using the exchange rates of the day"`
+}
+
+type getCountryActivityOutput struct {
+	Currency string                          `json:"currency" description:"Currency of all amounts"`
+	Series   []CountryActivitySeriesResponse `json:"series"`
+}
+
+func (h *Handler) getCountryActivity() usecase.Interactor {
+	u := usecase.NewInteractor(func(ctx context.Context, input getCountryActivityInput, output *getCountryActivityOutput) error {
+		loc, err := time.LoadLocation(input.Timezone)
+		if err != nil {
+			return status.Wrap(fmt.Errorf("unknown timezone %q", input.Timezone), status.InvalidArgument)
+		}
+
+		resp, err := h.service.GetCountryActivityTimeSeries(ctx, domain.GetCountryActivityTimeSeriesRequest{
+			From:        input.From,
+			To:          input.To,
+			Interval:    domain.BucketInterval(input.Interval),
+			Location:    loc,
+			CountryCode: input.CountryCode,
+			Currency:    input.Currency,
+		})
+		if err != nil {
+			return toStatusError(err)
+		}
+
+		output.Currency = resp.Currency
+		output.Series = make([]CountryActivitySeriesResponse, 0, len(resp.Series))
+		for _, series := range resp.Series {
+			buckets := make([]CountryActivityBucketResponse, 0, len(series.Buckets))
+			for _, bucket := range series.Buckets {
+				buckets = append(buckets, CountryActivityBucketResponse{
+					Start:         bucket.Start,
+					TotalBets:     Money(bucket.TotalBets),
+					BetCount:      bucket.BetCount,
+					ActivePlayers: bucket.ActivePlayers,
+				})
+			}
+
+			output.Series = append(output.Series, CountryActivitySeriesResponse{
+				CountryCode: series.CountryCode,
+				Buckets:     buckets,
+			})
+		}
+
+		return nil
+	})
+
+	u.SetTitle("Get Country Activity Time Series")
+	u.SetDescription("Returns bet totals and active player counts per country, bucketed by day, week or month")
+	u.SetTags("Statistics")
+
+	u.SetExpectedErrors(
+		status.InvalidArgument,
+		status.Internal,
+	)
+
+	return u
+}
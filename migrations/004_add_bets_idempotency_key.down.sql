@@ -0,0 +1,3 @@
+DROP INDEX idx_bets_idempotency_key ON bets;
+
+ALTER TABLE bets DROP COLUMN idempotency_key;
@@ -0,0 +1,33 @@
+DROP PROCEDURE IF EXISTS GetTopCountriesByPlayerActivity;
+
+-- from_ts is inclusive and to_ts exclusive, NULL leaves that side of the window open.
+-- Without a window every registered player is counted, with one only players
+-- that placed a bet inside the window are.
+CREATE PROCEDURE GetTopCountriesByPlayerActivity(IN limit_count INT, IN from_ts TIMESTAMP, IN to_ts TIMESTAMP)
+BEGIN
+    DECLARE window_start TIMESTAMP DEFAULT COALESCE(from_ts, TIMESTAMP '1970-01-01 00:00:01');
+    DECLARE window_end TIMESTAMP DEFAULT COALESCE(to_ts, TIMESTAMP '2038-01-19 03:14:07');
+    DECLARE windowed BOOLEAN DEFAULT from_ts IS NOT NULL OR to_ts IS NOT NULL;
+
+    SELECT 
+        p.country_code AS country_code,
+        COUNT(p.id) AS player_count,
+        COALESCE(SUM(b.total), 0) AS total_bets,
+        COALESCE(SUM(b.total) / COUNT(p.id), 0) AS avg_bet_per_player
+    FROM 
+        players p
+    LEFT JOIN (
+        SELECT player_id, SUM(amount) AS total
+        FROM bets
+        WHERE created_at >= window_start AND created_at < window_end
+        GROUP BY player_id
+    ) b ON p.id = b.player_id
+    WHERE 
+        NOT windowed OR b.player_id IS NOT NULL
+    GROUP BY 
+        p.country_code
+    ORDER BY 
+        player_count DESC,
+        total_bets DESC
+    LIMIT limit_count;
+END;
@@ -0,0 +1,111 @@
+-- payout is what a won bet paid out, in the currency of the bet. It stays 0 for
+-- open, lost and void bets.
+ALTER TABLE bets
+    ADD COLUMN status ENUM('open', 'won', 'lost', 'void') NOT NULL DEFAULT 'open',
+    ADD COLUMN payout DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN settled_at TIMESTAMP NULL;
+
+DROP PROCEDURE IF EXISTS GetTopCountriesByPlayerActivity;
+
+-- from_ts is inclusive and to_ts exclusive, NULL leaves that side of the window open.
+-- Without a window every registered player is counted, with one only players
+-- that placed a bet inside the window are.
+-- sort_by is one of player_count, total_bets, avg_bet_per_player or bet_count and
+-- sort_order is asc or desc. Ties are broken by total_bets and then country_code.
+-- The after_* parameters hold the ranking key of the last row of the previous
+-- page, only rows ranked strictly after it are returned. A NULL
+-- after_country_code starts from the first row.
+-- Bet amounts are converted into target_currency with the exchange rates
+-- effective when each bet was placed and rounded to the cent before summing.
+-- ggr is the stake minus the payout of won and lost bets, settled_stakes the
+-- stake of those bets. Open and void bets do not count towards either.
+CREATE PROCEDURE GetTopCountriesByPlayerActivity(
+    IN limit_count INT,
+    IN from_ts TIMESTAMP,
+    IN to_ts TIMESTAMP,
+    IN sort_by VARCHAR(32),
+    IN sort_order VARCHAR(4),
+    IN after_sort_value DECIMAL(65, 6),
+    IN after_total_bets DECIMAL(65, 2),
+    IN after_country_code VARCHAR(2),
+    IN target_currency CHAR(3)
+)
+BEGIN
+    DECLARE window_start TIMESTAMP DEFAULT COALESCE(from_ts, TIMESTAMP '1970-01-01 00:00:01');
+    DECLARE window_end TIMESTAMP DEFAULT COALESCE(to_ts, TIMESTAMP '2038-01-19 03:14:07');
+    DECLARE windowed BOOLEAN DEFAULT from_ts IS NOT NULL OR to_ts IS NOT NULL;
+
+    SELECT
+        s.country_code,
+        s.player_count,
+        s.total_bets,
+        s.avg_bet_per_player,
+        s.bet_count,
+        s.settled_stakes,
+        s.ggr,
+        s.win_count
+    FROM (
+        SELECT
+            a.*,
+            CASE sort_by
+                WHEN 'total_bets' THEN a.total_bets
+                WHEN 'avg_bet_per_player' THEN a.avg_bet_per_player
+                WHEN 'bet_count' THEN a.bet_count
+                ELSE a.player_count
+            END AS sort_value
+        FROM (
+            SELECT 
+                p.country_code AS country_code,
+                COUNT(p.id) AS player_count,
+                COALESCE(SUM(b.total), 0) AS total_bets,
+                COALESCE(SUM(b.total) / COUNT(p.id), 0) AS avg_bet_per_player,
+                COALESCE(SUM(b.bet_count), 0) AS bet_count,
+                COALESCE(SUM(b.settled_stakes), 0) AS settled_stakes,
+                COALESCE(SUM(b.settled_stakes) - SUM(b.payouts), 0) AS ggr,
+                COALESCE(SUM(b.win_count), 0) AS win_count
+            FROM 
+                players p
+            LEFT JOIN (
+                SELECT
+                    bets.player_id,
+                    SUM(ROUND(bets.amount * tgt.rate / src.rate, 2)) AS total,
+                    COUNT(*) AS bet_count,
+                    SUM(CASE WHEN bets.status IN ('won', 'lost') THEN ROUND(bets.amount * tgt.rate / src.rate, 2) ELSE 0 END) AS settled_stakes,
+                    SUM(CASE WHEN bets.status IN ('won', 'lost') THEN ROUND(bets.payout * tgt.rate / src.rate, 2) ELSE 0 END) AS payouts,
+                    SUM(CASE WHEN bets.status = 'won' THEN 1 ELSE 0 END) AS win_count
+                FROM bets
+                JOIN exchange_rate_periods src
+                    ON src.currency = bets.currency
+                    AND (src.valid_from IS NULL OR bets.created_at >= src.valid_from)
+                    AND (src.valid_to IS NULL OR bets.created_at < src.valid_to)
+                JOIN exchange_rate_periods tgt
+                    ON tgt.currency = target_currency
+                    AND (tgt.valid_from IS NULL OR bets.created_at >= tgt.valid_from)
+                    AND (tgt.valid_to IS NULL OR bets.created_at < tgt.valid_to)
+                WHERE bets.created_at >= window_start AND bets.created_at < window_end
+                GROUP BY bets.player_id
+            ) b ON p.id = b.player_id
+            WHERE 
+                NOT windowed OR b.player_id IS NOT NULL
+            GROUP BY 
+                p.country_code
+        ) a
+    ) s
+    WHERE
+        after_country_code IS NULL
+        OR (sort_order = 'asc' AND s.sort_value > after_sort_value)
+        OR (sort_order = 'desc' AND s.sort_value < after_sort_value)
+        OR (
+            s.sort_value = after_sort_value
+            AND (
+                s.total_bets < after_total_bets
+                OR (s.total_bets = after_total_bets AND s.country_code > after_country_code)
+            )
+        )
+    ORDER BY 
+        CASE WHEN sort_order = 'asc' THEN s.sort_value END ASC,
+        CASE WHEN sort_order = 'desc' THEN s.sort_value END DESC,
+        s.total_bets DESC,
+        s.country_code ASC
+    LIMIT limit_count;
+END;
@@ -0,0 +1,4 @@
+DROP PROCEDURE IF EXISTS GetTopCountriesByPlayerActivityFromRollup;
+
+DROP TABLE IF EXISTS player_activity_days;
+DROP TABLE IF EXISTS country_daily_stats;
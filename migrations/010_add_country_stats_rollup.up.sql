@@ -0,0 +1,149 @@
+-- Per country, day and bet currency aggregates of bets, kept in the currency of
+-- the bets so they can be converted into any currency when read. Days are UTC
+-- dates of created_at and a bet counts towards the country of its player. The
+-- store updates both rollup tables in the same transaction as the bets and
+-- players they aggregate.
+CREATE TABLE IF NOT EXISTS country_daily_stats (
+    country_code VARCHAR(2) NOT NULL,
+    day DATE NOT NULL,
+    currency CHAR(3) NOT NULL,
+    bet_count INT NOT NULL,
+    total_amount DECIMAL(20, 2) NOT NULL,
+    settled_stakes DECIMAL(20, 2) NOT NULL,
+    payouts DECIMAL(22, 2) NOT NULL,
+    win_count INT NOT NULL,
+    PRIMARY KEY (country_code, day, currency)
+);
+
+CREATE INDEX idx_country_daily_stats_day ON country_daily_stats(day);
+
+-- The days a player placed at least one bet, distinct players can not be summed
+-- up from country_daily_stats.
+CREATE TABLE IF NOT EXISTS player_activity_days (
+    player_id INT NOT NULL,
+    day DATE NOT NULL,
+    PRIMARY KEY (player_id, day),
+    FOREIGN KEY (player_id) REFERENCES players(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_player_activity_days_day ON player_activity_days(day);
+
+INSERT INTO country_daily_stats (country_code, day, currency, bet_count, total_amount, settled_stakes, payouts, win_count)
+SELECT
+    p.country_code,
+    DATE(b.created_at),
+    b.currency,
+    COUNT(*),
+    SUM(b.amount),
+    SUM(CASE WHEN b.status IN ('won', 'lost') THEN b.amount ELSE 0 END),
+    SUM(CASE WHEN b.status IN ('won', 'lost') THEN b.payout ELSE 0 END),
+    SUM(CASE WHEN b.status = 'won' THEN 1 ELSE 0 END)
+FROM bets b
+JOIN players p ON p.id = b.player_id
+GROUP BY p.country_code, DATE(b.created_at), b.currency;
+
+INSERT INTO player_activity_days (player_id, day)
+SELECT DISTINCT player_id, DATE(created_at) FROM bets;
+
+-- GetTopCountriesByPlayerActivityFromRollup returns the same rows as
+-- GetTopCountriesByPlayerActivity, reading country_daily_stats and
+-- player_activity_days instead of the bets. The window is truncated to whole
+-- days, so it is only used for windows that start and end at midnight UTC.
+-- Amounts are converted per day and currency rather than per bet, which can
+-- differ from GetTopCountriesByPlayerActivity by the rounding of a few cents.
+CREATE PROCEDURE GetTopCountriesByPlayerActivityFromRollup(
+    IN limit_count INT,
+    IN from_ts TIMESTAMP,
+    IN to_ts TIMESTAMP,
+    IN sort_by VARCHAR(32),
+    IN sort_order VARCHAR(4),
+    IN after_sort_value DECIMAL(65, 6),
+    IN after_total_bets DECIMAL(65, 2),
+    IN after_country_code VARCHAR(2),
+    IN target_currency CHAR(3)
+)
+BEGIN
+    DECLARE window_start DATE DEFAULT COALESCE(DATE(from_ts), DATE '1970-01-01');
+    DECLARE window_end DATE DEFAULT COALESCE(DATE(to_ts), DATE '2038-01-20');
+    DECLARE windowed BOOLEAN DEFAULT from_ts IS NOT NULL OR to_ts IS NOT NULL;
+
+    SELECT
+        s.country_code,
+        s.player_count,
+        s.total_bets,
+        s.avg_bet_per_player,
+        s.bet_count,
+        s.settled_stakes,
+        s.ggr,
+        s.win_count
+    FROM (
+        SELECT
+            a.*,
+            CASE sort_by
+                WHEN 'total_bets' THEN a.total_bets
+                WHEN 'avg_bet_per_player' THEN a.avg_bet_per_player
+                WHEN 'bet_count' THEN a.bet_count
+                ELSE a.player_count
+            END AS sort_value
+        FROM (
+            SELECT
+                p.country_code AS country_code,
+                p.player_count AS player_count,
+                COALESCE(d.total, 0) AS total_bets,
+                COALESCE(d.total / p.player_count, 0) AS avg_bet_per_player,
+                COALESCE(d.bet_count, 0) AS bet_count,
+                COALESCE(d.settled_stakes, 0) AS settled_stakes,
+                COALESCE(d.settled_stakes - d.payouts, 0) AS ggr,
+                COALESCE(d.win_count, 0) AS win_count
+            FROM (
+                SELECT country_code, COUNT(*) AS player_count
+                FROM players
+                WHERE NOT windowed
+                GROUP BY country_code
+                UNION ALL
+                SELECT players.country_code, COUNT(DISTINCT activity.player_id)
+                FROM player_activity_days activity
+                JOIN players ON players.id = activity.player_id
+                WHERE windowed AND activity.day >= window_start AND activity.day < window_end
+                GROUP BY players.country_code
+            ) p
+            LEFT JOIN (
+                SELECT
+                    r.country_code,
+                    SUM(ROUND(r.total_amount * tgt.rate / src.rate, 2)) AS total,
+                    SUM(r.bet_count) AS bet_count,
+                    SUM(ROUND(r.settled_stakes * tgt.rate / src.rate, 2)) AS settled_stakes,
+                    SUM(ROUND(r.payouts * tgt.rate / src.rate, 2)) AS payouts,
+                    SUM(r.win_count) AS win_count
+                FROM country_daily_stats r
+                JOIN exchange_rate_periods src
+                    ON src.currency = r.currency
+                    AND (src.valid_from IS NULL OR r.day >= src.valid_from)
+                    AND (src.valid_to IS NULL OR r.day < src.valid_to)
+                JOIN exchange_rate_periods tgt
+                    ON tgt.currency = target_currency
+                    AND (tgt.valid_from IS NULL OR r.day >= tgt.valid_from)
+                    AND (tgt.valid_to IS NULL OR r.day < tgt.valid_to)
+                WHERE r.day >= window_start AND r.day < window_end
+                GROUP BY r.country_code
+            ) d ON d.country_code = p.country_code
+        ) a
+    ) s
+    WHERE
+        after_country_code IS NULL
+        OR (sort_order = 'asc' AND s.sort_value > after_sort_value)
+        OR (sort_order = 'desc' AND s.sort_value < after_sort_value)
+        OR (
+            s.sort_value = after_sort_value
+            AND (
+                s.total_bets < after_total_bets
+                OR (s.total_bets = after_total_bets AND s.country_code > after_country_code)
+            )
+        )
+    ORDER BY
+        CASE WHEN sort_order = 'asc' THEN s.sort_value END ASC,
+        CASE WHEN sort_order = 'desc' THEN s.sort_value END DESC,
+        s.total_bets DESC,
+        s.country_code ASC
+    LIMIT limit_count;
+END;
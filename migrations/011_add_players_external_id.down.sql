@@ -0,0 +1,3 @@
+DROP INDEX idx_players_external_id ON players;
+
+ALTER TABLE players DROP COLUMN external_id;
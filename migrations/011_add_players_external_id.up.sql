@@ -0,0 +1,5 @@
+-- external_id is the ID of a player imported from another platform, NULL for
+-- players registered here.
+ALTER TABLE players ADD COLUMN external_id VARCHAR(255) NULL;
+
+CREATE UNIQUE INDEX idx_players_external_id ON players(external_id);
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS player_erasures_no_delete;
+
+DROP TRIGGER IF EXISTS player_erasures_no_update;
+
+DROP TABLE IF EXISTS player_erasures;
+
+ALTER TABLE players DROP COLUMN erased_at;
@@ -0,0 +1,21 @@
+-- erased_at is set once a player's personal data was anonymized on request. The
+-- player and their bets are kept so aggregates stay correct.
+ALTER TABLE players ADD COLUMN erased_at TIMESTAMP NULL;
+
+-- Log of erasure requests. There is no foreign key, so the log outlives the
+-- players it lists, and the triggers keep rows from being changed or removed.
+CREATE TABLE IF NOT EXISTS player_erasures (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    player_id INT NOT NULL,
+    requested_by VARCHAR(255) NOT NULL,
+    reason VARCHAR(1000) NOT NULL DEFAULT '',
+    erased_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_player_erasures_player_id ON player_erasures(player_id);
+
+CREATE TRIGGER player_erasures_no_update BEFORE UPDATE ON player_erasures
+FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'player_erasures is append-only';
+
+CREATE TRIGGER player_erasures_no_delete BEFORE DELETE ON player_erasures
+FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'player_erasures is append-only';
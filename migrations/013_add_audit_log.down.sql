@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS audit_log_no_delete;
+
+DROP TRIGGER IF EXISTS audit_log_no_update;
+
+DROP TABLE IF EXISTS audit_log;
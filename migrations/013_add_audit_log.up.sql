@@ -0,0 +1,36 @@
+-- Append-only log of every change of a player or bet, written by the store in
+-- the same transaction as the change. before_values and after_values hold the
+-- values of the entity around the change and are NULL when it did not exist.
+-- There are no foreign keys, so entries outlive the entities they describe.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    entity_type VARCHAR(16) NOT NULL,
+    entity_id INT NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    before_values JSON NULL,
+    after_values JSON NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- redacted_at is set once the personal data of an erased player was
+    -- replaced in the values.
+    redacted_at TIMESTAMP NULL
+);
+
+CREATE INDEX idx_audit_log_entity ON audit_log(entity_type, entity_id);
+CREATE INDEX idx_audit_log_actor ON audit_log(actor);
+CREATE INDEX idx_audit_log_created_at ON audit_log(created_at);
+
+-- Entries can not be deleted, and the only update is redacting the values of
+-- an entry once.
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+FOR EACH ROW
+BEGIN
+    IF OLD.redacted_at IS NOT NULL OR NEW.redacted_at IS NULL
+        OR NOT (NEW.id <=> OLD.id AND NEW.entity_type <=> OLD.entity_type AND NEW.entity_id <=> OLD.entity_id
+            AND NEW.action <=> OLD.action AND NEW.actor <=> OLD.actor AND NEW.created_at <=> OLD.created_at) THEN
+        SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only';
+    END IF;
+END;
+
+CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log
+FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only';
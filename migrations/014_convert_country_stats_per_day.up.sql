@@ -0,0 +1,126 @@
+DROP PROCEDURE IF EXISTS GetTopCountriesByPlayerActivity;
+
+-- from_ts is inclusive and to_ts exclusive, NULL leaves that side of the window open.
+-- Without a window every registered player is counted, with one only players
+-- that placed a bet inside the window are.
+-- sort_by is one of player_count, total_bets, avg_bet_per_player or bet_count and
+-- sort_order is asc or desc. Ties are broken by total_bets and then country_code.
+-- The after_* parameters hold the ranking key of the last row of the previous
+-- page, only rows ranked strictly after it are returned. A NULL
+-- after_country_code starts from the first row.
+-- The bets inside the window are summed up per country, UTC day and bet
+-- currency the way country_daily_stats holds them, and each sum is converted
+-- into target_currency with the exchange rates effective on the day and rounded
+-- to the cent, so that the result matches
+-- GetTopCountriesByPlayerActivityFromRollup for the same window.
+-- ggr is the stake minus the payout of won and lost bets, settled_stakes the
+-- stake of those bets. Open and void bets do not count towards either.
+CREATE PROCEDURE GetTopCountriesByPlayerActivity(
+    IN limit_count INT,
+    IN from_ts TIMESTAMP,
+    IN to_ts TIMESTAMP,
+    IN sort_by VARCHAR(32),
+    IN sort_order VARCHAR(4),
+    IN after_sort_value DECIMAL(65, 6),
+    IN after_total_bets DECIMAL(65, 2),
+    IN after_country_code VARCHAR(2),
+    IN target_currency CHAR(3)
+)
+BEGIN
+    DECLARE window_start TIMESTAMP DEFAULT COALESCE(from_ts, TIMESTAMP '1970-01-01 00:00:01');
+    DECLARE window_end TIMESTAMP DEFAULT COALESCE(to_ts, TIMESTAMP '2038-01-19 03:14:07');
+    DECLARE windowed BOOLEAN DEFAULT from_ts IS NOT NULL OR to_ts IS NOT NULL;
+
+    SELECT
+        s.country_code,
+        s.player_count,
+        s.total_bets,
+        s.avg_bet_per_player,
+        s.bet_count,
+        s.settled_stakes,
+        s.ggr,
+        s.win_count
+    FROM (
+        SELECT
+            a.*,
+            CASE sort_by
+                WHEN 'total_bets' THEN a.total_bets
+                WHEN 'avg_bet_per_player' THEN a.avg_bet_per_player
+                WHEN 'bet_count' THEN a.bet_count
+                ELSE a.player_count
+            END AS sort_value
+        FROM (
+            SELECT
+                p.country_code AS country_code,
+                p.player_count AS player_count,
+                COALESCE(d.total, 0) AS total_bets,
+                COALESCE(d.total / p.player_count, 0) AS avg_bet_per_player,
+                COALESCE(d.bet_count, 0) AS bet_count,
+                COALESCE(d.settled_stakes, 0) AS settled_stakes,
+                COALESCE(d.settled_stakes - d.payouts, 0) AS ggr,
+                COALESCE(d.win_count, 0) AS win_count
+            FROM (
+                SELECT country_code, COUNT(*) AS player_count
+                FROM players
+                WHERE NOT windowed
+                GROUP BY country_code
+                UNION ALL
+                SELECT players.country_code, COUNT(DISTINCT bets.player_id)
+                FROM bets
+                JOIN players ON players.id = bets.player_id
+                WHERE windowed AND bets.created_at >= window_start AND bets.created_at < window_end
+                GROUP BY players.country_code
+            ) p
+            LEFT JOIN (
+                SELECT
+                    r.country_code,
+                    SUM(ROUND(r.total_amount * tgt.rate / src.rate, 2)) AS total,
+                    SUM(r.bet_count) AS bet_count,
+                    SUM(ROUND(r.settled_stakes * tgt.rate / src.rate, 2)) AS settled_stakes,
+                    SUM(ROUND(r.payouts * tgt.rate / src.rate, 2)) AS payouts,
+                    SUM(r.win_count) AS win_count
+                FROM (
+                    SELECT
+                        players.country_code,
+                        DATE(bets.created_at) AS day,
+                        bets.currency,
+                        COUNT(*) AS bet_count,
+                        SUM(bets.amount) AS total_amount,
+                        SUM(CASE WHEN bets.status IN ('won', 'lost') THEN bets.amount ELSE 0 END) AS settled_stakes,
+                        SUM(CASE WHEN bets.status IN ('won', 'lost') THEN bets.payout ELSE 0 END) AS payouts,
+                        SUM(CASE WHEN bets.status = 'won' THEN 1 ELSE 0 END) AS win_count
+                    FROM bets
+                    JOIN players ON players.id = bets.player_id
+                    WHERE bets.created_at >= window_start AND bets.created_at < window_end
+                    GROUP BY players.country_code, DATE(bets.created_at), bets.currency
+                ) r
+                JOIN exchange_rate_periods src
+                    ON src.currency = r.currency
+                    AND (src.valid_from IS NULL OR r.day >= src.valid_from)
+                    AND (src.valid_to IS NULL OR r.day < src.valid_to)
+                JOIN exchange_rate_periods tgt
+                    ON tgt.currency = target_currency
+                    AND (tgt.valid_from IS NULL OR r.day >= tgt.valid_from)
+                    AND (tgt.valid_to IS NULL OR r.day < tgt.valid_to)
+                GROUP BY r.country_code
+            ) d ON d.country_code = p.country_code
+        ) a
+    ) s
+    WHERE
+        after_country_code IS NULL
+        OR (sort_order = 'asc' AND s.sort_value > after_sort_value)
+        OR (sort_order = 'desc' AND s.sort_value < after_sort_value)
+        OR (
+            s.sort_value = after_sort_value
+            AND (
+                s.total_bets < after_total_bets
+                OR (s.total_bets = after_total_bets AND s.country_code > after_country_code)
+            )
+        )
+    ORDER BY
+        CASE WHEN sort_order = 'asc' THEN s.sort_value END ASC,
+        CASE WHEN sort_order = 'desc' THEN s.sort_value END DESC,
+        s.total_bets DESC,
+        s.country_code ASC
+    LIMIT limit_count;
+END;
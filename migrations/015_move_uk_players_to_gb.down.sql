@@ -0,0 +1,3 @@
+-- The players moved to GB can not be told apart from the ones registered there,
+-- they stay in GB.
+DO 0;
@@ -0,0 +1,20 @@
+-- UK is not an ISO 3166-1 code, the seed data and players registered before
+-- codes were validated use it for the United Kingdom, GB. Their rollup rows are
+-- merged into the GB rows of the same day and currency before the players
+-- move, player_activity_days follows the players by itself.
+INSERT INTO country_daily_stats (country_code, day, currency, bet_count, total_amount, settled_stakes, payouts, win_count)
+SELECT * FROM (
+    SELECT 'GB' AS country_code, day, currency, bet_count, total_amount, settled_stakes, payouts, win_count
+    FROM country_daily_stats
+    WHERE country_code = 'UK'
+) delta
+ON DUPLICATE KEY UPDATE
+    bet_count = country_daily_stats.bet_count + delta.bet_count,
+    total_amount = country_daily_stats.total_amount + delta.total_amount,
+    settled_stakes = country_daily_stats.settled_stakes + delta.settled_stakes,
+    payouts = country_daily_stats.payouts + delta.payouts,
+    win_count = country_daily_stats.win_count + delta.win_count;
+
+DELETE FROM country_daily_stats WHERE country_code = 'UK';
+
+UPDATE players SET country_code = 'GB' WHERE country_code = 'UK';
@@ -0,0 +1,10 @@
+// Package migrations embeds the MySQL schema migrations, so the server binary
+// can apply them without the files on disk.
+package migrations
+
+import "embed"
+
+// FS holds the up and down migrations, named the way golang-migrate expects.
+//
+//go:embed *.sql
+var FS embed.FS